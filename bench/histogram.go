@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math/bits"
+	"strings"
+	"time"
+)
+
+// Every power of 2 (in microseconds) is split into this many buckets, so a bucket is at most ~6% wide
+const subBuckets = 16
+
+// Latency histogram in microseconds. Counts are bucketed instead of keeping every latency,
+// so workers can record millions of operations and the histograms are merged at the end.
+type histogram struct {
+	counts [64 * subBuckets]int64
+	total  int64
+	max    time.Duration
+}
+
+// Values below 2*subBuckets get a bucket each, above that value>>shift lies in [subBuckets, 2*subBuckets)
+func bucketOf(micros int64) int {
+	if micros < 2*subBuckets {
+		return int(micros)
+	}
+
+	shift := bits.Len64(uint64(micros)) - 5
+
+	return (shift+1)*subBuckets + int(micros>>shift) - subBuckets
+}
+
+// Smallest and largest+1 value of the bucket, in microseconds
+func bucketRange(bucket int) (int64, int64) {
+	if bucket < 2*subBuckets {
+		return int64(bucket), int64(bucket) + 1
+	}
+
+	shift := bucket/subBuckets - 1
+	lower := int64(bucket-shift*subBuckets) << shift
+
+	return lower, lower + 1<<shift
+}
+
+func (h *histogram) record(latency time.Duration) {
+	h.recordN(latency, 1)
+}
+
+func (h *histogram) recordN(latency time.Duration, n int64) {
+	h.counts[bucketOf(latency.Microseconds())] += n
+	h.total += n
+
+	if latency > h.max {
+		h.max = latency
+	}
+}
+
+func (h *histogram) merge(other *histogram) {
+	for i, count := range other.counts {
+		h.counts[i] += count
+	}
+
+	h.total += other.total
+
+	if other.max > h.max {
+		h.max = other.max
+	}
+}
+
+// Upper bound of the bucket holding the given percentile (0-100), never more than the largest latency seen
+func (h *histogram) percentile(p float64) time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+
+	target := int64(float64(h.total)*p/100 + 0.5)
+	if target < 1 {
+		target = 1
+	}
+
+	var seen int64
+
+	for bucket, count := range h.counts {
+		seen += count
+
+		if seen >= target {
+			_, upper := bucketRange(bucket)
+
+			return min(time.Duration(upper)*time.Microsecond, h.max)
+		}
+	}
+
+	return h.max
+}
+
+// One line per power of 2 between the fastest and the slowest operation
+func (h *histogram) print(out io.Writer) {
+	if h.total == 0 {
+		return
+	}
+
+	var ranges [64]int64
+	first, last := -1, 0
+
+	for bucket, count := range h.counts {
+		if count == 0 {
+			continue
+		}
+
+		lower, _ := bucketRange(bucket)
+		power := bits.Len64(uint64(lower))
+		ranges[power] += count
+
+		if first == -1 {
+			first = power
+		}
+		last = power
+	}
+
+	var cumulative int64
+
+	for power := first; power <= last; power++ {
+		cumulative += ranges[power]
+		share := float64(ranges[power]) / float64(h.total) * 100
+
+		lower := time.Duration(0)
+		if power > 0 {
+			lower = time.Duration(int64(1)<<(power-1)) * time.Microsecond
+		}
+		upper := time.Duration(int64(1)<<power) * time.Microsecond
+
+		fmt.Fprintf(out, "  %10v - %-10v %-40v %6.2f%%  (cumulative %6.2f%%)\n",
+			formatLatency(lower), formatLatency(upper), strings.Repeat("#", int(share*40/100+0.5)), share, float64(cumulative)/float64(h.total)*100)
+	}
+}
+
+func formatLatency(latency time.Duration) string {
+	return fmt.Sprintf("%.3fms", float64(latency)/float64(time.Millisecond))
+}
@@ -0,0 +1,367 @@
+/*
+Load generator for the kv server. Opens -c connections and runs a weighted mix of operations on them,
+then prints ops/sec and p50/p99/p999 latencies per operation along with a latency histogram.
+
+	go run ./bench -c 50 -n 200000 -mix set=40,get=50,del=5,bf_add=5 -keys 100000 -size 128 -P 16
+
+With -P > 1, operations are sent in pipelines of that many and every operation of a pipeline is given
+the latency of the whole pipeline. Keys are written to the current cache under -prefix.
+*/
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+
+	"prac/kvclient"
+)
+
+var (
+	addrFlag     = flag.String("addr", kvclient.DefaultAddr, "server address")
+	connsFlag    = flag.Int("c", 50, "number of concurrent connections")
+	requestsFlag = flag.Int("n", 100000, "total number of operations")
+	durationFlag = flag.Duration("d", 0, "run for this long instead of -n operations")
+	mixFlag      = flag.String("mix", "set=50,get=50", "weights of the operations, out of set, get, del, bf_add and tx (BEGIN, SET, INCR, COMMIT)")
+	keysFlag     = flag.Int("keys", 10000, "number of distinct keys")
+	sizeFlag     = flag.Int("size", 64, "value size in bytes")
+	pipelineFlag = flag.Int("P", 1, "operations sent together in one pipeline")
+	prefixFlag   = flag.String("prefix", "bench:", "prefix of the keys written by the benchmark")
+	prefillFlag  = flag.Bool("prefill", true, "SET every key before the run, so that GET doesn't only miss")
+	timeoutFlag  = flag.Duration("timeout", 10*time.Second, "time limit for every pipeline")
+)
+
+type operation struct {
+	name   string
+	weight int
+
+	// Statements sent for one operation, a transaction is several of them
+	statements func(key string, value string) [][]string
+}
+
+func operations(prefix string) []operation {
+	bloomName := prefix + "bloom"
+	counter := prefix + "counter"
+
+	return []operation{
+		{name: "SET", statements: func(key, value string) [][]string { return [][]string{{"SET", key, value}} }},
+		{name: "GET", statements: func(key, value string) [][]string { return [][]string{{"GET", key}} }},
+		{name: "DEL", statements: func(key, value string) [][]string { return [][]string{{"DEL", key}} }},
+		{name: "BF_ADD", statements: func(key, value string) [][]string { return [][]string{{"BF_ADD", bloomName, key}} }},
+		{name: "TX", statements: func(key, value string) [][]string {
+			return [][]string{{"BEGIN"}, {"SET", key, value}, {"INCR", counter}, {"COMMIT"}}
+		}},
+	}
+}
+
+// set=50,get=50 -> operations with their weights, in the order of operations()
+func parseMix(mix string, ops []operation) ([]operation, error) {
+	weights := map[string]int{}
+
+	for _, part := range strings.Split(mix, ",") {
+		name, weight, found := strings.Cut(strings.TrimSpace(part), "=")
+		if !found {
+			return nil, fmt.Errorf("-mix : %q should be operation=weight", part)
+		}
+
+		value, err := strconv.Atoi(weight)
+		if err != nil || value < 0 {
+			return nil, fmt.Errorf("-mix : Weight of %v should be a non negative integer", name)
+		}
+
+		weights[strings.ToUpper(name)] += value
+	}
+
+	chosen := []operation{}
+
+	for _, op := range ops {
+		if weight, exists := weights[op.name]; exists {
+			delete(weights, op.name)
+
+			if weight > 0 {
+				op.weight = weight
+				chosen = append(chosen, op)
+			}
+		}
+	}
+
+	for name := range weights {
+		return nil, fmt.Errorf("-mix : Unknown operation %v, should be one of set, get, del, bf_add and tx", strings.ToLower(name))
+	}
+
+	if len(chosen) == 0 {
+		return nil, fmt.Errorf("-mix : Atleast one operation should have a positive weight")
+	}
+
+	return chosen, nil
+}
+
+type opStats struct {
+	errors    int64
+	misses    int64 // GET and DEL of keys which don't exist, the server replies ERR for these
+	latencies histogram
+}
+
+type worker struct {
+	client *kvclient.Client
+	ops    []operation
+	total  int // sum of the weights
+	keys   []string
+	value  string
+	rnd    *rand.Rand
+	stats  []opStats // same order as ops
+}
+
+func (w *worker) pick() int {
+	n := w.rnd.Intn(w.total)
+
+	for i, op := range w.ops {
+		if n < op.weight {
+			return i
+		}
+		n -= op.weight
+	}
+
+	return len(w.ops) - 1
+}
+
+// Sends count operations in one pipeline and records their latencies
+func (w *worker) runBatch(count int) {
+	pipeline := w.client.Pipeline()
+	picked := make([]int, count)
+	replyCounts := make([]int, count)
+
+	for i := range picked {
+		picked[i] = w.pick()
+		statements := w.ops[picked[i]].statements(w.keys[w.rnd.Intn(len(w.keys))], w.value)
+
+		for _, statement := range statements {
+			pipeline.Queue(statement[0], statement[1:]...)
+		}
+		replyCounts[i] = len(statements)
+	}
+
+	start := time.Now()
+	replies, err := pipeline.Exec(context.Background())
+	latency := time.Since(start)
+
+	offset := 0
+
+	for i, op := range picked {
+		stats := &w.stats[op]
+		stats.latencies.record(latency)
+
+		if err != nil {
+			stats.errors++
+			continue
+		}
+
+		for _, reply := range replies[offset : offset+replyCounts[i]] {
+			if replyErr := reply.Err(); replyErr != nil {
+				if serverErr, ok := replyErr.(kvclient.ServerError); ok && serverErr.NoKey() {
+					stats.misses++
+				} else {
+					stats.errors++
+				}
+				break
+			}
+		}
+
+		offset += replyCounts[i]
+	}
+}
+
+func main() {
+	flag.Parse()
+
+	if *connsFlag < 1 || *pipelineFlag < 1 || *keysFlag < 1 || *sizeFlag < 0 || *requestsFlag < 1 {
+		fmt.Fprintln(os.Stderr, "-c, -P, -keys and -n should be positive and -size can't be negative")
+		os.Exit(2)
+	}
+
+	ops, err := parseMix(*mixFlag, operations(*prefixFlag))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	client, err := kvclient.CreateClient(kvclient.Options{Addr: *addrFlag, PoolSize: *connsFlag, Timeout: *timeoutFlag})
+	if err == nil {
+		defer client.Close()
+		err = prepare(client, ops)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	keys := make([]string, *keysFlag)
+	for i := range keys {
+		keys[i] = *prefixFlag + "key:" + strconv.Itoa(i)
+	}
+
+	value := randomValue(*sizeFlag)
+
+	// -n is shared by the workers, every worker takes a pipeline's worth at a time
+	var remaining atomic.Int64
+	remaining.Store(int64(*requestsFlag))
+	deadline := time.Now().Add(*durationFlag)
+
+	take := func() int {
+		if *durationFlag > 0 {
+			if time.Now().After(deadline) {
+				return 0
+			}
+			return *pipelineFlag
+		}
+
+		after := remaining.Add(-int64(*pipelineFlag))
+		if after <= -int64(*pipelineFlag) {
+			return 0
+		}
+
+		return *pipelineFlag + int(min(after, 0))
+	}
+
+	workers := make([]*worker, *connsFlag)
+	var wg sync.WaitGroup
+
+	fmt.Printf("Connections %v, pipeline %v, keys %v, value size %v bytes, mix %v\n\n", *connsFlag, *pipelineFlag, *keysFlag, *sizeFlag, *mixFlag)
+
+	start := time.Now()
+
+	for i := range workers {
+		w := &worker{client: client, ops: ops, keys: keys, value: value, rnd: rand.New(rand.NewSource(time.Now().UnixNano() + int64(i))), stats: make([]opStats, len(ops))}
+		for _, op := range ops {
+			w.total += op.weight
+		}
+		workers[i] = w
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for count := take(); count > 0; count = take() {
+				w.runBatch(count)
+			}
+		}()
+	}
+
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	report(ops, workers, elapsed)
+}
+
+// Checks the server is up, creates the bloom filter for BF_ADD and writes every key when -prefill is set
+func prepare(client *kvclient.Client, ops []operation) error {
+	ctx := context.Background()
+
+	if err := client.Ping(ctx); err != nil {
+		return err
+	}
+
+	for _, op := range ops {
+		if op.name != "BF_ADD" {
+			continue
+		}
+
+		_, err := client.Do(ctx, "BF_CREATE", *prefixFlag+"bloom", "0.01", strconv.Itoa(max(*keysFlag, 1000)))
+		if err != nil && !strings.Contains(err.Error(), "already exists") {
+			return err
+		}
+	}
+
+	if !*prefillFlag {
+		return nil
+	}
+
+	value := randomValue(*sizeFlag)
+	pipeline := client.Pipeline()
+
+	for i := 0; i < *keysFlag; i++ {
+		pipeline.Queue("SET", *prefixFlag+"key:"+strconv.Itoa(i), value)
+
+		if pipeline.Len() == 1000 || i == *keysFlag-1 {
+			if _, err := pipeline.Exec(ctx); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Printable, so that values never contain \r\n
+func randomValue(size int) string {
+	const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+	value := make([]byte, size)
+	for i := range value {
+		value[i] = letters[rand.Intn(len(letters))]
+	}
+
+	return string(value)
+}
+
+func report(ops []operation, workers []*worker, elapsed time.Duration) {
+	out := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(out, "OPERATION\tOPS\tOPS/SEC\tERRORS\tMISSES\tP50\tP99\tP999\tMAX")
+
+	var all histogram
+	var errors, misses int64
+
+	names := make([]string, len(ops))
+	merged := make([]opStats, len(ops))
+
+	for i, op := range ops {
+		names[i] = op.name
+
+		for _, w := range workers {
+			merged[i].errors += w.stats[i].errors
+			merged[i].misses += w.stats[i].misses
+			merged[i].latencies.merge(&w.stats[i].latencies)
+		}
+	}
+
+	// Busiest operation first
+	order := make([]int, len(ops))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return merged[order[a]].latencies.total > merged[order[b]].latencies.total })
+
+	row := func(name string, stats *opStats) {
+		h := &stats.latencies
+		fmt.Fprintf(out, "%v\t%v\t%.0f\t%v\t%v\t%v\t%v\t%v\t%v\n", name, h.total, float64(h.total)/elapsed.Seconds(), stats.errors, stats.misses,
+			formatLatency(h.percentile(50)), formatLatency(h.percentile(99)), formatLatency(h.percentile(99.9)), formatLatency(h.max))
+	}
+
+	for _, i := range order {
+		row(names[i], &merged[i])
+
+		all.merge(&merged[i].latencies)
+		errors += merged[i].errors
+		misses += merged[i].misses
+	}
+
+	total := opStats{errors: errors, misses: misses, latencies: all}
+	row("TOTAL", &total)
+	out.Flush()
+
+	fmt.Printf("\nThroughput : %.0f ops/sec (%v operations in %v)\n", float64(all.total)/elapsed.Seconds(), all.total, elapsed.Round(time.Millisecond))
+
+	fmt.Println("\nLatency distribution (all operations)")
+	all.print(os.Stdout)
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"prac/kvclient"
+)
+
+// Longest line read from a file or pipe
+const maxInputLine = 16 * 1024 * 1024
+
+// kv_client GET foo -> the shell has already split the arguments, so they're sent as they are
+func runOneShot(client *kvclient.Client, args []string, format string) int {
+	command, args, err := parseArgs(args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitCommandFailed
+	}
+
+	ok := runCommand(client, command, args, format)
+
+	if ok && isSubscribeCommand(command) {
+		waitForMessages()
+	}
+
+	if !ok {
+		return exitCommandFailed
+	}
+
+	return exitOK
+}
+
+// Commands from a file or pipe, one per line, without a prompt. Every command is run even if an earlier one fails.
+func runBatch(client *kvclient.Client, reader io.Reader, format string) int {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), maxInputLine)
+
+	exitCode := exitOK
+	subscribed := false
+	input := ""
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if input == "" && (strings.TrimSpace(line) == "" || strings.HasPrefix(strings.TrimSpace(line), "#")) {
+			continue
+		}
+
+		input += line
+
+		if next, more := continueInput(input); more {
+			input = next
+			continue
+		}
+
+		command, args, err := ParseInput(input)
+		input = ""
+
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exitCode = exitCommandFailed
+			continue
+		}
+
+		if command == "EXIT" {
+			return exitCode
+		}
+
+		if !runCommand(client, command, args, format) {
+			exitCode = exitCommandFailed
+		} else if isSubscribeCommand(command) {
+			subscribed = true
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+
+	if input != "" {
+		fmt.Fprintln(os.Stderr, "- Input ended inside quotes or after a \\ !!!")
+		exitCode = exitCommandFailed
+	}
+
+	if subscribed {
+		waitForMessages()
+	}
+
+	return exitCode
+}
+
+// Prints the reply, errors go to stderr unless the output is JSON. Returns false if the command failed.
+func runCommand(client *kvclient.Client, command string, args []string, format string) bool {
+	reply, err := client.Do(context.Background(), command, args...)
+
+	out := os.Stdout
+	if err != nil && format != formatJSON {
+		out = os.Stderr
+	}
+
+	fmt.Fprintln(out, formatReply(format, command, reply, err))
+
+	return err == nil
+}
+
+func isSubscribeCommand(command string) bool {
+	return command == "SUBSCRIBE" || command == "PSUBSCRIBE"
+}
+
+// Messages are printed by OnMessage as they arrive, till the process is interrupted
+func waitForMessages() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	<-ctx.Done()
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"prac/kvclient"
+)
+
+const maxKeyCompletions = 100
+
+// Tab completion of command names for the first word and of key names in the current cache for the rest
+type completer struct {
+	client   *kvclient.Client
+	out      io.Writer // candidates are listed here when there's more than one
+	commands []string
+
+	// SCAN would be queued instead of run while a transaction is open
+	inTransaction bool
+}
+
+// Command names from the server's HELP table, CommandsWithRequiredArgs for servers without HELP
+func loadCommandNames(client *kvclient.Client) []string {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	reply, err := client.Do(ctx, "HELP")
+	if err != nil {
+		return CommandsWithRequiredArgs
+	}
+
+	items, err := kvclient.ParseList(reply.Output)
+	if err != nil {
+		return CommandsWithRequiredArgs
+	}
+
+	names := make([]string, len(items))
+	for i, item := range items {
+		names[i], _, _ = strings.Cut(item, " - ")
+	}
+
+	return names
+}
+
+// Used as term.Terminal.AutoCompleteCallback
+func (c *completer) complete(line string, pos int, key rune) (string, int, bool) {
+	if key != '\t' {
+		return "", 0, false
+	}
+
+	start := strings.LastIndexAny(line[:pos], " \t") + 1
+	prefix := line[start:pos]
+
+	var candidates []string
+
+	if strings.TrimSpace(line[:start]) == "" {
+		prefix = strings.ToUpper(prefix)
+
+		for _, name := range c.commands {
+			if strings.HasPrefix(name, prefix) {
+				candidates = append(candidates, name)
+			}
+		}
+	} else {
+		candidates = c.keys(prefix)
+	}
+
+	if len(candidates) == 0 {
+		return "", 0, false
+	}
+
+	completion := commonPrefix(candidates)
+
+	if len(candidates) == 1 {
+		completion = quoteIfNeeded(completion) + " "
+	} else if completion != quoteIfNeeded(completion) {
+		// Partly typed quotes can't be completed further
+		completion = prefix
+	}
+
+	if len(candidates) > 1 && completion == prefix {
+		fmt.Fprintln(c.out, strings.Join(candidates, "  "))
+	}
+
+	return line[:start] + completion + line[pos:], start + len(completion), true
+}
+
+// Keys of the current cache starting with prefix, going over the cache with SCAN so that big caches aren't blocked
+func (c *completer) keys(prefix string) []string {
+	if c.inTransaction {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	pattern := escapeGlob(prefix) + "*"
+	cursor := "0"
+	seen := map[string]bool{}
+	keys := []string{}
+
+	for i := 0; i < 10 && len(keys) < maxKeyCompletions; i++ {
+		reply, err := c.client.Do(ctx, "SCAN", cursor, "MATCH", pattern, "COUNT", "1000")
+		if err != nil {
+			return nil
+		}
+
+		next, items, err := kvclient.ParseScan(reply.Output)
+		if err != nil {
+			return nil
+		}
+
+		for _, item := range items {
+			if !seen[item] {
+				seen[item] = true
+				keys = append(keys, item)
+			}
+		}
+
+		cursor = next
+		if cursor == "0" {
+			break
+		}
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func commonPrefix(words []string) string {
+	prefix := words[0]
+
+	for _, word := range words[1:] {
+		for !strings.HasPrefix(word, prefix) {
+			_, size := utf8.DecodeLastRuneInString(prefix)
+			prefix = prefix[:len(prefix)-size]
+		}
+	}
+
+	return prefix
+}
+
+// Keys with whitespace, quotes or \ are put in single quotes, so that they're split back into the same key
+func quoteIfNeeded(word string) string {
+	if !strings.ContainsAny(word, " \t\r\n'\"\\") {
+		return word
+	}
+
+	return "'" + strings.ReplaceAll(word, "'", `\'`) + "'"
+}
+
+func escapeGlob(str string) string {
+	var sb strings.Builder
+
+	for _, c := range str {
+		if strings.ContainsRune(`*?[]\`, c) {
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(c)
+	}
+
+	return sb.String()
+}
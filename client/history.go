@@ -0,0 +1,91 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const maxHistory = 1000
+
+// Lines typed in the REPL (oldest first), appended to a file so that they're available in the next session
+type fileHistory struct {
+	entries []string
+	file    *os.File // nil -> history isn't saved
+}
+
+// KV_HISTORY_FILE or ~/.kv_history
+func historyPath() string {
+	if path := os.Getenv("KV_HISTORY_FILE"); path != "" {
+		return path
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(home, ".kv_history")
+}
+
+func loadHistory(path string) *fileHistory {
+	history := &fileHistory{}
+
+	if path == "" {
+		return history
+	}
+
+	if data, err := os.ReadFile(path); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			if strings.TrimSpace(line) != "" {
+				history.entries = append(history.entries, line)
+			}
+		}
+	}
+
+	// Older entries are dropped from the file as well, so it doesn't keep growing
+	if len(history.entries) > maxHistory {
+		history.entries = history.entries[len(history.entries)-maxHistory:]
+		os.WriteFile(path, []byte(strings.Join(history.entries, "\n")+"\n"), 0600)
+	}
+
+	history.file, _ = os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+
+	return history
+}
+
+// Called by the terminal for every line read, empty lines and repeats of the last line are skipped
+func (history *fileHistory) Add(entry string) {
+	if strings.TrimSpace(entry) == "" {
+		return
+	}
+
+	if len(history.entries) > 0 && history.entries[len(history.entries)-1] == entry {
+		return
+	}
+
+	history.entries = append(history.entries, entry)
+
+	if len(history.entries) > maxHistory {
+		history.entries = history.entries[1:]
+	}
+
+	if history.file != nil {
+		history.file.WriteString(entry + "\n")
+	}
+}
+
+func (history *fileHistory) Len() int {
+	return len(history.entries)
+}
+
+// 0 -> most recent entry
+func (history *fileHistory) At(idx int) string {
+	return history.entries[len(history.entries)-1-idx]
+}
+
+func (history *fileHistory) Close() {
+	if history.file != nil {
+		history.file.Close()
+	}
+}
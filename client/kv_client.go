@@ -1,100 +1,284 @@
 package main
 
 import (
-	"bufio"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"log"
-	"net"
+	"io"
+	"io/fs"
 	"os"
 	"strconv"
 	"strings"
 
 	"github.com/joho/godotenv"
+	"golang.org/x/term"
+
+	"prac/kvclient"
+	"prac/utils"
+)
+
+var CommandsWithRequiredArgs []string = []string{"SET", "DEL", "GET", "NUM", "BF_CREATE", "BF_ADD", "BF_EXISTS", "BF_MADD", "BF_MEXISTS", "BF_DROP", "BF_INFO", "BF_SCANDUMP", "BF_LOADCHUNK", "BF_MERGE", "CF_CREATE", "CF_ADD", "CF_ADDNX", "CF_DEL", "CF_EXISTS", "CF_COUNT", "EVAL", "EVALSHA", "SCRIPT", "SUBSCRIBE", "PSUBSCRIBE", "PUBLISH", "PUBSUB",
+	"LPUSH", "RPUSH", "LPOP", "RPOP", "LRANGE", "LLEN", "LINDEX", "LSET", "LTRIM", "LREM", "LMOVE", "BLPOP", "BRPOP", "BLMOVE",
+	"HSET", "HGET", "HMGET", "HDEL", "HEXISTS", "HGETALL", "HKEYS", "HVALS", "HLEN", "HINCRBY", "HSCAN", "EXPIRE", "TTL", "PERSIST", "EXISTS", "TYPE", "KEYS", "SCAN",
+	"INCR", "DECR", "INCRBY", "DECRBY", "INCRBYFLOAT", "APPEND", "STRLEN", "GETRANGE", "SETRANGE", "GETDEL", "MSET", "MSETNX", "MGET", "RENAME", "RENAMENX", "COPY", "MOVE", "SWAPDB",
+	"SADD", "SREM", "SISMEMBER", "SMISMEMBER", "SMEMBERS", "SCARD", "SPOP", "SRANDMEMBER", "SINTER", "SUNION", "SDIFF", "SINTERSTORE", "SUNIONSTORE", "SDIFFSTORE",
+	"GEOADD", "GEOPOS", "GEODIST", "GEOHASH", "GEOSEARCH",
+	"PFADD", "PFCOUNT", "PFMERGE", "CMS_INITBYDIM", "CMS_INITBYPROB", "CMS_INCRBY", "CMS_QUERY", "CMS_MERGE",
+	"TOPK_RESERVE", "TOPK_ADD", "TOPK_QUERY", "TOPK_LIST"}
+
+const (
+	exitOK            = 0
+	exitCommandFailed = 1 // a command got an ERR reply or couldn't be sent
+	exitFailure       = 2 // bad flags, or the server can't be reached
 )
 
-var CommandsWithRequiredArgs []string = []string{"SET", "DEL", "GET", "NUM", "BF_CREATE", "BF_ADD", "BF_EXISTS"}
+var (
+	addrFlag    = flag.String("addr", "", "server address (default ADDR from the environment or ../.env, otherwise "+kvclient.DefaultAddr+")")
+	fileFlag    = flag.String("f", "", "read commands from a file, one per line (- for stdin)")
+	rawFlag     = flag.Bool("raw", false, "print only the values, one list item per line")
+	jsonFlag    = flag.Bool("json", false, "print every reply as a JSON object")
+	timeoutFlag = flag.Duration("timeout", 0, "time limit for every command, 0 -> no limit")
+)
 
 func main() {
-	err := godotenv.Load("../.env")
+	flag.Usage = usage
+	flag.Parse()
+
+	os.Exit(run())
+}
+
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), `Usage:
+  kv_client [flags]                    interactive REPL, or commands from stdin when it isn't a terminal
+  kv_client [flags] COMMAND [arg ...]  runs a single command
+  kv_client [flags] -f file            runs the commands in file, blank lines and lines starting with # are skipped
+
+Exits with 1 if any command got an ERR reply and 2 if the server can't be reached.
+
+Flags:
+`)
+	flag.PrintDefaults()
+}
+
+func run() int {
+	format, err := outputFormat(*rawFlag, *jsonFlag)
 	if err != nil {
-		log.Fatal(err)
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+
+	// .env is optional, variables already set in the environment win over it
+	if err := godotenv.Load("../.env"); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+
+	addr := *addrFlag
+
+	if addr == "" {
+		addr = os.Getenv("ADDR")
 	}
 
-	ADDR := os.Getenv("ADDR")
+	if addr == "" {
+		addr = kvclient.DefaultAddr
+	}
+
+	var input io.Reader
+
+	switch {
+	case flag.NArg() > 0:
+		// single command from the arguments
+
+	case *fileFlag != "" && *fileFlag != "-":
+		file, err := os.Open(*fileFlag)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitFailure
+		}
+		defer file.Close()
+
+		input = file
+
+	case *fileFlag == "-" || !term.IsTerminal(int(os.Stdin.Fd())):
+		input = os.Stdin
+	}
+
+	interactive := flag.NArg() == 0 && input == nil
+
+	var out io.Writer = os.Stdout
+	var console Console
+	restore := func() {}
+
+	if interactive {
+		history := loadHistory(historyPath())
+		defer history.Close()
 
-	if ADDR == "" {
-		ADDR = "localhost:9376"
+		console, restore, err = openConsole(history)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitFailure
+		}
+		defer restore()
+
+		out = console
+	}
+
+	// Single pooled connection, so that transactions stay on it. Subscriptions get a connection of their own.
+	// Pub/Sub messages are pushed by the server at any time and printed as they arrive.
+	client, err := kvclient.CreateClient(kvclient.Options{
+		Addr:     addr,
+		PoolSize: 1,
+		Timeout:  *timeoutFlag,
+		OnMessage: func(message kvclient.Message) {
+			fmt.Fprintln(out, formatReply(format, message.Command, kvclient.Reply{Command: message.Command, Output: message.Output}, nil))
+		},
+	})
+	if err == nil {
+		defer client.Close()
+		err = client.Ping(context.Background())
 	}
 
-	conn, err := net.Dial("tcp", ADDR)
 	if err != nil {
-		log.Fatal(err)
+		// Terminal has to leave raw mode before anything else is printed
+		restore()
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
 	}
 
-	defer conn.Close()
+	switch {
+	case interactive:
+		runInteractive(client, console, format)
+		return exitOK
+
+	case input != nil:
+		return runBatch(client, input, format)
+
+	default:
+		return runOneShot(client, flag.Args(), format)
+	}
+}
 
-	scanner := bufio.NewScanner(os.Stdin)
+func runInteractive(client *kvclient.Client, console Console, format string) {
+	completer := &completer{client: client, out: console, commands: loadCommandNames(client)}
+	if terminal, ok := console.(*term.Terminal); ok {
+		terminal.AutoCompleteCallback = completer.complete
+	}
 
 	var currentCacheNum uint8 = 0
-	fmt.Println("CONNECTED TO KV SERVER...")
+	var input string
+
+	fmt.Fprintln(console, "CONNECTED TO KV SERVER... (HELP lists the commands, TAB completes commands and keys)")
 	for {
-		fmt.Printf("\n[%v]>>> ", currentCacheNum)
+		if input == "" {
+			fmt.Fprintln(console)
+			console.SetPrompt(fmt.Sprintf("[%v]>>> ", currentCacheNum))
+		} else {
+			console.SetPrompt("... ")
+		}
 
-		if !scanner.Scan() {
+		line, err := console.ReadLine()
+		if err != nil && err != term.ErrPasteIndicator {
 			break
 		}
 
-		input, err := SerializeInput(scanner.Text())
+		input += line
 
-		if err != nil {
-			fmt.Println(err)
+		if next, more := continueInput(input); more {
+			input = next
 			continue
 		}
 
-		_, err = conn.Write([]byte(input))
+		command, args, err := ParseInput(input)
+		input = ""
+
 		if err != nil {
-			log.Fatal(err)
+			fmt.Fprintln(console, err)
+			continue
 		}
 
-		buffer := make([]byte, 1024)
-		n, err := conn.Read(buffer)
-		if err != nil {
-			log.Fatal(err)
+		if command == "EXIT" {
+			break
 		}
 
-		output := DeserializeOutput(string(buffer[:n]), &currentCacheNum)
+		reply, err := client.Do(context.Background(), command, args...)
+
+		switch {
+		case command == "BEGIN" && err == nil:
+			completer.inTransaction = true
+		case command == "COMMIT" || command == "DISCARD":
+			completer.inTransaction = false
+		case command == "NUM" && err == nil:
+			val, _ := strconv.Atoi(reply.Output)
+			currentCacheNum = uint8(val)
+		}
 
-		fmt.Println(output)
+		fmt.Fprintln(console, formatReply(format, command, reply, err))
 	}
+}
 
+// Line editing, history and completion for the interactive REPL, *term.Terminal
+type Console interface {
+	io.Writer
+	ReadLine() (string, error)
+	SetPrompt(prompt string)
 }
 
-// +COMMAND\r\nOUTPUT\r\n
-func DeserializeOutput(s string, cacheNum *uint8) string {
+// Puts the terminal in raw mode, the returned func restores it
+func openConsole(history term.History) (Console, func(), error) {
+	fd := int(os.Stdin.Fd())
 
-	str := strings.Split(s, "\r\n")
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	command := str[0]
-	output := str[1]
+	terminal := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{os.Stdin, os.Stdout}, "")
 
-	if command == "NUM" {
-		val, _ := strconv.Atoi(output)
+	terminal.History = history
 
-		*cacheNum = uint8(val)
-	} else if command == "ERR" {
-		output = "- " + output
+	if width, height, err := term.GetSize(fd); err == nil && width > 0 {
+		terminal.SetSize(width, height)
 	}
 
-	return output
+	return terminal, func() { term.Restore(fd, state) }, nil
 }
 
-func SerializeInput(input string) (string, error) {
+// Input is continued on the next line when it ends inside quotes (the newline is kept in the argument)
+// or with a \ (the lines are joined, like a shell does)
+func continueInput(input string) (string, bool) {
+	_, err := utils.SplitArgs(input)
+
+	switch {
+	case errors.Is(err, utils.ErrTrailingEscape):
+		return strings.TrimSuffix(input, "\\"), true
+
+	case errors.Is(err, utils.ErrUnbalancedSingleQuotes), errors.Is(err, utils.ErrUnbalancedDoubleQuotes):
+		return input + "\n", true
+	}
+
+	return input, false
+}
 
-	var output string
-	arr := strings.Fields(strings.TrimSpace(input))
+// Arguments are split like a shell does (see utils.SplitArgs), so they reach the server exactly as typed.
+// Arguments holding \r\n are rejected, as the protocol can't carry them.
+func ParseInput(input string) (string, []string, error) {
+	arr, err := utils.SplitArgs(input)
 
+	if err != nil {
+		return "", nil, fmt.Errorf("- %v !!!", err)
+	}
+
+	return parseArgs(arr)
+}
+
+// Command and its arguments, already split (by ParseInput or by the shell for a one-shot command)
+func parseArgs(arr []string) (string, []string, error) {
 	if len(arr) == 0 {
-		return "", fmt.Errorf(">> Nothing Entered !!!")
+		return "", nil, fmt.Errorf(">> Nothing Entered !!!")
 	}
 
 	firstArg := strings.ToUpper(arr[0])
@@ -102,16 +286,10 @@ func SerializeInput(input string) (string, error) {
 	if len(arr) == 1 {
 		for _, c := range CommandsWithRequiredArgs {
 			if c == firstArg {
-				return "", fmt.Errorf("- %v : Missing Arguments !!!", firstArg)
+				return "", nil, fmt.Errorf("- %v : Missing Arguments !!!", firstArg)
 			}
 		}
 	}
 
-	output += firstArg + "\r\n"
-
-	for i := 1; i < len(arr); i++ {
-		output += arr[i] + "\r\n"
-	}
-
-	return output, nil
+	return firstArg, arr[1:], nil
 }
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"prac/kvclient"
+)
+
+// Output formats, chosen with -raw and -json
+const (
+	formatDefault = ""
+	formatRaw     = "raw"
+	formatJSON    = "json"
+)
+
+func outputFormat(raw bool, asJSON bool) (string, error) {
+	switch {
+	case raw && asJSON:
+		return "", errors.New("-raw and -json can't be used together")
+	case raw:
+		return formatRaw, nil
+	case asJSON:
+		return formatJSON, nil
+	}
+
+	return formatDefault, nil
+}
+
+/*
+default -> output as sent by the server, errors prefixed by "- "
+raw -> value without ">> ", list items one per line and (nil) as an empty line, errors as they are
+json -> {"command": "GET", "value": "bar"}, lists as arrays and (nil) as null, {"command": "GET", "error": "..."} for errors
+*/
+func formatReply(format string, command string, reply kvclient.Reply, err error) string {
+	switch format {
+	case formatRaw:
+		if err != nil {
+			return err.Error()
+		}
+
+		switch value := replyValue(reply).(type) {
+		case []any:
+			items := make([]string, len(value))
+			for i, item := range value {
+				items[i], _ = item.(string)
+			}
+
+			return strings.Join(items, "\n")
+		case string:
+			return value
+		}
+
+		return ""
+
+	case formatJSON:
+		object := map[string]any{"command": command}
+
+		if err != nil {
+			object["error"] = err.Error()
+		} else {
+			object["value"] = replyValue(reply)
+		}
+
+		encoded, _ := json.Marshal(object)
+
+		return string(encoded)
+	}
+
+	if err != nil {
+		// Server errors and lost connections alike, the connection is made again for the next command
+		return "- " + err.Error()
+	}
+
+	return reply.Output
+}
+
+// []any for lists, nil for (nil), the output without ">> " otherwise
+func replyValue(reply kvclient.Reply) any {
+	if reply.IsNil() {
+		return nil
+	}
+
+	if items, err := kvclient.ParseNullableList(reply.Output); err == nil {
+		values := make([]any, len(items))
+		for i, item := range items {
+			if item != nil {
+				values[i] = *item
+			}
+		}
+
+		return values
+	}
+
+	return reply.Value()
+}
@@ -1,7 +1,13 @@
 module prac
 
-go 1.22.5
+go 1.23.0
 
 require github.com/joho/godotenv v1.5.1
 
 require github.com/cespare/xxhash/v2 v2.3.0
+
+require github.com/yuin/gopher-lua v1.1.1
+
+require golang.org/x/term v0.32.0
+
+require golang.org/x/sys v0.33.0 // indirect
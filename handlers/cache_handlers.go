@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"fmt"
+	"prac/utils"
+	"strconv"
+	"strings"
+)
+
+func CacheManagementHandler(command string, args []string) (string, error) {
+	switch command {
+	// RENAME key newkey -> overwrites newkey | RENAMENX key newkey -> 1 if renamed, 0 if newkey already exists
+	case "RENAME", "RENAMENX":
+		if len(args) < 2 {
+			return "", fmt.Errorf("%v : Missing key and new key", command)
+		}
+
+		cache := CurrentCache
+
+		cache.Mutex.Lock()
+		defer cache.Mutex.Unlock()
+
+		item, exists := cache.Data[args[0]]
+		if !exists {
+			return "", noKeyErrorf("%v %v : Key doesn't exist !!!", command, args[0])
+		}
+
+		if _, destExists := cache.Data[args[1]]; destExists && command == "RENAMENX" {
+			return ">> 0", nil
+		}
+
+		if args[0] != args[1] {
+			moveItem(cache, cache, args[0], args[1], item)
+
+			NotifyKeyspaceEvent(NotifyGeneric, "rename_from", args[0], cache.Index)
+			NotifyKeyspaceEvent(NotifyGeneric, "rename_to", args[1], cache.Index)
+		}
+
+		if command == "RENAMENX" {
+			return ">> 1", nil
+		}
+
+		return ">> SUCCESS", nil
+
+	// COPY source destination [DB index] [REPLACE] -> 1 if copied, 0 if destination exists and REPLACE isn't given
+	case "COPY":
+		if len(args) < 2 {
+			return "", fmt.Errorf("COPY : Missing source and destination")
+		}
+
+		src, dest := CurrentCache, CurrentCache
+		replace := false
+
+		for i := 2; i < len(args); i++ {
+			switch strings.ToUpper(args[i]) {
+			case "REPLACE":
+				replace = true
+			case "DB":
+				if i+1 >= len(args) {
+					return "", fmt.Errorf("COPY %v : Missing value for DB", args[0])
+				}
+
+				i++
+				cache, err := cacheByIndex("COPY", args[i])
+				if err != nil {
+					return "", err
+				}
+				dest = cache
+			default:
+				return "", fmt.Errorf("COPY %v : Unknown option %v", args[0], args[i])
+			}
+		}
+
+		if src == dest && args[0] == args[1] {
+			return "", fmt.Errorf("COPY %v : Source and destination should be different", args[0])
+		}
+
+		unlock := lockCaches(src, dest)
+		defer unlock()
+
+		item, exists := src.Data[args[0]]
+		if !exists {
+			return ">> 0", nil
+		}
+
+		if destItem, destExists := dest.Data[args[1]]; destExists {
+			if !replace {
+				return ">> 0", nil
+			}
+
+			dest.deleteItem(args[1], destItem)
+		}
+
+		dest.storeItem(args[1], item.Clone())
+
+		NotifyKeyspaceEvent(NotifyGeneric, "copy_to", args[1], dest.Index)
+
+		return ">> 1", nil
+
+	// MOVE key index -> 1 if moved, 0 if the key doesn't exist or is already present in the destination
+	case "MOVE":
+		if len(args) < 2 {
+			return "", fmt.Errorf("MOVE : Missing key and cache index")
+		}
+
+		src := CurrentCache
+		dest, err := cacheByIndex("MOVE", args[1])
+		if err != nil {
+			return "", err
+		}
+
+		if dest.Index == src.Index {
+			return "", fmt.Errorf("MOVE %v : Source and destination caches should be different", args[0])
+		}
+
+		unlock := lockCaches(src, dest)
+		defer unlock()
+
+		item, exists := src.Data[args[0]]
+		if !exists {
+			return ">> 0", nil
+		}
+
+		if _, destExists := dest.Data[args[0]]; destExists {
+			return ">> 0", nil
+		}
+
+		moveItem(src, dest, args[0], args[0], item)
+
+		NotifyKeyspaceEvent(NotifyGeneric, "move_from", args[0], src.Index)
+		NotifyKeyspaceEvent(NotifyGeneric, "move_to", args[0], dest.Index)
+
+		return ">> 1", nil
+
+	// SWAPDB index1 index2 -> connections using either cache see the other one's data right away
+	case "SWAPDB":
+		if len(args) < 2 {
+			return "", fmt.Errorf("SWAPDB : Missing cache indexes")
+		}
+
+		first, err := cacheByIndex("SWAPDB", args[0])
+		if err != nil {
+			return "", err
+		}
+
+		second, err := cacheByIndex("SWAPDB", args[1])
+		if err != nil {
+			return "", err
+		}
+
+		if first == second {
+			return ">> SUCCESS", nil
+		}
+
+		unlock := lockCaches(first, second)
+		defer unlock()
+
+		first.Data, second.Data = second.Data, first.Data
+		first.SkipList, second.SkipList = second.SkipList, first.SkipList
+
+		// Clients blocked on a cache are served from the data which is now in it
+		first.serveAllListWaiters()
+		second.serveAllListWaiters()
+
+		return ">> SUCCESS", nil
+
+	// FLUSHDB [ASYNC | SYNC] | FLUSHALL [ASYNC | SYNC]
+	// Data and skiplist are swapped for empty ones and the old ones are left to the GC, so the cache is blocked
+	// only for the swap. ASYNC and SYNC behave the same, the option is accepted for compatibility.
+	case "FLUSHDB", "FLUSHALL":
+		if len(args) > 0 {
+			mode := strings.ToUpper(args[0])
+			if mode != "ASYNC" && mode != "SYNC" {
+				return "", fmt.Errorf("%v : Unknown option %v", command, args[0])
+			}
+		}
+
+		if command == "FLUSHDB" {
+			CurrentCache.flush()
+		} else {
+			for index := range Caches {
+				Caches[index].flush()
+			}
+
+			// CurrentCache isn't one of Caches after RETAIN
+			CurrentCache.flush()
+		}
+
+		return ">> SUCCESS", nil
+
+	// DBSIZE -> number of keys in the current cache
+	case "DBSIZE":
+		cache := CurrentCache
+
+		cache.Mutex.Lock()
+		defer cache.Mutex.Unlock()
+
+		return fmt.Sprintf(">> %v", len(cache.Data)), nil
+	}
+
+	return "", fmt.Errorf("Unknown command !!!")
+}
+
+func cacheByIndex(command string, arg string) (*Cache, error) {
+	index, err := strconv.Atoi(arg)
+
+	if err != nil || index < 0 || index >= len(Caches) {
+		return nil, fmt.Errorf("%v : Cache index should lie in the range of [0, %v]", command, len(Caches)-1)
+	}
+
+	if index == int(CurrentCache.Index) {
+		return CurrentCache, nil
+	}
+
+	return &Caches[index], nil
+}
+
+// Locks both caches in the order of their index, so that commands using the same pair can't deadlock.
+// Returns the function which unlocks them.
+func lockCaches(first *Cache, second *Cache) func() {
+	if first == second {
+		first.Mutex.Lock()
+		return first.Mutex.Unlock
+	}
+
+	if second.Index < first.Index {
+		first, second = second, first
+	}
+
+	first.Mutex.Lock()
+	second.Mutex.Lock()
+
+	return func() {
+		second.Mutex.Unlock()
+		first.Mutex.Unlock()
+	}
+}
+
+/*
+***************************
+Helpers (cache.Mutex must be held)
+***************************
+*/
+
+// Moves the item along with its ttl entry, overwriting destKey
+func moveItem(src *Cache, dest *Cache, key string, destKey string, item CacheItem) {
+	src.deleteItem(key, item)
+
+	if destItem, exists := dest.Data[destKey]; exists {
+		dest.deleteItem(destKey, destItem)
+	}
+
+	dest.storeItem(destKey, item)
+}
+
+// Stores the item and its ttl entry, key shouldn't exist in the cache
+func (cache *Cache) storeItem(key string, item CacheItem) {
+	cache.Data[key] = item
+
+	if item.CanExpire {
+		cache.SkipList.Insert(key, item.TTL)
+	}
+
+	if item.Kind == KindList {
+		cache.serveListWaiters(key)
+	}
+}
+
+func (cache *Cache) serveAllListWaiters() {
+	for key := range cache.blockedClients {
+		cache.serveListWaiters(key)
+	}
+}
+
+// Returns the old data, which nothing else refers to anymore
+func (cache *Cache) flush() {
+	cache.Mutex.Lock()
+	defer cache.Mutex.Unlock()
+
+	cache.Data = make(map[string]CacheItem)
+	cache.SkipList = utils.CreateTTLSkipList(DefaultSkipListMaxHeight)
+}
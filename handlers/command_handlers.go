@@ -1,10 +1,13 @@
 package handlers
 
 import (
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"math"
 	"net"
 	"prac/utils"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -12,9 +15,19 @@ import (
 
 func SwitchCases(command string, args []string, connectionObj *Connection, conn net.Conn) {
 
+	if connectionObj.InSubscriberMode() && !subscriberModeCommands[command] {
+		conn.Write([]byte(utils.SerializeOutput("ERR", fmt.Sprintf("%v : Only (P)SUBSCRIBE, (P)UNSUBSCRIBE and PING are allowed in subscriber mode !!!", command))))
+		return
+	}
+
 	inTransaction := connectionObj.TransactionFlag
 
 	if inTransaction && command != "COMMIT" && command != "DISCARD" && command != "BEGIN" {
+		if nonTransactionalCommands[command] {
+			conn.Write([]byte(utils.SerializeOutput("ERR", nonTransactionalError(command).Error())))
+			return
+		}
+
 		connectionObj.TransactionQueue = append(connectionObj.TransactionQueue, Statement{command, args})
 		conn.Write([]byte(utils.SerializeOutput("TR", ">> QUEUED")))
 		return
@@ -25,12 +38,38 @@ func SwitchCases(command string, args []string, connectionObj *Connection, conn
 
 	if command == "BEGIN" || command == "COMMIT" || command == "DISCARD" {
 		successMsg, err = TransactionHandler(command, args, connectionObj)
+	} else if BlockingListCommands[command] {
+		disconnected, stopWatching := connectionObj.watchDisconnect()
+		successMsg, err = BlockingListHandler(command, args, true, disconnected)
+		stopWatching()
+	} else if IsPubSubCommand(command) {
+		successMsg, err = PubSubHandler(command, args, connectionObj)
+	} else if command == "SCRIPT" {
+		// Only touches the script cache, and SCRIPT KILL has to get through while a script holds the lock
+		successMsg, err = ScriptHandler(args)
+	} else if command == "EVAL" || command == "EVALSHA" {
+		// Scripts run atomically, same as a transaction commit
+		cache := CurrentCache
+		cache.TransactionMutex.Lock()
+		successMsg, err = CommandHandler(command, args)
+		cache.TransactionMutex.Unlock()
 	} else {
+		cache := CurrentCache
+		cache.TransactionMutex.RLock()
 		successMsg, err = CommandHandler(command, args)
+		cache.TransactionMutex.RUnlock()
 	}
 
 	if err != nil {
-		conn.Write([]byte(utils.SerializeOutput("ERR", err.Error())))
+		// Keys written by scripts can hold newlines
+		msg := escapeValue(err.Error())
+
+		var noKey *NoKeyError
+		if errors.As(err, &noKey) {
+			msg = NoKeyPrefix + msg
+		}
+
+		conn.Write([]byte(utils.SerializeOutput("ERR", msg)))
 	} else {
 		conn.Write([]byte(utils.SerializeOutput(command, successMsg)))
 	}
@@ -39,11 +78,10 @@ func SwitchCases(command string, args []string, connectionObj *Connection, conn
 func CommandHandler(command string, args []string) (string, error) {
 	switch command {
 	case "SET":
-		if err := SetHandler(args); err != nil {
-			return "", err
-		}
+		return SetHandler(args)
 
-		return ">> SUCCESS", nil
+	case "MSET", "MSETNX", "MGET":
+		return MultiStringHandler(command, args)
 
 	case "GET":
 		val, err := GetHandler(args)
@@ -52,7 +90,7 @@ func CommandHandler(command string, args []string) (string, error) {
 			return "", err
 		}
 
-		return fmt.Sprintf(">> %v", val), nil
+		return formatValue(val), nil
 
 	case "DEL":
 		if err := DelHandler(args); err != nil {
@@ -84,6 +122,45 @@ func CommandHandler(command string, args []string) (string, error) {
 
 		return fmt.Sprintf(">> %v", val), nil
 
+	case "BF_MADD":
+		return BloomFilterMultiAddHandler(args)
+
+	case "BF_MEXISTS":
+		return BloomFilterMultiExistsHandler(args)
+
+	case "BF_DROP":
+		if err := BloomFilterDropHandler(args); err != nil {
+			return "", err
+		}
+
+		return ">> SUCCESS", nil
+
+	case "BF_LIST":
+		return BloomFilterListHandler(args), nil
+
+	case "BF_INFO":
+		return BloomFilterInfoHandler(args)
+
+	case "BF_SCANDUMP":
+		return BloomFilterScanDumpHandler(args)
+
+	case "BF_LOADCHUNK":
+		if err := BloomFilterLoadChunkHandler(args); err != nil {
+			return "", err
+		}
+
+		return ">> SUCCESS", nil
+
+	case "BF_MERGE":
+		if err := BloomFilterMergeHandler(args); err != nil {
+			return "", err
+		}
+
+		return ">> SUCCESS", nil
+
+	case "CF_CREATE", "CF_ADD", "CF_ADDNX", "CF_DEL", "CF_EXISTS", "CF_COUNT":
+		return CuckooFilterHandler(command, args)
+
 	case "NUM":
 		num, err := SetCurrentCacheHandler(args)
 		if err != nil {
@@ -112,6 +189,73 @@ func CommandHandler(command string, args []string) (string, error) {
 		}
 
 		return ">> SUCCESS", nil
+
+	case "EVAL":
+		return EvalHandler(args)
+
+	case "EVALSHA":
+		return EvalShaHandler(args)
+
+	case "SCRIPT":
+		return ScriptHandler(args)
+
+	case "PUBLISH":
+		receivers, err := PublishHandler(args)
+		if err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf(">> %v", receivers), nil
+
+	case "PUBSUB":
+		return PubSubInfoHandler(args)
+
+	case "PING":
+		return ">> PONG", nil
+
+	case "HELP":
+		return HelpHandler(args)
+
+	case "NOTIFY":
+		return NotifyConfigHandler(args)
+
+	case "HSET", "HGET", "HMGET", "HDEL", "HEXISTS", "HGETALL", "HKEYS", "HVALS", "HLEN", "HINCRBY", "HSCAN":
+		return HashHandler(command, args)
+
+	case "SADD", "SREM", "SISMEMBER", "SMISMEMBER", "SMEMBERS", "SCARD", "SPOP", "SRANDMEMBER",
+		"SINTER", "SUNION", "SDIFF", "SINTERSTORE", "SUNIONSTORE", "SDIFFSTORE":
+		return SetTypeHandler(command, args)
+
+	case "GEOADD", "GEOPOS", "GEODIST", "GEOHASH", "GEOSEARCH":
+		return GeoHandler(command, args)
+
+	case "PFADD", "PFCOUNT", "PFMERGE":
+		return HyperLogLogHandler(command, args)
+
+	case "CMS_INITBYDIM", "CMS_INITBYPROB", "CMS_INCRBY", "CMS_QUERY", "CMS_MERGE":
+		return CountMinSketchHandler(command, args)
+
+	case "TOPK_RESERVE", "TOPK_ADD", "TOPK_QUERY", "TOPK_LIST":
+		return TopKHandler(command, args)
+
+	case "EXPIRE", "TTL", "PERSIST":
+		return ExpireHandler(command, args)
+
+	case "EXISTS", "TYPE", "KEYS", "SCAN":
+		return KeyspaceHandler(command, args)
+
+	case "RENAME", "RENAMENX", "COPY", "MOVE", "SWAPDB", "FLUSHDB", "FLUSHALL", "DBSIZE":
+		return CacheManagementHandler(command, args)
+
+	case "INCR", "DECR", "INCRBY", "DECRBY", "INCRBYFLOAT", "APPEND", "STRLEN", "GETRANGE", "SETRANGE", "GETDEL":
+		return StringHandler(command, args)
+
+	case "LPUSH", "RPUSH", "LPOP", "RPOP", "LLEN", "LRANGE", "LINDEX", "LSET", "LTRIM", "LREM", "LMOVE":
+		return ListHandler(command, args)
+
+	case "BLPOP", "BRPOP", "BLMOVE":
+		// Blocking is handled in SwitchCases, inside transactions and scripts these never wait
+		return BlockingListHandler(command, args, false, nil)
 	}
 
 	return "", fmt.Errorf("Unknown command !!!")
@@ -126,7 +270,7 @@ func SaveCacheHandler(args []string) error {
 	// CASE time -> save cache[cacheIndex] in "snapshot+cachIndex".gob file periodically (time in seconds)
 
 	if len(args) == 0 {
-		return utils.StoreCacheGobEncoded("dump", CurrentCache.Data)
+		return storeCache("dump", CurrentCache)
 	}
 
 	num, err := strconv.Atoi(args[0])
@@ -154,7 +298,7 @@ func SaveCacheHandler(args []string) error {
 	// Time of atleast 60 seconds is required to be considered for periodic snapshots
 	if period <= 60 {
 		fileName = fmt.Sprintf("dump_%v", num)
-		return utils.StoreCacheGobEncoded(fileName, Caches[num].Data)
+		return storeCache(fileName, &Caches[num])
 	}
 
 	currentTime := strconv.Itoa(int(time.Now().Unix()))
@@ -163,6 +307,14 @@ func SaveCacheHandler(args []string) error {
 	return SetSnapshots(fileName, uint8(num), uint32(period))
 }
 
+// Lists and hashes are modified in place, so the cache is locked while it's being encoded
+func storeCache(fileName string, cache *Cache) error {
+	cache.Mutex.Lock()
+	defer cache.Mutex.Unlock()
+
+	return utils.StoreCacheGobEncoded(fileName, cache.Data)
+}
+
 /*
 RETAIN [fileName] (fileName default : dump.gob)
 Overwrites current cache and skiplist
@@ -182,7 +334,7 @@ func RetainCacheHandler(args []string) error {
 		return err
 	}
 
-	CurrentCache = &Cache{Data: m, SkipList: utils.CreateTTLSkipList(48)}
+	CurrentCache = &Cache{Data: m, SkipList: utils.CreateTTLSkipList(48), Index: CurrentCache.Index}
 
 	CurrentCache.Mutex.Lock()
 	defer CurrentCache.Mutex.Unlock()
@@ -210,78 +362,284 @@ func DelHandler(args []string) error {
 	item, exist := CurrentCache.Data[args[0]]
 
 	if !exist {
-		return fmt.Errorf("DEL %s : Key doesn't exist !!!", args[0])
+		return noKeyErrorf("DEL %s : Key doesn't exist !!!", args[0])
 	}
 
-	delete(CurrentCache.Data, args[0])
+	CurrentCache.deleteItem(args[0], item)
 
-	CurrentCache.SkipList.Delete(args[0], item.TTL)
+	NotifyKeyspaceEvent(NotifyGeneric, "del", args[0], CurrentCache.Index)
 
 	return nil
 }
 
-func SetHandler(args []string) error {
+// Removes the key along with its ttl entry. cache.Mutex must be held.
+func (cache *Cache) deleteItem(key string, item CacheItem) {
+	delete(cache.Data, key)
+
+	if item.CanExpire {
+		cache.SkipList.Delete(key, item.TTL)
+	}
+}
+
+// Sets (ttl > 0) or removes (ttl = 0) the expiry of an existing key. cache.Mutex must be held.
+func (cache *Cache) setItemTTL(key string, item CacheItem, ttl uint32) {
+	if item.CanExpire {
+		cache.SkipList.Delete(key, item.TTL)
+	}
+
+	item.CanExpire = ttl > 0
+	item.TTL = 0
+
+	if ttl > 0 {
+		item.TTL = expiryFromTTL(ttl)
+		cache.SkipList.Insert(key, item.TTL)
+	}
+
+	cache.Data[key] = item
+}
+
+// ttl in seconds -> unix time of expiry
+func expiryFromTTL(ttl uint32) uint32 {
+	now := uint32(time.Now().Unix())
+
+	if ttl > math.MaxInt32-now-1 {
+		return math.MaxInt32 - 1
+	}
+
+	return now + ttl
+}
+
+// EXPIRE key seconds | TTL key | PERSIST key
+func ExpireHandler(command string, args []string) (string, error) {
+	if len(args) == 0 {
+		return "", fmt.Errorf("%v : Missing Key", command)
+	}
+
+	cache := CurrentCache
+
+	cache.Mutex.Lock()
+	defer cache.Mutex.Unlock()
+
+	item, exist := cache.Data[args[0]]
+
+	switch command {
+	case "EXPIRE":
+		if len(args) == 1 {
+			return "", fmt.Errorf("EXPIRE %v : Missing ttl", args[0])
+		}
+
+		ttl, err := strconv.ParseUint(args[1], 10, 32)
+		if err != nil || ttl == 0 {
+			return "", fmt.Errorf("EXPIRE %v : ttl should be a positive number of seconds", args[0])
+		}
+
+		if !exist {
+			return "", noKeyErrorf("EXPIRE %v : Key doesn't exist !!!", args[0])
+		}
+
+		cache.setItemTTL(args[0], item, uint32(ttl))
+
+		return ">> SUCCESS", nil
+
+	case "TTL":
+		// -2 -> key doesn't exist, -1 -> key doesn't expire
+		if !exist {
+			return ">> -2", nil
+		}
+
+		if !item.CanExpire {
+			return ">> -1", nil
+		}
+
+		remaining := int64(item.TTL) - time.Now().Unix()
+		if remaining < 0 {
+			remaining = 0
+		}
+
+		return fmt.Sprintf(">> %v", remaining), nil
+
+	case "PERSIST":
+		if !exist {
+			return "", noKeyErrorf("PERSIST %v : Key doesn't exist !!!", args[0])
+		}
+
+		cache.setItemTTL(args[0], item, 0)
+
+		return ">> SUCCESS", nil
+	}
+
+	return "", fmt.Errorf("Unknown command !!!")
+}
+
+// SET key value [ttl] [NX | XX] [GET] [EX seconds | PX milliseconds | EXAT unix-seconds | PXAT unix-milliseconds | KEEPTTL]
+// Output -> SUCCESS, the old value with GET, or (nil) when NX / XX stopped the write
+func SetHandler(args []string) (string, error) {
 	if len(args) == 0 {
-		return fmt.Errorf("SET : Missing Key and Value")
+		return "", fmt.Errorf("SET : Missing Key and Value")
 	}
 
 	if len(args) == 1 {
-		return fmt.Errorf("SET %s: Add value as well !!!", args[0])
+		return "", fmt.Errorf("SET %s: Add value as well !!!", args[0])
 	}
 
-	var value string = args[1]
-	var ttl uint32
-	if len(args) > 2 {
-		val, err := strconv.Atoi(args[2])
+	key := args[0]
+	value := args[1]
 
-		if err != nil {
-			ttl = 0
-		} else {
-			ttl = uint32(val)
+	opts, err := parseSetOptions(key, args[2:])
+	if err != nil {
+		return "", err
+	}
+
+	cache := CurrentCache
+
+	cache.Mutex.Lock()
+	defer cache.Mutex.Unlock()
+
+	item, exist := cache.Data[key]
+
+	if opts.get && exist && item.Kind != KindString {
+		return "", wrongTypeError("SET", key)
+	}
+
+	output := ">> SUCCESS"
+	if opts.get {
+		output = nilOutput
+		if exist {
+			output = formatValue(item.Val)
 		}
 	}
 
-	if args[1][0] == '"' {
-		for i := 2; i < len(args); i++ {
-			value += " " + args[i]
-			if strings.ContainsAny(args[i], "\"") {
-				break
-			}
+	if (opts.nx && exist) || (opts.xx && !exist) {
+		return nilOutput, nil
+	}
+
+	newItem := CacheItem{Val: value}
+
+	if opts.keepTTL && exist {
+		newItem.CanExpire = item.CanExpire
+		newItem.TTL = item.TTL
+	}
+
+	if exist {
+		cache.deleteItem(key, item)
+	}
+
+	// EXAT / PXAT in the past -> key expires right away
+	if opts.expiry > 0 && opts.expiry <= uint32(time.Now().Unix()) {
+		if exist {
+			NotifyKeyspaceEvent(NotifyGeneric, "del", key, cache.Index)
 		}
+
+		return output, nil
 	}
 
-	canExpire := false
-	var expiry uint32
+	if opts.expiry > 0 {
+		newItem.CanExpire = true
+		newItem.TTL = opts.expiry
+	}
 
-	if ttl > 0 {
-		canExpire = true
+	cache.Data[key] = newItem
+
+	if newItem.CanExpire {
+		cache.SkipList.Insert(key, newItem.TTL)
+	}
+
+	NotifyKeyspaceEvent(NotifyString, "set", key, cache.Index)
+
+	return output, nil
+}
+
+type setOptions struct {
+	nx, xx, get, keepTTL bool
+	expiry               uint32 // unix time, 0 -> no expiry
+}
+
+func parseSetOptions(key string, args []string) (setOptions, error) {
+	var opts setOptions
+	expirySet := false
+
+	for i := 0; i < len(args); i++ {
+		option := strings.ToUpper(args[i])
+
+		// SET key value ttl -> older positional form of EX
+		if i == 0 {
+			if ttl, err := strconv.ParseUint(args[i], 10, 32); err == nil {
+				if ttl > 0 {
+					opts.expiry = expiryFromTTL(uint32(ttl))
+				}
+				expirySet = true
+				continue
+			}
+		}
+
+		switch option {
+		case "NX":
+			opts.nx = true
+		case "XX":
+			opts.xx = true
+		case "GET":
+			opts.get = true
+		case "KEEPTTL":
+			if expirySet {
+				return opts, fmt.Errorf("SET %v : Only one of EX, PX, EXAT, PXAT and KEEPTTL can be given", key)
+			}
+			opts.keepTTL = true
+			expirySet = true
+		case "EX", "PX", "EXAT", "PXAT":
+			if expirySet {
+				return opts, fmt.Errorf("SET %v : Only one of EX, PX, EXAT, PXAT and KEEPTTL can be given", key)
+			}
 
-		now := uint32(time.Now().Unix())
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("SET %v : Missing value for %v", key, option)
+			}
+
+			i++
+			expiry, err := parseExpiry(option, args[i])
+			if err != nil {
+				return opts, fmt.Errorf("SET %v : %v", key, err)
+			}
 
-		if ttl > math.MaxInt32-now-1 {
-			expiry = math.MaxInt32 - 1
-		} else {
-			expiry = now + ttl
+			opts.expiry = expiry
+			expirySet = true
+		default:
+			return opts, fmt.Errorf("SET %v : Unknown option %v", key, args[i])
 		}
+	}
 
+	if opts.nx && opts.xx {
+		return opts, fmt.Errorf("SET %v : NX and XX can't be used together", key)
 	}
 
-	CurrentCache.Mutex.Lock()
-	item, exist := CurrentCache.Data[args[0]]
-	if exist {
-		// NOTE: Seperate command for changing ttl, so don't bother with it here
-		CurrentCache.Data[args[0]] = CacheItem{Val: value, CanExpire: item.CanExpire, TTL: item.TTL}
-	} else {
-		CurrentCache.Data[args[0]] = CacheItem{Val: value, CanExpire: canExpire, TTL: expiry}
+	return opts, nil
+}
 
-		if canExpire {
-			CurrentCache.SkipList.Insert(args[0], expiry)
+// Converts the value of EX / PX / EXAT / PXAT to the unix time of expiry.
+// TTLs are kept in seconds, so milliseconds are rounded up.
+func parseExpiry(option string, value string) (uint32, error) {
+	num, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || num <= 0 {
+		return 0, fmt.Errorf("Invalid expire time %v, should be a positive integer", value)
+	}
+
+	if option == "PX" || option == "PXAT" {
+		num = (num + 999) / 1000
+	}
+
+	switch option {
+	case "EX", "PX":
+		if num > math.MaxInt32 {
+			return 0, fmt.Errorf("Invalid expire time %v, too large", value)
 		}
+
+		return expiryFromTTL(uint32(num)), nil
 	}
 
-	CurrentCache.Mutex.Unlock()
+	if num >= math.MaxInt32 {
+		return 0, fmt.Errorf("Invalid expire time %v, too large", value)
+	}
 
-	return nil
+	return uint32(num), nil
 }
 
 func GetHandler(args []string) (string, error) {
@@ -294,7 +652,11 @@ func GetHandler(args []string) (string, error) {
 	CurrentCache.Mutex.Unlock()
 
 	if !exist {
-		return "", fmt.Errorf("GET %v: Key doesn't exist!!!", args[0])
+		return "", noKeyErrorf("GET %v: Key doesn't exist!!!", args[0])
+	}
+
+	if item.Kind != KindString {
+		return "", wrongTypeError("GET", args[0])
 	}
 
 	return item.Val, nil
@@ -338,7 +700,7 @@ func runSnapShot(fileName string, cacheIndex uint8, t uint32, doneChannel <-chan
 		select {
 		case <-time.Tick(time.Second * time.Duration(t)):
 			fmt.Printf("Snapshotted cacheIndex: %v!!!", cacheIndex)
-			utils.StoreCacheGobEncoded(fileName, Caches[cacheIndex].Data)
+			storeCache(fileName, &Caches[cacheIndex])
 		case <-doneChannel:
 			fmt.Printf("Snapshotting stopped for cacheIndex: %v!!!", cacheIndex)
 			return
@@ -374,7 +736,22 @@ func StopSnapshot(args []string) error {
 	return nil
 }
 
-// BF_CREATE name [error_rate] [capacity] [SCALABLE -> T/F]
+// Bloom filter stored at name in the cache, nil if the key doesn't exist. cache.Mutex must be held.
+func (cache *Cache) getBloomFilter(command string, name string) (utils.BloomFilter, error) {
+	item, exists := cache.Data[name]
+
+	if !exists {
+		return nil, nil
+	}
+
+	if item.Kind != KindBloomFilter {
+		return nil, wrongTypeError(command, name)
+	}
+
+	return item.Bloom, nil
+}
+
+// BF_CREATE name [error_rate] [capacity] [SCALABLE -> T/F] [expansion] [tightening_ratio]
 func BloomFilterCreationHandler(args []string) error {
 	if len(args) == 0 {
 		return fmt.Errorf("BF_CREATE : Missing name of bloom filter")
@@ -384,8 +761,22 @@ func BloomFilterCreationHandler(args []string) error {
 	cap := 1000
 	key := args[0]
 	scalable := false
+	expansion := utils.DefaultBloomFilterExpansion
+	tighteningRatio := utils.DefaultBloomFilterTighteningRatio
 
-	var err error
+	cache := CurrentCache
+
+	cache.Mutex.Lock()
+	defer cache.Mutex.Unlock()
+
+	existing, err := cache.getBloomFilter("BF_CREATE", key)
+	if err != nil {
+		return err
+	}
+
+	if existing != nil {
+		return fmt.Errorf("BF_CREATE %v : Bloom filter already exists !!!", key)
+	}
 
 	if len(args) > 1 {
 		errorRate, err = strconv.ParseFloat(args[1], 32)
@@ -407,12 +798,40 @@ func BloomFilterCreationHandler(args []string) error {
 		}
 	}
 
+	if len(args) > 4 {
+		expansion, err = strconv.Atoi(args[4])
+		if err != nil || expansion < 1 {
+			return fmt.Errorf("BF_CREATE %v : Expansion should be a positive integer", key)
+		}
+	}
+
+	if len(args) > 5 {
+		tighteningRatio, err = strconv.ParseFloat(args[5], 64)
+		if err != nil || tighteningRatio <= 0 || tighteningRatio >= 1 {
+			return fmt.Errorf("BF_CREATE %v : Tightening ratio should lie in the range of (0, 1)", key)
+		}
+	}
+
+	if errorRate <= 0 || errorRate >= 1 {
+		return fmt.Errorf("BF_CREATE %v : Error rate should lie in the range of (0, 1)", key)
+	}
+
+	if cap < 1 {
+		return fmt.Errorf("BF_CREATE %v : Capacity should be a positive integer", key)
+	}
+
+	item := CacheItem{Kind: KindBloomFilter}
+
 	if scalable {
-		BloomFilterMap[key] = utils.CreateAdaptiveBloomFilter(uint(cap), errorRate)
+		item.Bloom = utils.CreateAdaptiveBloomFilter(uint(cap), errorRate, uint(expansion), tighteningRatio)
 	} else {
-		BloomFilterMap[key] = utils.CreateBloomFilter(uint(cap), errorRate)
+		item.Bloom = utils.CreateBloomFilter(uint(cap), errorRate)
 	}
 
+	cache.Data[key] = item
+
+	NotifyKeyspaceEvent(NotifyModule, "bf_create", key, cache.Index)
+
 	return nil
 
 }
@@ -427,13 +846,27 @@ func BloomFilterAddHandler(args []string) error {
 		return fmt.Errorf("BF_ADD : Missing Value")
 	}
 
-	val, exists := BloomFilterMap[args[0]]
+	cache := CurrentCache
 
-	if !exists {
+	cache.Mutex.Lock()
+	defer cache.Mutex.Unlock()
+
+	val, err := cache.getBloomFilter("BF_ADD", args[0])
+	if err != nil {
+		return err
+	}
+
+	if val == nil {
 		return fmt.Errorf("BF_ADD : Wrong name of the bloom filter")
 	}
 
-	return val.Set(args[1])
+	if err := val.Set(args[1]); err != nil {
+		return err
+	}
+
+	NotifyKeyspaceEvent(NotifyModule, "bf_add", args[0], cache.Index)
+
+	return nil
 
 }
 
@@ -447,11 +880,457 @@ func BloomFilterExistsHandler(args []string) (bool, error) {
 		return false, fmt.Errorf("BF_EXISTS : Missing Value")
 	}
 
-	val, exists := BloomFilterMap[args[0]]
+	cache := CurrentCache
 
-	if !exists {
+	cache.Mutex.Lock()
+	defer cache.Mutex.Unlock()
+
+	val, err := cache.getBloomFilter("BF_EXISTS", args[0])
+	if err != nil {
+		return false, err
+	}
+
+	if val == nil {
 		return false, fmt.Errorf("BF_EXISTS : Wrong name of the bloom filter")
 	}
 
 	return val.DoesExist(args[1]), nil
 }
+
+// BF_MADD name key [key ...] -> true for the keys which weren't in the bloom filter before
+func BloomFilterMultiAddHandler(args []string) (string, error) {
+	if len(args) < 2 {
+		return "", fmt.Errorf("BF_MADD : Missing name of the bloom filter and Values")
+	}
+
+	cache := CurrentCache
+
+	cache.Mutex.Lock()
+	defer cache.Mutex.Unlock()
+
+	val, err := cache.getBloomFilter("BF_MADD", args[0])
+	if err != nil {
+		return "", err
+	}
+
+	if val == nil {
+		return "", fmt.Errorf("BF_MADD : Wrong name of the bloom filter")
+	}
+
+	added := make([]string, 0, len(args)-1)
+
+	for _, key := range args[1:] {
+		isNew := !val.DoesExist(key)
+
+		if err := val.Set(key); err != nil {
+			return "", err
+		}
+
+		added = append(added, strconv.FormatBool(isNew))
+	}
+
+	NotifyKeyspaceEvent(NotifyModule, "bf_add", args[0], cache.Index)
+
+	return formatList(added), nil
+}
+
+// BF_MEXISTS name key [key ...]
+func BloomFilterMultiExistsHandler(args []string) (string, error) {
+	if len(args) < 2 {
+		return "", fmt.Errorf("BF_MEXISTS : Missing name of the bloom filter and Values")
+	}
+
+	cache := CurrentCache
+
+	cache.Mutex.Lock()
+	defer cache.Mutex.Unlock()
+
+	val, err := cache.getBloomFilter("BF_MEXISTS", args[0])
+	if err != nil {
+		return "", err
+	}
+
+	if val == nil {
+		return "", fmt.Errorf("BF_MEXISTS : Wrong name of the bloom filter")
+	}
+
+	result := make([]string, 0, len(args)-1)
+
+	for _, key := range args[1:] {
+		result = append(result, strconv.FormatBool(val.DoesExist(key)))
+	}
+
+	return formatList(result), nil
+}
+
+// BF_DROP name
+func BloomFilterDropHandler(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("BF_DROP : Missing name of the bloom filter")
+	}
+
+	cache := CurrentCache
+
+	cache.Mutex.Lock()
+	defer cache.Mutex.Unlock()
+
+	val, err := cache.getBloomFilter("BF_DROP", args[0])
+	if err != nil {
+		return err
+	}
+
+	if val == nil {
+		return fmt.Errorf("BF_DROP : Wrong name of the bloom filter")
+	}
+
+	cache.deleteItem(args[0], cache.Data[args[0]])
+
+	NotifyKeyspaceEvent(NotifyGeneric, "del", args[0], cache.Index)
+
+	return nil
+}
+
+// BF_LIST [pattern] -> names of the bloom filters in the current cache matching the glob pattern
+func BloomFilterListHandler(args []string) string {
+	names := []string{}
+
+	cache := CurrentCache
+
+	cache.Mutex.Lock()
+	defer cache.Mutex.Unlock()
+
+	for name, item := range cache.Data {
+		if item.Kind != KindBloomFilter {
+			continue
+		}
+
+		if len(args) == 0 || utils.GlobMatch(args[0], name) {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	return formatList(names)
+}
+
+// BF_INFO name
+func BloomFilterInfoHandler(args []string) (string, error) {
+	if len(args) == 0 {
+		return "", fmt.Errorf("BF_INFO : Missing name of the bloom filter")
+	}
+
+	cache := CurrentCache
+
+	cache.Mutex.Lock()
+	defer cache.Mutex.Unlock()
+
+	val, err := cache.getBloomFilter("BF_INFO", args[0])
+	if err != nil {
+		return "", err
+	}
+
+	if val == nil {
+		return "", fmt.Errorf("BF_INFO : Wrong name of the bloom filter")
+	}
+
+	info := val.Info()
+
+	return formatList([]string{
+		fmt.Sprintf("Capacity : %v", info.Capacity),
+		fmt.Sprintf("Items inserted : %v", info.NumItems),
+		fmt.Sprintf("Number of filters : %v", info.NumFilters),
+		fmt.Sprintf("Bits set : %v / %v", info.BitsSet, info.TotalBits),
+		fmt.Sprintf("Size : %v bytes", info.SizeInBytes),
+		fmt.Sprintf("Error rate : %v", info.ErrorRate),
+		fmt.Sprintf("Estimated false positive rate : %.6f", info.FalsePositiveRate),
+	}), nil
+}
+
+// BF_SCANDUMP name iterator -> next iterator and the chunk (base64), iterator 0 is returned at the end
+func BloomFilterScanDumpHandler(args []string) (string, error) {
+	if len(args) < 2 {
+		return "", fmt.Errorf("BF_SCANDUMP : Missing name of the bloom filter and iterator")
+	}
+
+	iterator, err := strconv.ParseUint(args[1], 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("BF_SCANDUMP : Iterator should be a non negative integer")
+	}
+
+	cache := CurrentCache
+
+	cache.Mutex.Lock()
+	defer cache.Mutex.Unlock()
+
+	val, err := cache.getBloomFilter("BF_SCANDUMP", args[0])
+	if err != nil {
+		return "", err
+	}
+
+	if val == nil {
+		return "", fmt.Errorf("BF_SCANDUMP : Wrong name of the bloom filter")
+	}
+
+	next, chunk, err := utils.BloomFilterScanDump(val, iterator)
+	if err != nil {
+		return "", err
+	}
+
+	if next == 0 {
+		cursor := "0"
+		return formatNullableList([]*string{&cursor, nil}), nil
+	}
+
+	return formatList([]string{strconv.FormatUint(next, 10), base64.StdEncoding.EncodeToString(chunk)}), nil
+}
+
+// BF_LOADCHUNK name iterator data -> iterator and data as returned by BF_SCANDUMP
+func BloomFilterLoadChunkHandler(args []string) error {
+	if len(args) < 3 {
+		return fmt.Errorf("BF_LOADCHUNK : Missing name of the bloom filter, iterator and data")
+	}
+
+	key := args[0]
+
+	iterator, err := strconv.ParseUint(args[1], 10, 64)
+	if err != nil || iterator == 0 {
+		return fmt.Errorf("BF_LOADCHUNK %v : Iterator should be a positive integer", key)
+	}
+
+	chunk, err := base64.StdEncoding.DecodeString(args[2])
+	if err != nil {
+		return fmt.Errorf("BF_LOADCHUNK %v : Data should be base64 encoded", key)
+	}
+
+	cache := CurrentCache
+
+	cache.Mutex.Lock()
+	defer cache.Mutex.Unlock()
+
+	val, err := cache.getBloomFilter("BF_LOADCHUNK", key)
+	if err != nil {
+		return err
+	}
+
+	// Header chunk creates the filter, so it can't overwrite an existing one
+	if iterator == 1 && val != nil {
+		return fmt.Errorf("BF_LOADCHUNK %v : Bloom filter already exists !!!", key)
+	}
+
+	if iterator != 1 && val == nil {
+		return fmt.Errorf("BF_LOADCHUNK : Wrong name of the bloom filter")
+	}
+
+	val, err = utils.BloomFilterLoadChunk(val, iterator, chunk)
+	if err != nil {
+		return fmt.Errorf("BF_LOADCHUNK %v : %v", key, err)
+	}
+
+	// Later chunks fill the bits of the existing filter in place, keeping its TTL
+	if iterator == 1 {
+		cache.Data[key] = CacheItem{Kind: KindBloomFilter, Bloom: val}
+	}
+
+	NotifyKeyspaceEvent(NotifyModule, "bf_loadchunk", key, cache.Index)
+
+	return nil
+}
+
+// BF_MERGE destination source [source ...] -> ORs plain bloom filters of the same size, destination is created if missing
+func BloomFilterMergeHandler(args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("BF_MERGE : Missing destination and source bloom filters")
+	}
+
+	cache := CurrentCache
+
+	cache.Mutex.Lock()
+	defer cache.Mutex.Unlock()
+
+	filters := make([]*utils.PlainBloomFilter, 0, len(args))
+
+	for i, name := range args {
+		val, err := cache.getBloomFilter("BF_MERGE", name)
+		if err != nil {
+			return err
+		}
+
+		if val == nil {
+			if i == 0 {
+				filters = append(filters, nil)
+				continue
+			}
+
+			return fmt.Errorf("BF_MERGE : Wrong name of the bloom filter %v", name)
+		}
+
+		plain, isPlain := val.(*utils.PlainBloomFilter)
+		if !isPlain {
+			return fmt.Errorf("BF_MERGE %v : Only non scalable bloom filters can be merged", name)
+		}
+
+		filters = append(filters, plain)
+	}
+
+	dest := filters[0]
+
+	if dest == nil {
+		source := filters[1]
+		dest = &utils.PlainBloomFilter{
+			Arr:               make([]uint64, len(source.Arr)),
+			EstimatedCapacity: source.EstimatedCapacity,
+			HashFuncNum:       source.HashFuncNum,
+			ErrorRate:         source.ErrorRate,
+		}
+	}
+
+	// Compatibility is checked for all the sources before merging, so that a failure leaves the destination untouched
+	for _, source := range filters[1:] {
+		if len(source.Arr) != len(dest.Arr) || source.HashFuncNum != dest.HashFuncNum {
+			return fmt.Errorf("BF_MERGE %v : Bloom filters should have the same size and number of hash functions to be merged !!!", args[0])
+		}
+	}
+
+	for _, source := range filters[1:] {
+		if err := dest.Merge(source); err != nil {
+			return fmt.Errorf("BF_MERGE %v : %v", args[0], err)
+		}
+	}
+
+	if filters[0] == nil {
+		cache.Data[args[0]] = CacheItem{Kind: KindBloomFilter, Bloom: dest}
+	}
+
+	NotifyKeyspaceEvent(NotifyModule, "bf_merge", args[0], cache.Index)
+
+	return nil
+}
+
+/*
+***************************
+Replies
+***************************
+*/
+
+/*
+Values are sent as ">> value". Nil and lists start with ">>\n" instead, so that no value can be taken for them.
+Every reply stays on its own line : \ and newlines in values are escaped as \\ and \n, so a value holding \r\n can't end it early.
+*/
+const (
+	nilOutput       = ">>\n(nil)"
+	emptyListOutput = ">>\n(empty list)"
+)
+
+// Errors for missing keys are sent with this prefix, so that clients can tell a miss from other errors
+const NoKeyPrefix = "NOKEY "
+
+type NoKeyError struct {
+	msg string
+}
+
+func (err *NoKeyError) Error() string {
+	return err.msg
+}
+
+func noKeyErrorf(format string, args ...any) error {
+	return &NoKeyError{fmt.Sprintf(format, args...)}
+}
+
+// ">> value" with the value escaped
+func formatValue(val string) string {
+	return ">> " + escapeValue(val)
+}
+
+// Formats multiple values as numbered lines -> ">>\n1) a\n2) b". Values are escaped, and one reading (nil) is sent as \(nil).
+func formatList(items []string) string {
+	nullable := make([]*string, len(items))
+	for i := range items {
+		nullable[i] = &items[i]
+	}
+
+	return formatNullableList(nullable)
+}
+
+// Same as formatList, with (nil) for the nil items
+func formatNullableList(items []*string) string {
+	if len(items) == 0 {
+		return emptyListOutput
+	}
+
+	var sb strings.Builder
+	sb.WriteString(">>")
+
+	for i, item := range items {
+		switch {
+		case item == nil:
+			fmt.Fprintf(&sb, "\n%v) (nil)", i+1)
+		case *item == "(nil)":
+			fmt.Fprintf(&sb, "\n%v) \\(nil)", i+1)
+		default:
+			fmt.Fprintf(&sb, "\n%v) %v", i+1, escapeValue(*item))
+		}
+	}
+
+	return sb.String()
+}
+
+var valueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+func escapeValue(val string) string {
+	return valueEscaper.Replace(val)
+}
+
+// Items of an output made by formatNullableList, nil for (nil). false if output isn't a list.
+func parseListOutput(output string) ([]*string, bool) {
+	if output == emptyListOutput {
+		return []*string{}, true
+	}
+
+	body, isList := strings.CutPrefix(output, ">>\n")
+	if !isList || body == "(nil)" {
+		return nil, false
+	}
+
+	lines := strings.Split(body, "\n")
+	items := make([]*string, 0, len(lines))
+
+	for i, line := range lines {
+		item, found := strings.CutPrefix(line, strconv.Itoa(i+1)+") ")
+		if !found {
+			return nil, false
+		}
+
+		if item == "(nil)" {
+			items = append(items, nil)
+			continue
+		}
+
+		item = unescapeValue(item)
+		items = append(items, &item)
+	}
+
+	return items, true
+}
+
+func unescapeValue(val string) string {
+	if !strings.Contains(val, `\`) {
+		return val
+	}
+
+	var sb strings.Builder
+
+	for i := 0; i < len(val); i++ {
+		if val[i] == '\\' && i+1 < len(val) {
+			i++
+			if val[i] == 'n' {
+				sb.WriteByte('\n')
+				continue
+			}
+		}
+
+		sb.WriteByte(val[i])
+	}
+
+	return sb.String()
+}
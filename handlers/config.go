@@ -1,9 +1,14 @@
 package handlers
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
+	"net"
+	"os"
 	"prac/utils"
 	"sync"
+	"time"
 )
 
 type Statement struct {
@@ -16,19 +21,121 @@ type Connection struct {
 	IP               string
 	TransactionQueue []Statement
 	TransactionFlag  bool
+	Conn             net.Conn      // used for pushing pub/sub messages
+	Reader           *bufio.Reader // commands are read from it, watched for the client leaving while a command blocks
+
+	subMu    sync.Mutex
+	channels map[string]bool
+	patterns map[string]bool
+	outbox   chan string // pub/sub messages waiting to be written, nil when not subscribed
 }
 
+// The returned channel is closed if the client goes away before stopWatching is called. Nothing is read
+// from the connection, so commands sent after a blocking command are still there once it's done.
+func (c *Connection) watchDisconnect() (<-chan struct{}, func()) {
+	if c.Reader == nil || c.Conn == nil {
+		return nil, func() {}
+	}
+
+	disconnected := make(chan struct{})
+	finished := make(chan struct{})
+
+	go func() {
+		defer close(finished)
+
+		if _, err := c.Reader.Peek(1); err != nil && !errors.Is(err, os.ErrDeadlineExceeded) {
+			close(disconnected)
+		}
+	}()
+
+	stopWatching := func() {
+		// Wakes up Peek, the deadline error isn't kept by the reader
+		c.Conn.SetReadDeadline(time.Now())
+		<-finished
+		c.Conn.SetReadDeadline(time.Time{})
+	}
+
+	return disconnected, stopWatching
+}
+
+type ValueKind uint8
+
+const (
+	KindString ValueKind = iota
+	KindList
+	KindHash
+	KindSet
+	KindSortedSet
+	KindHyperLogLog
+	KindBloomFilter
+)
+
 type CacheItem struct {
 	Val       string
 	CanExpire bool
 	TTL       uint32
+	Kind      ValueKind
+	List      []string
+	Hash      map[string]string
+	Set       map[string]bool
+	SortedSet map[string]int // member -> score
+	HLL       *utils.HyperLogLog
+	Bloom     utils.BloomFilter
+
+	scoreIndex *utils.ScoreSkipList // members ordered by score, built lazily from SortedSet
+}
+
+// Deep copy, so that the copy isn't affected by later changes to lists and hashes
+func (item CacheItem) Clone() CacheItem {
+	if item.List != nil {
+		item.List = append([]string{}, item.List...)
+	}
+
+	if item.Hash != nil {
+		hash := make(map[string]string, len(item.Hash))
+		for field, val := range item.Hash {
+			hash[field] = val
+		}
+		item.Hash = hash
+	}
+
+	if item.Set != nil {
+		set := make(map[string]bool, len(item.Set))
+		for member := range item.Set {
+			set[member] = true
+		}
+		item.Set = set
+	}
+
+	if item.SortedSet != nil {
+		sortedSet := make(map[string]int, len(item.SortedSet))
+		for member, score := range item.SortedSet {
+			sortedSet[member] = score
+		}
+		item.SortedSet = sortedSet
+		item.scoreIndex = nil
+	}
+
+	if item.HLL != nil {
+		item.HLL = item.HLL.Clone()
+	}
+
+	if item.Bloom != nil {
+		item.Bloom = item.Bloom.Clone()
+	}
+
+	return item
 }
 
 type Cache struct {
-	Mutex            sync.Mutex
-	TransactionMutex sync.Mutex
+	Mutex sync.Mutex
+	// Commands hold it shared, scripts and commits hold it alone, so nothing runs in between their steps
+	TransactionMutex sync.RWMutex
 	Data             map[string]CacheItem
 	SkipList         *utils.TTLSkipList
+	Index            uint8
+
+	blockedClients map[string][]*listWaiter // connections blocked on BLPOP, BRPOP and BLMOVE per key
 }
 
 type CurrentSnapshot struct {
@@ -38,12 +145,15 @@ type CurrentSnapshot struct {
 
 var ConnectionMap = make(map[string]*Connection)
 var SnapShotMap = make(map[uint8]CurrentSnapshot)
-var BloomFilterMap = make(map[string]utils.BloomFilter)
+var CuckooFilterMap = make(map[string]utils.DeletableFilter)
+var CountMinSketchMap = make(map[string]*utils.CountMinSketch)
+var TopKMap = make(map[string]*utils.TopK)
 
 var Caches []Cache
 var CurrentCache *Cache
 var DefaultCacheNum uint8 // total number of caches
 var DefaultSkipListMaxHeight uint8
+var ScriptTimeLimit = 5 * time.Second // scripts running longer than this are aborted
 
 func SetUpCaches(cacheNum uint8, skipListMaxHeight uint8) error {
 
@@ -57,7 +167,7 @@ func SetUpCaches(cacheNum uint8, skipListMaxHeight uint8) error {
 	Caches = make([]Cache, DefaultCacheNum)
 
 	for index := range Caches {
-		Caches[index] = Cache{Data: make(map[string]CacheItem), SkipList: utils.CreateTTLSkipList(DefaultSkipListMaxHeight)}
+		Caches[index] = Cache{Data: make(map[string]CacheItem), SkipList: utils.CreateTTLSkipList(DefaultSkipListMaxHeight), Index: uint8(index)}
 	}
 
 	CurrentCache = &Caches[0]
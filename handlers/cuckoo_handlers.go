@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"fmt"
+	"prac/utils"
+	"strconv"
+	"sync"
+)
+
+// Guards CuckooFilterMap and the filters stored in it
+var cuckooFilterMutex sync.Mutex
+
+func CuckooFilterHandler(command string, args []string) (string, error) {
+	if len(args) == 0 {
+		return "", fmt.Errorf("%v : Missing name of the cuckoo filter", command)
+	}
+
+	key := args[0]
+
+	cuckooFilterMutex.Lock()
+	defer cuckooFilterMutex.Unlock()
+
+	filter, exists := CuckooFilterMap[key]
+
+	// CF_CREATE name [capacity] [bucket_size] [fingerprint_bits]
+	if command == "CF_CREATE" {
+		if exists {
+			return "", fmt.Errorf("CF_CREATE %v : Cuckoo filter already exists !!!", key)
+		}
+
+		capacity, bucketSize, fingerprintBits := uint64(1000), uint64(4), uint64(16)
+		var err error
+
+		if len(args) > 1 {
+			if capacity, err = strconv.ParseUint(args[1], 10, 32); err != nil {
+				return "", fmt.Errorf("CF_CREATE %v : Capacity should be a positive integer", key)
+			}
+		}
+
+		if len(args) > 2 {
+			if bucketSize, err = strconv.ParseUint(args[2], 10, 8); err != nil {
+				return "", fmt.Errorf("CF_CREATE %v : Bucket size should be a positive integer", key)
+			}
+		}
+
+		if len(args) > 3 {
+			if fingerprintBits, err = strconv.ParseUint(args[3], 10, 8); err != nil {
+				return "", fmt.Errorf("CF_CREATE %v : Fingerprint bits should be a positive integer", key)
+			}
+		}
+
+		cf, err := utils.CreateCuckooFilter(uint(capacity), uint(bucketSize), uint8(fingerprintBits))
+		if err != nil {
+			return "", fmt.Errorf("CF_CREATE %v : %v", key, err)
+		}
+
+		CuckooFilterMap[key] = cf
+
+		return ">> SUCCESS", nil
+	}
+
+	if len(args) == 1 {
+		return "", fmt.Errorf("%v : Missing Value", command)
+	}
+
+	if !exists {
+		return "", fmt.Errorf("%v : Wrong name of the cuckoo filter", command)
+	}
+
+	item := args[1]
+
+	switch command {
+	// CF_ADD name item -> the same item can be added multiple times
+	case "CF_ADD":
+		if err := filter.Set(item); err != nil {
+			return "", fmt.Errorf("CF_ADD %v : %v", key, err)
+		}
+
+		return ">> SUCCESS", nil
+
+	// CF_ADDNX name item -> adds only if the item doesn't exist
+	case "CF_ADDNX":
+		if filter.DoesExist(item) {
+			return ">> false", nil
+		}
+
+		if err := filter.Set(item); err != nil {
+			return "", fmt.Errorf("CF_ADDNX %v : %v", key, err)
+		}
+
+		return ">> true", nil
+
+	// CF_DEL name item
+	case "CF_DEL":
+		return fmt.Sprintf(">> %v", filter.Delete(item)), nil
+
+	// CF_EXISTS name item
+	case "CF_EXISTS":
+		return fmt.Sprintf(">> %v", filter.DoesExist(item)), nil
+
+	// CF_COUNT name item
+	case "CF_COUNT":
+		return fmt.Sprintf(">> %v", filter.Count(item)), nil
+	}
+
+	return "", fmt.Errorf("Unknown command !!!")
+}
@@ -0,0 +1,442 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"prac/utils"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var geoUnits = map[string]float64{"M": 1, "KM": 1000, "FT": 0.3048, "MI": 1609.34}
+
+type geoResult struct {
+	member string
+	score  int
+	dist   float64
+}
+
+func GeoHandler(command string, args []string) (string, error) {
+	if len(args) == 0 {
+		return "", fmt.Errorf("%v : Missing Key", command)
+	}
+
+	cache := CurrentCache
+	key := args[0]
+
+	cache.Mutex.Lock()
+	defer cache.Mutex.Unlock()
+
+	item, exists := cache.Data[key]
+
+	if exists && item.Kind != KindSortedSet {
+		return "", wrongTypeError(command, key)
+	}
+
+	switch command {
+	// GEOADD key [NX|XX] [CH] longitude latitude member [longitude latitude member ...]
+	case "GEOADD":
+		nx, xx, ch := false, false, false
+		i := 1
+
+	options:
+		for ; i < len(args); i++ {
+			switch strings.ToUpper(args[i]) {
+			case "NX":
+				nx = true
+			case "XX":
+				xx = true
+			case "CH":
+				ch = true
+			default:
+				break options
+			}
+		}
+
+		if nx && xx {
+			return "", fmt.Errorf("GEOADD %v : NX and XX can't be used together", key)
+		}
+
+		if len(args)-i == 0 || (len(args)-i)%3 != 0 {
+			return "", fmt.Errorf("GEOADD %v : Longitude, latitude and member should be given in triplets", key)
+		}
+
+		scores := make(map[string]int)
+		members := []string{}
+
+		for ; i < len(args); i += 3 {
+			lon, lonErr := strconv.ParseFloat(args[i], 64)
+			lat, latErr := strconv.ParseFloat(args[i+1], 64)
+
+			if lonErr != nil || latErr != nil {
+				return "", fmt.Errorf("GEOADD %v : Longitude and latitude should be numbers", key)
+			}
+
+			hash, err := utils.GeoEncode(lon, lat)
+			if err != nil {
+				return "", fmt.Errorf("GEOADD %v : %v", key, err)
+			}
+
+			if _, seen := scores[args[i+2]]; !seen {
+				members = append(members, args[i+2])
+			}
+
+			scores[args[i+2]] = int(hash)
+		}
+
+		if !exists {
+			item = CacheItem{Kind: KindSortedSet, SortedSet: make(map[string]int)}
+			cache.Data[key] = item
+		}
+
+		changed, written := 0, 0
+
+		for _, member := range members {
+			oldScore, memberExists := item.SortedSet[member]
+
+			if (nx && memberExists) || (xx && !memberExists) {
+				continue
+			}
+
+			if !memberExists || (ch && oldScore != scores[member]) {
+				changed++
+			}
+
+			cache.addToSortedSet(key, member, scores[member])
+			written++
+		}
+
+		if len(cache.Data[key].SortedSet) == 0 {
+			cache.deleteItem(key, cache.Data[key])
+		}
+
+		if written > 0 {
+			NotifyKeyspaceEvent(NotifySortedSet, "geoadd", key, cache.Index)
+		}
+
+		return fmt.Sprintf(">> %v", changed), nil
+
+	// GEOPOS key member [member ...]
+	case "GEOPOS":
+		if len(args) == 1 {
+			return "", fmt.Errorf("GEOPOS %v : Missing member", key)
+		}
+
+		positions := make([]*string, 0, len(args)-1)
+
+		for _, member := range args[1:] {
+			score, memberExists := item.SortedSet[member]
+
+			if !memberExists {
+				positions = append(positions, nil)
+				continue
+			}
+
+			position := formatGeoPosition(score)
+			positions = append(positions, &position)
+		}
+
+		return formatNullableList(positions), nil
+
+	// GEODIST key member1 member2 [M|KM|FT|MI]
+	case "GEODIST":
+		if len(args) < 3 {
+			return "", fmt.Errorf("GEODIST %v : Missing members", key)
+		}
+
+		unit := "M"
+		if len(args) > 3 {
+			unit = strings.ToUpper(args[3])
+		}
+
+		conversion, validUnit := geoUnits[unit]
+		if !validUnit {
+			return "", fmt.Errorf("GEODIST %v : Unit should be one of M, KM, FT or MI", key)
+		}
+
+		score1, exists1 := item.SortedSet[args[1]]
+		score2, exists2 := item.SortedSet[args[2]]
+
+		if !exists1 || !exists2 {
+			return nilOutput, nil
+		}
+
+		lon1, lat1 := utils.GeoDecode(uint64(score1))
+		lon2, lat2 := utils.GeoDecode(uint64(score2))
+
+		return fmt.Sprintf(">> %.4f", utils.GeoDistance(lon1, lat1, lon2, lat2)/conversion), nil
+
+	// GEOHASH key member [member ...]
+	case "GEOHASH":
+		if len(args) == 1 {
+			return "", fmt.Errorf("GEOHASH %v : Missing member", key)
+		}
+
+		hashes := make([]*string, 0, len(args)-1)
+
+		for _, member := range args[1:] {
+			score, memberExists := item.SortedSet[member]
+
+			if !memberExists {
+				hashes = append(hashes, nil)
+				continue
+			}
+
+			hash := utils.GeoHashString(utils.GeoDecode(uint64(score)))
+			hashes = append(hashes, &hash)
+		}
+
+		return formatNullableList(hashes), nil
+
+	/*
+		GEOSEARCH key FROMMEMBER member | FROMLONLAT longitude latitude
+			BYRADIUS radius M|KM|FT|MI | BYBOX width height M|KM|FT|MI
+			[ASC|DESC] [COUNT count] [WITHCOORD] [WITHDIST] [WITHHASH]
+	*/
+	case "GEOSEARCH":
+		return geoSearch(cache, key, item, args[1:])
+	}
+
+	return "", fmt.Errorf("Unknown command !!!")
+}
+
+func geoSearch(cache *Cache, key string, item CacheItem, args []string) (string, error) {
+	var centerLon, centerLat float64
+	var radius, width, height, conversion float64
+	hasCenter, byBox, hasShape := false, false, false
+	withCoord, withDist, withHash := false, false, false
+	order := ""
+	count := 0
+
+	missing := func(option string) error {
+		return fmt.Errorf("GEOSEARCH %v : Missing arguments for %v", key, option)
+	}
+
+	parseUnit := func(unit string) (float64, error) {
+		conversion, validUnit := geoUnits[strings.ToUpper(unit)]
+		if !validUnit {
+			return 0, fmt.Errorf("GEOSEARCH %v : Unit should be one of M, KM, FT or MI", key)
+		}
+		return conversion, nil
+	}
+
+	for i := 0; i < len(args); i++ {
+		var err error
+
+		switch option := strings.ToUpper(args[i]); option {
+		case "FROMMEMBER":
+			if i+1 >= len(args) {
+				return "", missing(option)
+			}
+
+			score, memberExists := item.SortedSet[args[i+1]]
+			if !memberExists {
+				return "", fmt.Errorf("GEOSEARCH %v : Member %v doesn't exist !!!", key, args[i+1])
+			}
+
+			centerLon, centerLat = utils.GeoDecode(uint64(score))
+			hasCenter = true
+			i++
+
+		case "FROMLONLAT":
+			if i+2 >= len(args) {
+				return "", missing(option)
+			}
+
+			lon, lonErr := strconv.ParseFloat(args[i+1], 64)
+			lat, latErr := strconv.ParseFloat(args[i+2], 64)
+
+			if lonErr != nil || latErr != nil {
+				return "", fmt.Errorf("GEOSEARCH %v : Longitude and latitude should be numbers", key)
+			}
+
+			if _, err = utils.GeoEncode(lon, lat); err != nil {
+				return "", fmt.Errorf("GEOSEARCH %v : %v", key, err)
+			}
+
+			centerLon, centerLat = lon, lat
+			hasCenter = true
+			i += 2
+
+		case "BYRADIUS":
+			if i+2 >= len(args) {
+				return "", missing(option)
+			}
+
+			if radius, err = strconv.ParseFloat(args[i+1], 64); err != nil || radius < 0 {
+				return "", fmt.Errorf("GEOSEARCH %v : Radius should be a non negative number", key)
+			}
+
+			if conversion, err = parseUnit(args[i+2]); err != nil {
+				return "", err
+			}
+
+			hasShape = true
+			i += 2
+
+		case "BYBOX":
+			if i+3 >= len(args) {
+				return "", missing(option)
+			}
+
+			width, err = strconv.ParseFloat(args[i+1], 64)
+			if err != nil || width < 0 {
+				return "", fmt.Errorf("GEOSEARCH %v : Width should be a non negative number", key)
+			}
+
+			height, err = strconv.ParseFloat(args[i+2], 64)
+			if err != nil || height < 0 {
+				return "", fmt.Errorf("GEOSEARCH %v : Height should be a non negative number", key)
+			}
+
+			if conversion, err = parseUnit(args[i+3]); err != nil {
+				return "", err
+			}
+
+			byBox, hasShape = true, true
+			i += 3
+
+		case "ASC", "DESC":
+			order = option
+
+		case "COUNT":
+			if i+1 >= len(args) {
+				return "", missing(option)
+			}
+
+			if count, err = strconv.Atoi(args[i+1]); err != nil || count < 1 {
+				return "", fmt.Errorf("GEOSEARCH %v : COUNT should be a positive integer", key)
+			}
+
+			i++
+
+		case "WITHCOORD":
+			withCoord = true
+		case "WITHDIST":
+			withDist = true
+		case "WITHHASH":
+			withHash = true
+
+		default:
+			return "", fmt.Errorf("GEOSEARCH %v : Unknown option %v", key, args[i])
+		}
+	}
+
+	if !hasCenter || !hasShape {
+		return "", fmt.Errorf("GEOSEARCH %v : Both FROMMEMBER|FROMLONLAT and BYRADIUS|BYBOX are required", key)
+	}
+
+	// Radius of the circle around the box when searching by box
+	searchRadius := radius * conversion
+	if byBox {
+		searchRadius = math.Sqrt(width*width+height*height) * conversion / 2
+	}
+
+	results := []geoResult{}
+
+	if item.Kind == KindSortedSet {
+		index := cache.scoreIndex(key)
+
+		for _, r := range utils.GeoSearchRanges(centerLon, centerLat, searchRadius) {
+			for _, node := range index.RangeByScore(int(r[0]), int(r[1])-1) {
+				lon, lat := utils.GeoDecode(uint64(node.OrderedValue))
+				dist := utils.GeoDistance(centerLon, centerLat, lon, lat)
+
+				if byBox {
+					// distances along the latitude and longitude of the center
+					lonDist := utils.GeoDistance(centerLon, centerLat, lon, centerLat)
+					latDist := utils.GeoDistance(centerLon, centerLat, centerLon, lat)
+
+					if lonDist > width*conversion/2 || latDist > height*conversion/2 {
+						continue
+					}
+				} else if dist > searchRadius {
+					continue
+				}
+
+				results = append(results, geoResult{node.Key, node.OrderedValue, dist})
+			}
+		}
+	}
+
+	if order != "" || count > 0 {
+		sort.Slice(results, func(i, j int) bool {
+			if order == "DESC" {
+				return results[i].dist > results[j].dist
+			}
+			return results[i].dist < results[j].dist
+		})
+	}
+
+	if count > 0 && count < len(results) {
+		results = results[:count]
+	}
+
+	lines := make([]string, 0, len(results))
+
+	for _, result := range results {
+		parts := []string{result.member}
+
+		if withDist {
+			parts = append(parts, fmt.Sprintf("%.4f", result.dist/conversion))
+		}
+
+		if withHash {
+			parts = append(parts, strconv.Itoa(result.score))
+		}
+
+		if withCoord {
+			parts = append(parts, formatGeoPosition(result.score))
+		}
+
+		lines = append(lines, strings.Join(parts, " : "))
+	}
+
+	return formatList(lines), nil
+}
+
+func formatGeoPosition(score int) string {
+	lon, lat := utils.GeoDecode(uint64(score))
+
+	return fmt.Sprintf("%.6f, %.6f", lon, lat)
+}
+
+/*
+***************************
+Sorted set helpers (cache.Mutex must be held)
+***************************
+*/
+
+// Adds or updates the member of the sorted set stored at key, which must already exist
+func (cache *Cache) addToSortedSet(key string, member string, score int) {
+	index := cache.scoreIndex(key)
+	item := cache.Data[key]
+
+	if oldScore, exists := item.SortedSet[member]; exists {
+		if oldScore == score {
+			return
+		}
+		index.Delete(member, oldScore)
+	}
+
+	item.SortedSet[member] = score
+	index.Insert(member, score)
+}
+
+// Skiplist ordered by score. It isn't saved in snapshots or rollback logs, so it is rebuilt from the member map when missing.
+func (cache *Cache) scoreIndex(key string) *utils.ScoreSkipList {
+	item := cache.Data[key]
+
+	if item.scoreIndex == nil {
+		item.scoreIndex = utils.CreateScoreSkipList(DefaultSkipListMaxHeight)
+
+		for member, score := range item.SortedSet {
+			item.scoreIndex.Insert(member, score)
+		}
+
+		cache.Data[key] = item
+	}
+
+	return item.scoreIndex
+}
@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"prac/utils"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func HashHandler(command string, args []string) (string, error) {
+	if len(args) == 0 {
+		return "", fmt.Errorf("%v : Missing Key", command)
+	}
+
+	cache := CurrentCache
+	key := args[0]
+
+	cache.Mutex.Lock()
+	defer cache.Mutex.Unlock()
+
+	item, exists := cache.Data[key]
+
+	if exists && item.Kind != KindHash {
+		return "", wrongTypeError(command, key)
+	}
+
+	switch command {
+	// HSET key field value [field value ...]
+	case "HSET":
+		if len(args) < 3 || len(args)%2 == 0 {
+			return "", fmt.Errorf("HSET %v : Fields and values should be given in pairs", key)
+		}
+
+		if !exists {
+			item = CacheItem{Kind: KindHash, Hash: make(map[string]string)}
+		}
+
+		added := 0
+
+		for i := 1; i < len(args); i += 2 {
+			if _, fieldExists := item.Hash[args[i]]; !fieldExists {
+				added++
+			}
+
+			item.Hash[args[i]] = args[i+1]
+		}
+
+		cache.Data[key] = item
+
+		NotifyKeyspaceEvent(NotifyHash, "hset", key, cache.Index)
+
+		return fmt.Sprintf(">> %v", added), nil
+
+	// HGET key field
+	case "HGET":
+		if len(args) < 2 {
+			return "", fmt.Errorf("HGET %v : Missing field", key)
+		}
+
+		val, fieldExists := item.Hash[args[1]]
+
+		if !fieldExists {
+			return nilOutput, nil
+		}
+
+		return formatValue(val), nil
+
+	// HMGET key field [field ...]
+	case "HMGET":
+		if len(args) < 2 {
+			return "", fmt.Errorf("HMGET %v : Missing field", key)
+		}
+
+		vals := make([]*string, 0, len(args)-1)
+
+		for _, field := range args[1:] {
+			if val, fieldExists := item.Hash[field]; fieldExists {
+				vals = append(vals, &val)
+			} else {
+				vals = append(vals, nil)
+			}
+		}
+
+		return formatNullableList(vals), nil
+
+	// HDEL key field [field ...]
+	case "HDEL":
+		if len(args) < 2 {
+			return "", fmt.Errorf("HDEL %v : Missing field", key)
+		}
+
+		removed := 0
+
+		for _, field := range args[1:] {
+			if _, fieldExists := item.Hash[field]; fieldExists {
+				delete(item.Hash, field)
+				removed++
+			}
+		}
+
+		if exists && len(item.Hash) == 0 {
+			cache.deleteItem(key, item)
+		}
+
+		if removed > 0 {
+			cache.notifyWrite(NotifyHash, "hdel", key)
+		}
+
+		return fmt.Sprintf(">> %v", removed), nil
+
+	// HEXISTS key field
+	case "HEXISTS":
+		if len(args) < 2 {
+			return "", fmt.Errorf("HEXISTS %v : Missing field", key)
+		}
+
+		_, fieldExists := item.Hash[args[1]]
+
+		return fmt.Sprintf(">> %v", fieldExists), nil
+
+	// HGETALL key
+	case "HGETALL":
+		fields := sortedFields(item.Hash)
+
+		for i, field := range fields {
+			fields[i] = field + " : " + item.Hash[field]
+		}
+
+		return formatList(fields), nil
+
+	// HKEYS key
+	case "HKEYS":
+		return formatList(sortedFields(item.Hash)), nil
+
+	// HVALS key
+	case "HVALS":
+		fields := sortedFields(item.Hash)
+
+		for i, field := range fields {
+			fields[i] = item.Hash[field]
+		}
+
+		return formatList(fields), nil
+
+	// HLEN key
+	case "HLEN":
+		return fmt.Sprintf(">> %v", len(item.Hash)), nil
+
+	// HINCRBY key field increment
+	case "HINCRBY":
+		if len(args) < 3 {
+			return "", fmt.Errorf("HINCRBY %v : Missing field and increment", key)
+		}
+
+		increment, err := strconv.ParseInt(args[2], 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("HINCRBY %v : Increment should be an integer", key)
+		}
+
+		var current int64
+
+		if val, fieldExists := item.Hash[args[1]]; fieldExists {
+			current, err = strconv.ParseInt(val, 10, 64)
+			if err != nil {
+				return "", fmt.Errorf("HINCRBY %v : Value of field %v is not an integer", key, args[1])
+			}
+		}
+
+		if (increment > 0 && current > math.MaxInt64-increment) || (increment < 0 && current < math.MinInt64-increment) {
+			return "", fmt.Errorf("HINCRBY %v : Increment would overflow", key)
+		}
+
+		if !exists {
+			item = CacheItem{Kind: KindHash, Hash: make(map[string]string)}
+		}
+
+		item.Hash[args[1]] = strconv.FormatInt(current+increment, 10)
+		cache.Data[key] = item
+
+		NotifyKeyspaceEvent(NotifyHash, "hincrby", key, cache.Index)
+
+		return fmt.Sprintf(">> %v", current+increment), nil
+
+	// HSCAN key cursor [MATCH pattern] [COUNT count]
+	// Output -> next cursor on the first line followed by the "field : value" pairs
+	case "HSCAN":
+		if len(args) < 2 {
+			return "", fmt.Errorf("HSCAN %v : Missing cursor", key)
+		}
+
+		cursor, err := strconv.ParseUint(args[1], 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("HSCAN %v : Invalid cursor", key)
+		}
+
+		pattern := "*"
+		count := 10
+
+		for i := 2; i < len(args); i += 2 {
+			if i+1 >= len(args) {
+				return "", fmt.Errorf("HSCAN %v : Missing value for %v", key, args[i])
+			}
+
+			switch strings.ToUpper(args[i]) {
+			case "MATCH":
+				pattern = args[i+1]
+			case "COUNT":
+				count, err = strconv.Atoi(args[i+1])
+				if err != nil || count < 1 {
+					return "", fmt.Errorf("HSCAN %v : COUNT should be a positive integer", key)
+				}
+			default:
+				return "", fmt.Errorf("HSCAN %v : Unknown option %v", key, args[i])
+			}
+		}
+
+		next, fields := utils.ScanByHash(item.Hash, cursor, count, func(field string) bool {
+			return utils.GlobMatch(pattern, field)
+		})
+
+		for i, field := range fields {
+			fields[i] = field + " : " + item.Hash[field]
+		}
+
+		return formatScan(next, fields), nil
+	}
+
+	return "", fmt.Errorf("Unknown command !!!")
+}
+
+func sortedFields(hash map[string]string) []string {
+	fields := make([]string, 0, len(hash))
+
+	for field := range hash {
+		fields = append(fields, field)
+	}
+
+	sort.Strings(fields)
+
+	return fields
+}
+
+// Next cursor on the first line, followed by the numbered elements
+func formatScan(cursor uint64, elements []string) string {
+	return fmt.Sprintf(">> %v\n%v", cursor, strings.TrimPrefix(formatList(elements), ">>\n"))
+}
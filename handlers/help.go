@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+)
+
+type CommandInfo struct {
+	Name   string
+	Syntax string
+	// Number of parts including the command name, negative -> at least that many
+	Arity       int
+	Group       string
+	Description string
+}
+
+// Every command the server understands, in the order HELP lists them
+var Commands = []CommandInfo{
+	// Strings
+	{"SET", "SET key value [ttl] [NX | XX] [GET] [EX seconds | PX milliseconds | EXAT unix-seconds | PXAT unix-milliseconds | KEEPTTL]", -3, "string", "Sets the value of a key, clearing its ttl unless KEEPTTL is given"},
+	{"GET", "GET key", 2, "string", "Value of a key"},
+	{"MSET", "MSET key value [key value ...]", -3, "string", "Sets multiple keys"},
+	{"MSETNX", "MSETNX key value [key value ...]", -3, "string", "Sets multiple keys only if none of them exist, 1 if set"},
+	{"MGET", "MGET key [key ...]", -2, "string", "Values of multiple keys, (nil) for missing keys"},
+	{"INCR", "INCR key", 2, "string", "Increments the integer value of a key by one"},
+	{"DECR", "DECR key", 2, "string", "Decrements the integer value of a key by one"},
+	{"INCRBY", "INCRBY key increment", 3, "string", "Increments the integer value of a key"},
+	{"DECRBY", "DECRBY key decrement", 3, "string", "Decrements the integer value of a key"},
+	{"INCRBYFLOAT", "INCRBYFLOAT key increment", 3, "string", "Increments the float value of a key"},
+	{"APPEND", "APPEND key value", 3, "string", "Appends to a string, length of the string after appending"},
+	{"STRLEN", "STRLEN key", 2, "string", "Length of a string"},
+	{"GETRANGE", "GETRANGE key start end", 4, "string", "Substring, both indexes inclusive and negative indexes count from the end"},
+	{"SETRANGE", "SETRANGE key offset value", 4, "string", "Overwrites a string from offset, padding with zero bytes if it's shorter"},
+	{"GETDEL", "GETDEL key", 2, "string", "Value of a key, deleting it"},
+
+	// Keys
+	{"DEL", "DEL key", 2, "keyspace", "Deletes a key"},
+	{"EXISTS", "EXISTS key [key ...]", -2, "keyspace", "Number of the given keys which exist"},
+	{"TYPE", "TYPE key", 2, "keyspace", "Kind of value stored at a key"},
+	{"KEYS", "KEYS pattern", 2, "keyspace", "Keys matching a glob pattern, blocks the cache while going over every key"},
+	{"SCAN", "SCAN cursor [MATCH pattern] [COUNT count] [TYPE type]", -2, "keyspace", "Iterates over the keys a few at a time"},
+	{"EXPIRE", "EXPIRE key seconds", 3, "keyspace", "Sets the ttl of a key"},
+	{"TTL", "TTL key", 2, "keyspace", "Seconds left for a key to expire, -1 if it doesn't expire"},
+	{"PERSIST", "PERSIST key", 2, "keyspace", "Removes the ttl of a key"},
+	{"RENAME", "RENAME key newkey", 3, "keyspace", "Renames a key, overwriting newkey"},
+	{"RENAMENX", "RENAMENX key newkey", 3, "keyspace", "Renames a key only if newkey doesn't exist"},
+	{"COPY", "COPY source destination [DB index] [REPLACE]", -3, "keyspace", "Copies a key, 1 if copied"},
+	{"MOVE", "MOVE key index", 3, "keyspace", "Moves a key to another cache"},
+
+	// Caches
+	{"NUM", "NUM index", 2, "cache", "Switches the current cache, shared by every connection"},
+	{"DBSIZE", "DBSIZE", 1, "cache", "Number of keys in the current cache"},
+	{"SWAPDB", "SWAPDB index1 index2", 3, "cache", "Swaps the data of two caches"},
+	{"FLUSHDB", "FLUSHDB [ASYNC | SYNC]", -1, "cache", "Deletes every key of the current cache, ASYNC and SYNC behave the same"},
+	{"FLUSHALL", "FLUSHALL [ASYNC | SYNC]", -1, "cache", "Deletes every key of every cache, ASYNC and SYNC behave the same"},
+	{"SAVE", "SAVE [cacheIndex] [time]", -1, "cache", "Saves a cache to disk, periodically if time is more than 60 seconds"},
+	{"RETAIN", "RETAIN [fileName]", -1, "cache", "Replaces the current cache with one saved on disk (default dump.gob)"},
+	{"HALT", "HALT cacheIndex", 2, "cache", "Stops the periodic snapshots of a cache"},
+
+	// Transactions
+	{"BEGIN", "BEGIN", 1, "transaction", "Starts queueing commands"},
+	{"COMMIT", "COMMIT", 1, "transaction", "Runs the queued commands, rolling all of them back if one fails"},
+	{"DISCARD", "DISCARD", 1, "transaction", "Drops the queued commands"},
+
+	// Lists
+	{"LPUSH", "LPUSH key value [value ...]", -3, "list", "Pushes values to the head of a list"},
+	{"RPUSH", "RPUSH key value [value ...]", -3, "list", "Pushes values to the tail of a list"},
+	{"LPOP", "LPOP key [count]", -2, "list", "Pops values from the head of a list"},
+	{"RPOP", "RPOP key [count]", -2, "list", "Pops values from the tail of a list"},
+	{"LLEN", "LLEN key", 2, "list", "Length of a list"},
+	{"LRANGE", "LRANGE key start stop", 4, "list", "Values between two indexes, both inclusive"},
+	{"LINDEX", "LINDEX key index", 3, "list", "Value at an index"},
+	{"LSET", "LSET key index value", 4, "list", "Sets the value at an index"},
+	{"LTRIM", "LTRIM key start stop", 4, "list", "Keeps only the values between two indexes"},
+	{"LREM", "LREM key count value", 4, "list", "Removes count occurrences of a value, from the tail if count is negative and all if it's 0"},
+	{"LMOVE", "LMOVE source destination LEFT|RIGHT LEFT|RIGHT", 5, "list", "Pops from one list and pushes to another"},
+	{"BLPOP", "BLPOP key [key ...] timeout", -3, "list", "LPOP which waits for a value, 0 waits forever"},
+	{"BRPOP", "BRPOP key [key ...] timeout", -3, "list", "RPOP which waits for a value, 0 waits forever"},
+	{"BLMOVE", "BLMOVE source destination LEFT|RIGHT LEFT|RIGHT timeout", 6, "list", "LMOVE which waits for a value, 0 waits forever"},
+
+	// Hashes
+	{"HSET", "HSET key field value [field value ...]", -4, "hash", "Sets fields of a hash"},
+	{"HGET", "HGET key field", 3, "hash", "Value of a field"},
+	{"HMGET", "HMGET key field [field ...]", -3, "hash", "Values of multiple fields"},
+	{"HDEL", "HDEL key field [field ...]", -3, "hash", "Deletes fields"},
+	{"HEXISTS", "HEXISTS key field", 3, "hash", "Whether a field exists"},
+	{"HGETALL", "HGETALL key", 2, "hash", "Every field and value"},
+	{"HKEYS", "HKEYS key", 2, "hash", "Every field"},
+	{"HVALS", "HVALS key", 2, "hash", "Every value"},
+	{"HLEN", "HLEN key", 2, "hash", "Number of fields"},
+	{"HINCRBY", "HINCRBY key field increment", 4, "hash", "Increments the integer value of a field"},
+	{"HSCAN", "HSCAN key cursor [MATCH pattern] [COUNT count]", -3, "hash", "Iterates over the fields a few at a time"},
+
+	// Sets
+	{"SADD", "SADD key member [member ...]", -3, "set", "Adds members to a set"},
+	{"SREM", "SREM key member [member ...]", -3, "set", "Removes members from a set"},
+	{"SISMEMBER", "SISMEMBER key member", 3, "set", "Whether a member is in the set"},
+	{"SMISMEMBER", "SMISMEMBER key member [member ...]", -3, "set", "Whether each member is in the set"},
+	{"SMEMBERS", "SMEMBERS key", 2, "set", "Every member"},
+	{"SCARD", "SCARD key", 2, "set", "Number of members"},
+	{"SPOP", "SPOP key [count]", -2, "set", "Removes and returns random members"},
+	{"SRANDMEMBER", "SRANDMEMBER key [count]", -2, "set", "Random members, a negative count (down to -1048576) can return the same member multiple times"},
+	{"SINTER", "SINTER key [key ...]", -2, "set", "Intersection of sets"},
+	{"SUNION", "SUNION key [key ...]", -2, "set", "Union of sets"},
+	{"SDIFF", "SDIFF key [key ...]", -2, "set", "Members of the first set which aren't in the others"},
+	{"SINTERSTORE", "SINTERSTORE destination key [key ...]", -3, "set", "Stores the intersection of sets"},
+	{"SUNIONSTORE", "SUNIONSTORE destination key [key ...]", -3, "set", "Stores the union of sets"},
+	{"SDIFFSTORE", "SDIFFSTORE destination key [key ...]", -3, "set", "Stores the difference of sets"},
+
+	// Geospatial
+	{"GEOADD", "GEOADD key [NX|XX] [CH] longitude latitude member [longitude latitude member ...]", -5, "geo", "Adds members with their coordinates"},
+	{"GEOPOS", "GEOPOS key member [member ...]", -3, "geo", "Coordinates of members"},
+	{"GEODIST", "GEODIST key member1 member2 [M|KM|FT|MI]", -4, "geo", "Distance between two members"},
+	{"GEOHASH", "GEOHASH key member [member ...]", -3, "geo", "Geohashes of members"},
+	{"GEOSEARCH", "GEOSEARCH key FROMMEMBER member | FROMLONLAT longitude latitude BYRADIUS radius M|KM|FT|MI | BYBOX width height M|KM|FT|MI [ASC|DESC] [COUNT count] [WITHCOORD] [WITHDIST] [WITHHASH]", -7, "geo", "Members within a radius or a box"},
+
+	// Probabilistic
+	{"BF_CREATE", "BF_CREATE name [error_rate] [capacity] [SCALABLE -> T/F] [expansion] [tightening_ratio]", -2, "bloom", "Creates a bloom filter"},
+	{"BF_ADD", "BF_ADD name key", 3, "bloom", "Adds a key to a bloom filter"},
+	{"BF_EXISTS", "BF_EXISTS name key", 3, "bloom", "Whether a key may be in a bloom filter"},
+	{"BF_MADD", "BF_MADD name key [key ...]", -3, "bloom", "Adds keys, true for the keys which weren't in the bloom filter before"},
+	{"BF_MEXISTS", "BF_MEXISTS name key [key ...]", -3, "bloom", "Whether each key may be in a bloom filter"},
+	{"BF_DROP", "BF_DROP name", 2, "bloom", "Deletes a bloom filter"},
+	{"BF_LIST", "BF_LIST [pattern]", -1, "bloom", "Names of the bloom filters in the current cache matching a glob pattern"},
+	{"BF_INFO", "BF_INFO name", 2, "bloom", "Size, capacity and error rate of a bloom filter"},
+	{"BF_SCANDUMP", "BF_SCANDUMP name iterator", 3, "bloom", "Next iterator and chunk (base64) of a bloom filter, iterator 0 is returned at the end"},
+	{"BF_LOADCHUNK", "BF_LOADCHUNK name iterator data", 4, "bloom", "Restores a chunk returned by BF_SCANDUMP"},
+	{"BF_MERGE", "BF_MERGE destination source [source ...]", -3, "bloom", "ORs plain bloom filters of the same size, destination is created if missing"},
+	{"CF_CREATE", "CF_CREATE name [capacity] [bucket_size] [fingerprint_bits]", -2, "cuckoo", "Creates a cuckoo filter"},
+	{"CF_ADD", "CF_ADD name item", 3, "cuckoo", "Adds an item, the same item can be added multiple times"},
+	{"CF_ADDNX", "CF_ADDNX name item", 3, "cuckoo", "Adds an item only if it doesn't exist"},
+	{"CF_DEL", "CF_DEL name item", 3, "cuckoo", "Deletes one occurrence of an item"},
+	{"CF_EXISTS", "CF_EXISTS name item", 3, "cuckoo", "Whether an item may be in the filter"},
+	{"CF_COUNT", "CF_COUNT name item", 3, "cuckoo", "Estimated number of times an item was added"},
+	{"PFADD", "PFADD key [element ...]", -2, "hyperloglog", "Adds elements, 1 if the estimated cardinality changed"},
+	{"PFCOUNT", "PFCOUNT key [key ...]", -2, "hyperloglog", "Estimated cardinality of the union"},
+	{"PFMERGE", "PFMERGE destkey [sourcekey ...]", -2, "hyperloglog", "Merges HyperLogLogs into destkey"},
+	{"CMS_INITBYDIM", "CMS_INITBYDIM name width depth", 4, "sketch", "Creates a count-min sketch of the given size"},
+	{"CMS_INITBYPROB", "CMS_INITBYPROB name error_rate probability", 4, "sketch", "Creates a count-min sketch for the given error"},
+	{"CMS_INCRBY", "CMS_INCRBY name item increment [item increment ...]", -4, "sketch", "Increments counts, estimated counts after incrementing"},
+	{"CMS_QUERY", "CMS_QUERY name item [item ...]", -3, "sketch", "Estimated counts of items"},
+	{"CMS_MERGE", "CMS_MERGE destination numkeys source [source ...] [WEIGHTS weight [weight ...]]", -4, "sketch", "Overwrites destination with the weighted sum of count-min sketches"},
+	{"TOPK_RESERVE", "TOPK_RESERVE name k [width depth decay]", -3, "sketch", "Creates a top-k list"},
+	{"TOPK_ADD", "TOPK_ADD name item [item ...]", -3, "sketch", "Adds items, items expelled from the top k list"},
+	{"TOPK_QUERY", "TOPK_QUERY name item [item ...]", -3, "sketch", "Whether each item is in the top k list"},
+	{"TOPK_LIST", "TOPK_LIST name [WITHCOUNT]", -2, "sketch", "Items in the top k list"},
+
+	// Pub/Sub
+	{"SUBSCRIBE", "SUBSCRIBE channel [channel ...]", -2, "pubsub", "Listens for messages on channels"},
+	{"UNSUBSCRIBE", "UNSUBSCRIBE [channel ...]", -1, "pubsub", "Stops listening on channels, every channel if none given"},
+	{"PSUBSCRIBE", "PSUBSCRIBE pattern [pattern ...]", -2, "pubsub", "Listens for messages on channels matching glob patterns"},
+	{"PUNSUBSCRIBE", "PUNSUBSCRIBE [pattern ...]", -1, "pubsub", "Stops listening on patterns, every pattern if none given"},
+	{"PUBLISH", "PUBLISH channel message", 3, "pubsub", "Sends a message, number of receivers"},
+	{"PUBSUB", "PUBSUB CHANNELS [pattern] | PUBSUB NUMSUB [channel ...] | PUBSUB NUMPAT", -2, "pubsub", "Active channels, subscribers per channel or number of patterns"},
+	{"NOTIFY", "NOTIFY [flags]", -1, "pubsub", "Shows or sets the keyspace notification flags (K, E, g, $, l, s, h, z, x, d, A), NOTIFY NONE disables them"},
+
+	// Scripting
+	{"EVAL", "EVAL script numkeys [key ...] [arg ...]", -3, "scripting", "Runs a Lua script atomically"},
+	{"EVALSHA", "EVALSHA sha1 numkeys [key ...] [arg ...]", -3, "scripting", "Runs a script loaded by SCRIPT LOAD"},
+	{"SCRIPT", "SCRIPT LOAD script | SCRIPT EXISTS sha1 [sha1 ...] | SCRIPT FLUSH | SCRIPT KILL", -2, "scripting", "Manages the script cache"},
+
+	// Server
+	{"PING", "PING", 1, "server", "PONG"},
+	{"HELP", "HELP [command]", -1, "server", "Every command, or the syntax of one"},
+	{"EXIT", "EXIT", 1, "server", "Closes the connection"},
+}
+
+var commandsByName = func() map[string]*CommandInfo {
+	byName := make(map[string]*CommandInfo, len(Commands))
+
+	for i := range Commands {
+		byName[Commands[i].Name] = &Commands[i]
+	}
+
+	return byName
+}()
+
+func LookupCommand(name string) (CommandInfo, bool) {
+	info, exists := commandsByName[strings.ToUpper(name)]
+	if !exists {
+		return CommandInfo{}, false
+	}
+
+	return *info, true
+}
+
+// HELP -> NAME - description of every command | HELP command -> syntax, arity, group and description
+func HelpHandler(args []string) (string, error) {
+	if len(args) == 0 {
+		items := make([]string, len(Commands))
+
+		for i, info := range Commands {
+			items[i] = fmt.Sprintf("%v - %v", info.Name, info.Description)
+		}
+
+		return formatList(items), nil
+	}
+
+	info, exists := LookupCommand(args[0])
+	if !exists {
+		return "", fmt.Errorf("HELP %v : Unknown command !!!", args[0])
+	}
+
+	return fmt.Sprintf(">> Syntax : %v\nArity : %v\nGroup : %v\nDescription : %v", info.Syntax, describeArity(info.Arity), info.Group, info.Description), nil
+}
+
+func describeArity(arity int) string {
+	if arity < 0 {
+		return fmt.Sprintf("%v (at least %v parts including the command)", arity, -arity)
+	}
+
+	return fmt.Sprintf("%v (exactly %v parts including the command)", arity, arity)
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"fmt"
+	"prac/utils"
+)
+
+func HyperLogLogHandler(command string, args []string) (string, error) {
+	if len(args) == 0 {
+		return "", fmt.Errorf("%v : Missing Key", command)
+	}
+
+	cache := CurrentCache
+
+	cache.Mutex.Lock()
+	defer cache.Mutex.Unlock()
+
+	for _, key := range args {
+		if item, exists := cache.Data[key]; exists && item.Kind != KindHyperLogLog {
+			return "", wrongTypeError(command, key)
+		}
+
+		// PFADD has elements after the key
+		if command == "PFADD" {
+			break
+		}
+	}
+
+	switch command {
+	// PFADD key [element ...] -> 1 if the estimated cardinality changed (or key was created), 0 otherwise
+	case "PFADD":
+		item, exists := cache.Data[args[0]]
+		changed := !exists
+
+		if !exists {
+			item = CacheItem{Kind: KindHyperLogLog, HLL: utils.CreateHyperLogLog()}
+			cache.Data[args[0]] = item
+		}
+
+		for _, element := range args[1:] {
+			if item.HLL.Add(element) {
+				changed = true
+			}
+		}
+
+		if changed {
+			NotifyKeyspaceEvent(NotifyString, "pfadd", args[0], cache.Index)
+			return ">> 1", nil
+		}
+
+		return ">> 0", nil
+
+	// PFCOUNT key [key ...] -> estimated cardinality of the union
+	case "PFCOUNT":
+		if len(args) == 1 {
+			item, exists := cache.Data[args[0]]
+
+			if !exists {
+				return ">> 0", nil
+			}
+
+			return fmt.Sprintf(">> %v", item.HLL.Count()), nil
+		}
+
+		union := utils.CreateHyperLogLog()
+
+		for _, key := range args {
+			if item, exists := cache.Data[key]; exists {
+				union.Merge(item.HLL)
+			}
+		}
+
+		return fmt.Sprintf(">> %v", union.Count()), nil
+
+	// PFMERGE destkey [sourcekey ...]
+	case "PFMERGE":
+		dest, exists := cache.Data[args[0]]
+
+		if !exists {
+			dest = CacheItem{Kind: KindHyperLogLog, HLL: utils.CreateHyperLogLog()}
+		}
+
+		for _, key := range args[1:] {
+			if item, exists := cache.Data[key]; exists {
+				dest.HLL.Merge(item.HLL)
+			}
+		}
+
+		cache.Data[args[0]] = dest
+
+		NotifyKeyspaceEvent(NotifyString, "pfmerge", args[0], cache.Index)
+
+		return ">> SUCCESS", nil
+	}
+
+	return "", fmt.Errorf("Unknown command !!!")
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"fmt"
+	"prac/utils"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var kindNames = map[ValueKind]string{
+	KindString:      "string",
+	KindList:        "list",
+	KindHash:        "hash",
+	KindSet:         "set",
+	KindSortedSet:   "zset",
+	KindHyperLogLog: "hyperloglog",
+	KindBloomFilter: "bloomfilter",
+}
+
+func (kind ValueKind) String() string {
+	return kindNames[kind]
+}
+
+func KeyspaceHandler(command string, args []string) (string, error) {
+	if len(args) == 0 {
+		return "", fmt.Errorf("%v : Missing Key", command)
+	}
+
+	cache := CurrentCache
+
+	cache.Mutex.Lock()
+	defer cache.Mutex.Unlock()
+
+	switch command {
+	// EXISTS key [key ...] -> number of keys which exist, a key given multiple times is counted every time
+	case "EXISTS":
+		count := 0
+
+		for _, key := range args {
+			if _, exists := cache.Data[key]; exists {
+				count++
+			}
+		}
+
+		return fmt.Sprintf(">> %v", count), nil
+
+	// TYPE key
+	case "TYPE":
+		item, exists := cache.Data[args[0]]
+
+		if !exists {
+			return ">> none", nil
+		}
+
+		return ">> " + item.Kind.String(), nil
+
+	// KEYS pattern -> blocks the cache while going over every key, SCAN should be preferred for big caches
+	case "KEYS":
+		keys := []string{}
+
+		for key := range cache.Data {
+			if utils.GlobMatch(args[0], key) {
+				keys = append(keys, key)
+			}
+		}
+
+		sort.Strings(keys)
+
+		return formatList(keys), nil
+
+	// SCAN cursor [MATCH pattern] [COUNT count] [TYPE type]
+	// Output -> next cursor on the first line followed by the keys
+	case "SCAN":
+		cursor, err := strconv.ParseUint(args[0], 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("SCAN : Invalid cursor")
+		}
+
+		pattern := "*"
+		count := 10
+		kind := ""
+
+		for i := 1; i < len(args); i += 2 {
+			if i+1 >= len(args) {
+				return "", fmt.Errorf("SCAN : Missing value for %v", args[i])
+			}
+
+			switch strings.ToUpper(args[i]) {
+			case "MATCH":
+				pattern = args[i+1]
+			case "COUNT":
+				count, err = strconv.Atoi(args[i+1])
+				if err != nil || count < 1 {
+					return "", fmt.Errorf("SCAN : COUNT should be a positive integer")
+				}
+			case "TYPE":
+				kind = strings.ToLower(args[i+1])
+				if !isKindName(kind) {
+					return "", fmt.Errorf("SCAN : Unknown type %v", args[i+1])
+				}
+			default:
+				return "", fmt.Errorf("SCAN : Unknown option %v", args[i])
+			}
+		}
+
+		next, keys := utils.ScanByHash(cache.Data, cursor, count, func(key string) bool {
+			return (kind == "" || cache.Data[key].Kind.String() == kind) && utils.GlobMatch(pattern, key)
+		})
+
+		return formatScan(next, keys), nil
+	}
+
+	return "", fmt.Errorf("Unknown command !!!")
+}
+
+func isKindName(name string) bool {
+	for _, kindName := range kindNames {
+		if kindName == name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,615 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type listPopResult struct {
+	key string
+	val string
+	err error
+}
+
+type listWaiter struct {
+	keys    []string
+	popLeft bool
+
+	// BLMOVE
+	isMove      bool
+	destination string
+	pushLeft    bool
+
+	result chan listPopResult
+}
+
+var BlockingListCommands = map[string]bool{"BLPOP": true, "BRPOP": true, "BLMOVE": true}
+
+func wrongTypeError(command string, key string) error {
+	return fmt.Errorf("%v %v : Key holds the wrong kind of value !!!", command, key)
+}
+
+func ListHandler(command string, args []string) (string, error) {
+	if len(args) == 0 {
+		return "", fmt.Errorf("%v : Missing Key", command)
+	}
+
+	cache := CurrentCache
+	key := args[0]
+
+	cache.Mutex.Lock()
+	defer cache.Mutex.Unlock()
+
+	item, exists := cache.Data[key]
+
+	if exists && item.Kind != KindList {
+		return "", wrongTypeError(command, key)
+	}
+
+	switch command {
+	// LPUSH key value [value ...]
+	case "LPUSH", "RPUSH":
+		if len(args) == 1 {
+			return "", fmt.Errorf("%v %v : Missing Value", command, key)
+		}
+
+		cache.pushToList(key, command == "LPUSH", args[1:]...)
+
+		length := len(cache.Data[key].List)
+
+		NotifyKeyspaceEvent(NotifyList, listPushEvent(command == "LPUSH"), key, cache.Index)
+
+		cache.serveListWaiters(key)
+
+		return fmt.Sprintf(">> %v", length), nil
+
+	// LPOP key [count]
+	case "LPOP", "RPOP":
+		count := 1
+
+		if len(args) > 1 {
+			var err error
+			count, err = strconv.Atoi(args[1])
+
+			if err != nil || count < 0 {
+				return "", fmt.Errorf("%v %v : Count should be a non negative integer", command, key)
+			}
+		}
+
+		if !exists {
+			return nilOutput, nil
+		}
+
+		popped := []string{}
+
+		for i := 0; i < count && len(cache.Data[key].List) > 0; i++ {
+			popped = append(popped, cache.popFromList(key, command == "LPOP"))
+		}
+
+		if len(popped) > 0 {
+			cache.notifyWrite(NotifyList, listPopEvent(command == "LPOP"), key)
+		}
+
+		if len(args) == 1 {
+			return formatValue(popped[0]), nil
+		}
+
+		return formatList(popped), nil
+
+	// LLEN key
+	case "LLEN":
+		return fmt.Sprintf(">> %v", len(item.List)), nil
+
+	// LRANGE key start stop
+	case "LRANGE":
+		if len(args) < 3 {
+			return "", fmt.Errorf("LRANGE %v : Missing start and stop", key)
+		}
+
+		start, stop, err := parseListRange(args[1], args[2], len(item.List))
+		if err != nil {
+			return "", fmt.Errorf("LRANGE %v : %v", key, err)
+		}
+
+		if start > stop {
+			return formatList(nil), nil
+		}
+
+		return formatList(item.List[start : stop+1]), nil
+
+	// LINDEX key index
+	case "LINDEX":
+		if len(args) < 2 {
+			return "", fmt.Errorf("LINDEX %v : Missing index", key)
+		}
+
+		index, err := parseListIndex(args[1], len(item.List))
+		if err != nil {
+			return "", fmt.Errorf("LINDEX %v : %v", key, err)
+		}
+
+		if index < 0 || index >= len(item.List) {
+			return nilOutput, nil
+		}
+
+		return formatValue(item.List[index]), nil
+
+	// LSET key index value
+	case "LSET":
+		if len(args) < 3 {
+			return "", fmt.Errorf("LSET %v : Missing index and value", key)
+		}
+
+		if !exists {
+			return "", noKeyErrorf("LSET %v : Key doesn't exist !!!", key)
+		}
+
+		index, err := parseListIndex(args[1], len(item.List))
+		if err != nil {
+			return "", fmt.Errorf("LSET %v : %v", key, err)
+		}
+
+		if index < 0 || index >= len(item.List) {
+			return "", fmt.Errorf("LSET %v : Index out of range !!!", key)
+		}
+
+		item.List[index] = args[2]
+
+		NotifyKeyspaceEvent(NotifyList, "lset", key, cache.Index)
+
+		return ">> SUCCESS", nil
+
+	// LTRIM key start stop
+	case "LTRIM":
+		if len(args) < 3 {
+			return "", fmt.Errorf("LTRIM %v : Missing start and stop", key)
+		}
+
+		start, stop, err := parseListRange(args[1], args[2], len(item.List))
+		if err != nil {
+			return "", fmt.Errorf("LTRIM %v : %v", key, err)
+		}
+
+		if !exists {
+			return ">> SUCCESS", nil
+		}
+
+		if start > stop {
+			item.List = nil
+		} else {
+			item.List = append([]string{}, item.List[start:stop+1]...)
+		}
+
+		cache.setList(key, item)
+		cache.notifyWrite(NotifyList, "ltrim", key)
+
+		return ">> SUCCESS", nil
+
+	// LREM key count value -> count > 0 : from head, count < 0 : from tail, count = 0 : all
+	case "LREM":
+		if len(args) < 3 {
+			return "", fmt.Errorf("LREM %v : Missing count and value", key)
+		}
+
+		count, err := strconv.Atoi(args[1])
+		if err != nil {
+			return "", fmt.Errorf("LREM %v : Count should be an integer", key)
+		}
+
+		limit := count
+		if limit < 0 {
+			limit = -limit
+		}
+
+		removed := 0
+		skip := make(map[int]bool)
+
+		for i := range item.List {
+			index := i
+			if count < 0 {
+				index = len(item.List) - 1 - i
+			}
+
+			if item.List[index] == args[2] && (limit == 0 || removed < limit) {
+				skip[index] = true
+				removed++
+			}
+		}
+
+		remaining := make([]string, 0, len(item.List)-removed)
+
+		for i, val := range item.List {
+			if !skip[i] {
+				remaining = append(remaining, val)
+			}
+		}
+
+		if exists && removed > 0 {
+			item.List = remaining
+			cache.setList(key, item)
+			cache.notifyWrite(NotifyList, "lrem", key)
+		}
+
+		return fmt.Sprintf(">> %v", removed), nil
+
+	// LMOVE source destination LEFT|RIGHT LEFT|RIGHT
+	case "LMOVE":
+		if len(args) < 4 {
+			return "", fmt.Errorf("LMOVE : Missing destination and directions")
+		}
+
+		popLeft, pushLeft, err := parseMoveDirections(args[2], args[3])
+		if err != nil {
+			return "", err
+		}
+
+		if destItem, destExists := cache.Data[args[1]]; destExists && destItem.Kind != KindList {
+			return "", wrongTypeError(command, args[1])
+		}
+
+		if !exists {
+			return nilOutput, nil
+		}
+
+		val := cache.popFromList(key, popLeft)
+		cache.notifyWrite(NotifyList, listPopEvent(popLeft), key)
+
+		cache.pushToList(args[1], pushLeft, val)
+		NotifyKeyspaceEvent(NotifyList, listPushEvent(pushLeft), args[1], cache.Index)
+
+		cache.serveListWaiters(args[1])
+
+		return formatValue(val), nil
+	}
+
+	return "", fmt.Errorf("Unknown command !!!")
+}
+
+/*
+BLPOP key [key ...] timeout
+BRPOP key [key ...] timeout
+BLMOVE source destination LEFT|RIGHT LEFT|RIGHT timeout
+
+timeout is in seconds, 0 blocks forever. If block is false (inside transactions and scripts),
+the command doesn't wait and returns (nil) when all the lists are empty.
+The wait ends when disconnected is closed, so that elements aren't handed to a client which is gone.
+*/
+func BlockingListHandler(command string, args []string, block bool, disconnected <-chan struct{}) (string, error) {
+	waiter := &listWaiter{popLeft: command == "BLPOP", result: make(chan listPopResult, 1)}
+
+	var timeoutArg string
+
+	if command == "BLMOVE" {
+		if len(args) < 5 {
+			return "", fmt.Errorf("BLMOVE : Missing arguments. Usage : BLMOVE source destination LEFT|RIGHT LEFT|RIGHT timeout")
+		}
+
+		popLeft, pushLeft, err := parseMoveDirections(args[2], args[3])
+		if err != nil {
+			return "", err
+		}
+
+		waiter.keys = args[:1]
+		waiter.isMove = true
+		waiter.destination = args[1]
+		waiter.popLeft = popLeft
+		waiter.pushLeft = pushLeft
+		timeoutArg = args[4]
+	} else {
+		if len(args) < 2 {
+			return "", fmt.Errorf("%v : Missing Key and timeout", command)
+		}
+
+		waiter.keys = args[:len(args)-1]
+		timeoutArg = args[len(args)-1]
+	}
+
+	timeout, err := strconv.ParseFloat(timeoutArg, 64)
+	if err != nil || timeout < 0 {
+		return "", fmt.Errorf("%v : Timeout should be a non negative number of seconds", command)
+	}
+
+	cache := CurrentCache
+
+	// Scripts and commits already hold the transaction lock when block is false. The lock isn't held
+	// while waiting, the element is then handed over by a push which holds it.
+	unlock := cache.Mutex.Unlock
+
+	if block {
+		cache.TransactionMutex.RLock()
+		unlock = func() {
+			cache.Mutex.Unlock()
+			cache.TransactionMutex.RUnlock()
+		}
+	}
+
+	cache.Mutex.Lock()
+
+	for _, key := range waiter.keys {
+		if item, exists := cache.Data[key]; exists && item.Kind != KindList {
+			unlock()
+			return "", wrongTypeError(command, key)
+		}
+	}
+
+	// Serve immediately if any of the lists has data, otherwise wait in line
+	cache.addListWaiter(waiter)
+
+	for _, key := range waiter.keys {
+		cache.serveListWaiters(key)
+	}
+
+	unlock()
+
+	var result listPopResult
+
+	if !block {
+		select {
+		case result = <-waiter.result:
+		default:
+			cache.cancelListWaiter(waiter)
+			return nilOutput, nil
+		}
+	} else {
+		// nil channel -> never fires, for timeout 0
+		var expired <-chan time.Time
+
+		if timeout > 0 {
+			timer := time.NewTimer(time.Duration(timeout * float64(time.Second)))
+			defer timer.Stop()
+
+			expired = timer.C
+		}
+
+		select {
+		case result = <-waiter.result:
+		case <-expired:
+			if served := cache.cancelListWaiter(waiter); !served {
+				return nilOutput, nil
+			}
+			result = <-waiter.result
+		case <-disconnected:
+			if served := cache.cancelListWaiter(waiter); served {
+				cache.restorePopped(waiter, <-waiter.result)
+			}
+			return "", fmt.Errorf("%v : Client disconnected", command)
+		}
+	}
+
+	if result.err != nil {
+		return "", result.err
+	}
+
+	if command == "BLMOVE" {
+		return formatValue(result.val), nil
+	}
+
+	return formatList([]string{result.key, result.val}), nil
+}
+
+func parseMoveDirections(from string, to string) (bool, bool, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+
+	if (from != "LEFT" && from != "RIGHT") || (to != "LEFT" && to != "RIGHT") {
+		return false, false, fmt.Errorf("Direction should be either LEFT or RIGHT")
+	}
+
+	return from == "LEFT", to == "LEFT", nil
+}
+
+// Negative index counts from the tail (-1 is the last element)
+func parseListIndex(arg string, length int) (int, error) {
+	index, err := strconv.Atoi(arg)
+	if err != nil {
+		return 0, fmt.Errorf("Index should be an integer")
+	}
+
+	if index < 0 {
+		index += length
+	}
+
+	return index, nil
+}
+
+// Returns start and stop clamped to the list. start > stop means the range is empty.
+func parseListRange(startArg string, stopArg string, length int) (int, int, error) {
+	start, err := parseListIndex(startArg, length)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	stop, err := parseListIndex(stopArg, length)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if start < 0 {
+		start = 0
+	}
+
+	if stop >= length {
+		stop = length - 1
+	}
+
+	return start, stop, nil
+}
+
+/*
+***************************
+Helpers (cache.Mutex must be held)
+***************************
+*/
+
+// Pushes vals one after the other, so pushing left reverses them. A left push copies the list once
+// for all the new values instead of once per value.
+func (cache *Cache) pushToList(key string, left bool, vals ...string) {
+	item := cache.Data[key]
+	item.Kind = KindList
+
+	if left {
+		list := make([]string, len(vals), len(vals)+len(item.List))
+		for i, val := range vals {
+			list[len(vals)-1-i] = val
+		}
+		item.List = append(list, item.List...)
+	} else {
+		item.List = append(item.List, vals...)
+	}
+
+	cache.Data[key] = item
+}
+
+func (cache *Cache) popFromList(key string, left bool) string {
+	item := cache.Data[key]
+
+	var val string
+
+	if left {
+		val = item.List[0]
+		item.List = item.List[1:]
+	} else {
+		val = item.List[len(item.List)-1]
+		item.List = item.List[:len(item.List)-1]
+	}
+
+	cache.setList(key, item)
+
+	return val
+}
+
+// Stores the list, deleting the key when the list becomes empty
+func (cache *Cache) setList(key string, item CacheItem) {
+	if len(item.List) > 0 {
+		cache.Data[key] = item
+		return
+	}
+
+	cache.deleteItem(key, item)
+}
+
+func listPushEvent(left bool) string {
+	if left {
+		return "lpush"
+	}
+	return "rpush"
+}
+
+func listPopEvent(left bool) string {
+	if left {
+		return "lpop"
+	}
+	return "rpop"
+}
+
+func (cache *Cache) addListWaiter(waiter *listWaiter) {
+	if cache.blockedClients == nil {
+		cache.blockedClients = make(map[string][]*listWaiter)
+	}
+
+	for _, key := range waiter.keys {
+		cache.blockedClients[key] = append(cache.blockedClients[key], waiter)
+	}
+}
+
+func (cache *Cache) removeListWaiter(waiter *listWaiter) {
+	for _, key := range waiter.keys {
+		waiters := cache.blockedClients[key]
+
+		for i, w := range waiters {
+			if w == waiter {
+				waiters = append(waiters[:i:i], waiters[i+1:]...)
+				break
+			}
+		}
+
+		if len(waiters) == 0 {
+			delete(cache.blockedClients, key)
+		} else {
+			cache.blockedClients[key] = waiters
+		}
+	}
+}
+
+// Removes the waiter if it is still waiting. Returns true if it has already been served.
+func (cache *Cache) cancelListWaiter(waiter *listWaiter) bool {
+	cache.Mutex.Lock()
+	defer cache.Mutex.Unlock()
+
+	if len(waiter.result) > 0 {
+		return true
+	}
+
+	cache.removeListWaiter(waiter)
+
+	return false
+}
+
+// Puts back an element popped for a waiter whose client left before getting it. BLMOVE has already
+// pushed the element to its destination, so nothing is lost there.
+func (cache *Cache) restorePopped(waiter *listWaiter, result listPopResult) {
+	if waiter.isMove || result.err != nil {
+		return
+	}
+
+	cache.TransactionMutex.RLock()
+	defer cache.TransactionMutex.RUnlock()
+
+	cache.Mutex.Lock()
+	defer cache.Mutex.Unlock()
+
+	cache.pushToList(result.key, waiter.popLeft, result.val)
+	NotifyKeyspaceEvent(NotifyList, listPushEvent(waiter.popLeft), result.key, cache.Index)
+
+	cache.serveListWaiters(result.key)
+}
+
+// Hands elements of the list to the blocked connections in the order they started waiting
+func (cache *Cache) serveListWaiters(key string) {
+	for len(cache.blockedClients[key]) > 0 {
+		item, exists := cache.Data[key]
+
+		if !exists || item.Kind != KindList || len(item.List) == 0 {
+			return
+		}
+
+		waiter := cache.blockedClients[key][0]
+		cache.removeListWaiter(waiter)
+
+		if waiter.isMove {
+			if destItem, destExists := cache.Data[waiter.destination]; destExists && destItem.Kind != KindList {
+				waiter.result <- listPopResult{err: wrongTypeError("BLMOVE", waiter.destination)}
+				continue
+			}
+		}
+
+		val := cache.popFromList(key, waiter.popLeft)
+		cache.notifyWrite(NotifyList, listPopEvent(waiter.popLeft), key)
+
+		if waiter.isMove {
+			cache.pushToList(waiter.destination, waiter.pushLeft, val)
+			NotifyKeyspaceEvent(NotifyList, listPushEvent(waiter.pushLeft), waiter.destination, cache.Index)
+
+			cache.serveListWaiters(waiter.destination)
+		}
+
+		waiter.result <- listPopResult{key: key, val: val}
+	}
+}
+
+// Number of connections blocked on lists of the current cache
+func NumBlockedClients() int {
+	CurrentCache.Mutex.Lock()
+	defer CurrentCache.Mutex.Unlock()
+
+	waiters := make(map[*listWaiter]bool)
+
+	for _, keyWaiters := range CurrentCache.blockedClients {
+		for _, waiter := range keyWaiters {
+			waiters[waiter] = true
+		}
+	}
+
+	return len(waiters)
+}
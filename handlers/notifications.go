@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+/*
+Keyspace notification classes (same letters as redis' notify-keyspace-events).
+Keys emptied by a write (LPOP, HDEL, SREM, SPOP ...) are deleted and raise del after the event of the write.
+There is no evicted event, as keys are never evicted.
+*/
+const (
+	NotifyKeyspace  = 1 << iota // K -> published on __keyspace@<index>__:<key>
+	NotifyKeyevent              // E -> published on __keyevent@<index>__:<event>
+	NotifyGeneric               // g -> del, rename_from, rename_to, copy_to, move_from, move_to
+	NotifyString                // $ -> set, incrby, incrbyfloat, append, setrange, pfadd, pfmerge
+	NotifyList                  // l -> lpush, rpush, lpop, rpop, lset, ltrim, lrem
+	NotifySet                   // s -> sadd, srem, spop, sinterstore, sunionstore, sdiffstore
+	NotifyHash                  // h -> hset, hincrby, hdel
+	NotifySortedSet             // z -> geoadd
+	NotifyExpired               // x -> expired
+	NotifyModule                // d -> bf_create, bf_add, bf_loadchunk, bf_merge
+
+	NotifyAll = NotifyGeneric | NotifyString | NotifyList | NotifySet | NotifyHash | NotifySortedSet | NotifyExpired | NotifyModule // A
+)
+
+var notifyFlagChars = []struct {
+	char rune
+	flag int32
+}{
+	{'K', NotifyKeyspace}, {'E', NotifyKeyevent}, {'g', NotifyGeneric}, {'$', NotifyString}, {'l', NotifyList},
+	{'s', NotifySet}, {'h', NotifyHash}, {'z', NotifySortedSet}, {'x', NotifyExpired}, {'d', NotifyModule}, {'A', NotifyAll},
+}
+
+// Notifications are disabled by default
+var notifyFlags atomic.Int32
+
+// NOTIFY [flags] -> shows the current flags, or sets them. NOTIFY "" or NOTIFY NONE disables notifications.
+func NotifyConfigHandler(args []string) (string, error) {
+	if len(args) == 0 {
+		return ">> " + FormatNotifyFlags(notifyFlags.Load()), nil
+	}
+
+	flags, err := ParseNotifyFlags(args[0])
+	if err != nil {
+		return "", err
+	}
+
+	notifyFlags.Store(flags)
+
+	return ">> SUCCESS", nil
+}
+
+func ParseNotifyFlags(s string) (int32, error) {
+	var flags int32
+
+	if s == "" || strings.ToUpper(s) == "NONE" {
+		return 0, nil
+	}
+
+outer:
+	for _, c := range s {
+		for _, fc := range notifyFlagChars {
+			if fc.char == c {
+				flags |= fc.flag
+				continue outer
+			}
+		}
+
+		return 0, fmt.Errorf("NOTIFY : Unknown flag %q. Valid flags are K, E, g, $, l, s, h, z, x, d and A", c)
+	}
+
+	return flags, nil
+}
+
+func FormatNotifyFlags(flags int32) string {
+	var sb strings.Builder
+
+	for _, fc := range notifyFlagChars {
+		if fc.char == 'A' {
+			continue
+		}
+
+		if flags&fc.flag != 0 {
+			sb.WriteRune(fc.char)
+		}
+	}
+
+	if sb.Len() == 0 {
+		return "NONE"
+	}
+
+	return sb.String()
+}
+
+type pendingMessage struct {
+	channel string
+	message string
+}
+
+/*
+Events are raised while the cache's lock is held, so they are only queued here and published by another goroutine.
+Publishing in the order they were queued keeps the events of a key in order.
+*/
+var pendingEvents = struct {
+	mu       sync.Mutex
+	messages []pendingMessage
+	wake     chan struct{}
+}{wake: make(chan struct{}, 1)}
+
+func init() {
+	go publishPendingEvents()
+}
+
+// Queues the event for the key on keyspace/keyevent channels if its class is enabled
+func NotifyKeyspaceEvent(class int32, event string, key string, cacheIndex uint8) {
+	flags := notifyFlags.Load()
+
+	if flags&class == 0 {
+		return
+	}
+
+	pendingEvents.mu.Lock()
+
+	if flags&NotifyKeyspace != 0 {
+		pendingEvents.messages = append(pendingEvents.messages, pendingMessage{fmt.Sprintf("__keyspace@%v__:%v", cacheIndex, key), event})
+	}
+
+	if flags&NotifyKeyevent != 0 {
+		pendingEvents.messages = append(pendingEvents.messages, pendingMessage{fmt.Sprintf("__keyevent@%v__:%v", cacheIndex, event), key})
+	}
+
+	pendingEvents.mu.Unlock()
+
+	select {
+	case pendingEvents.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Raises the event for a write to the key, followed by del if the write emptied the key. cache.Mutex must be held.
+func (cache *Cache) notifyWrite(class int32, event string, key string) {
+	NotifyKeyspaceEvent(class, event, key, cache.Index)
+
+	if _, exists := cache.Data[key]; !exists {
+		NotifyKeyspaceEvent(NotifyGeneric, "del", key, cache.Index)
+	}
+}
+
+func publishPendingEvents() {
+	for range pendingEvents.wake {
+		pendingEvents.mu.Lock()
+		messages := pendingEvents.messages
+		pendingEvents.messages = nil
+		pendingEvents.mu.Unlock()
+
+		for _, msg := range messages {
+			PubSub.Publish(msg.channel, msg.message)
+		}
+	}
+}
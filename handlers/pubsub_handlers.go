@@ -0,0 +1,290 @@
+package handlers
+
+import (
+	"fmt"
+	"prac/utils"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type pubSubRegistry struct {
+	mu       sync.RWMutex
+	channels map[string]map[*Connection]bool
+	patterns map[string]map[*Connection]bool
+}
+
+var PubSub = pubSubRegistry{
+	channels: make(map[string]map[*Connection]bool),
+	patterns: make(map[string]map[*Connection]bool),
+}
+
+// Commands allowed while a connection is in subscriber mode
+var subscriberModeCommands = map[string]bool{
+	"SUBSCRIBE": true, "UNSUBSCRIBE": true, "PSUBSCRIBE": true, "PUNSUBSCRIBE": true, "PING": true,
+}
+
+func IsPubSubCommand(command string) bool {
+	return subscriberModeCommands[command] && command != "PING"
+}
+
+func (c *Connection) InSubscriberMode() bool {
+	return c.subscriptionCount() > 0
+}
+
+// Messages queued for a subscriber, it's dropped from the receivers of a message once its queue is full
+const subscriberQueueSize = 1024
+
+// Queues a message to be written on the connection outside of the request/response cycle.
+// Doesn't block, so a slow subscriber can't hold up the publisher.
+func (c *Connection) Push(command string, msg string) error {
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+
+	if c.outbox == nil {
+		return fmt.Errorf("Connection %v can't receive messages", c.Id)
+	}
+
+	select {
+	case c.outbox <- utils.SerializeOutput(command, msg):
+		return nil
+	default:
+		return fmt.Errorf("Connection %v is too slow, message dropped", c.Id)
+	}
+}
+
+// Writes the queued messages in order until the queue is closed. Once a write fails the rest are discarded.
+func (c *Connection) writeMessages(outbox <-chan string) {
+	failed := false
+
+	for msg := range outbox {
+		if failed {
+			continue
+		}
+
+		if _, err := c.Conn.Write([]byte(msg)); err != nil {
+			failed = true
+		}
+	}
+}
+
+// SUBSCRIBE channel [channel ...] | UNSUBSCRIBE [channel ...] | PSUBSCRIBE pattern [pattern ...] | PUNSUBSCRIBE [pattern ...]
+func PubSubHandler(command string, args []string, connectionObj *Connection) (string, error) {
+	switch command {
+	case "SUBSCRIBE", "PSUBSCRIBE":
+		if len(args) == 0 {
+			return "", fmt.Errorf("%v : Missing channel", command)
+		}
+
+		isPattern := command == "PSUBSCRIBE"
+
+		for _, name := range args {
+			PubSub.subscribe(connectionObj, name, isPattern)
+		}
+
+		return fmt.Sprintf(">> SUBSCRIBED TO %v (total subscriptions: %v)", strings.Join(args, ", "), connectionObj.subscriptionCount()), nil
+
+	case "UNSUBSCRIBE", "PUNSUBSCRIBE":
+		isPattern := command == "PUNSUBSCRIBE"
+
+		names := args
+		if len(names) == 0 {
+			names = connectionObj.subscribedTo(isPattern)
+		}
+
+		for _, name := range names {
+			PubSub.unsubscribe(connectionObj, name, isPattern)
+		}
+
+		return fmt.Sprintf(">> UNSUBSCRIBED FROM %v (total subscriptions: %v)", strings.Join(names, ", "), connectionObj.subscriptionCount()), nil
+	}
+
+	return "", fmt.Errorf("Unknown command !!!")
+}
+
+// PUBLISH channel message
+func PublishHandler(args []string) (int, error) {
+	if len(args) == 0 {
+		return 0, fmt.Errorf("PUBLISH : Missing channel and message")
+	}
+
+	if len(args) == 1 {
+		return 0, fmt.Errorf("PUBLISH %v : Missing message", args[0])
+	}
+
+	return PubSub.Publish(args[0], args[1]), nil
+}
+
+// PUBSUB CHANNELS [pattern] | PUBSUB NUMSUB [channel ...] | PUBSUB NUMPAT
+func PubSubInfoHandler(args []string) (string, error) {
+	if len(args) == 0 {
+		return "", fmt.Errorf("PUBSUB : Missing subcommand (CHANNELS, NUMSUB, NUMPAT)")
+	}
+
+	PubSub.mu.RLock()
+	defer PubSub.mu.RUnlock()
+
+	switch strings.ToUpper(args[0]) {
+	case "CHANNELS":
+		channels := []string{}
+
+		for channel := range PubSub.channels {
+			if len(args) == 1 || utils.GlobMatch(args[1], channel) {
+				channels = append(channels, channel)
+			}
+		}
+
+		sort.Strings(channels)
+
+		return formatList(channels), nil
+
+	case "NUMSUB":
+		result := make([]string, 0, len(args)-1)
+
+		for _, channel := range args[1:] {
+			result = append(result, fmt.Sprintf("%v : %v", channel, len(PubSub.channels[channel])))
+		}
+
+		return formatList(result), nil
+
+	case "NUMPAT":
+		return ">> " + strconv.Itoa(len(PubSub.patterns)), nil
+	}
+
+	return "", fmt.Errorf("PUBSUB %v : Unknown subcommand !!!", args[0])
+}
+
+// Sends message to subscribers of channel and of matching patterns. Returns number of receivers.
+func (ps *pubSubRegistry) Publish(channel string, message string) int {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	receivers := 0
+
+	// Scripts can publish newlines
+	escapedChannel, message := escapeValue(channel), escapeValue(message)
+
+	for conn := range ps.channels[channel] {
+		if conn.Push("MESSAGE", fmt.Sprintf(">> (%v) %v", escapedChannel, message)) == nil {
+			receivers++
+		}
+	}
+
+	for pattern, conns := range ps.patterns {
+		if !utils.GlobMatch(pattern, channel) {
+			continue
+		}
+
+		for conn := range conns {
+			if conn.Push("PMESSAGE", fmt.Sprintf(">> (%v | %v) %v", escapeValue(pattern), escapedChannel, message)) == nil {
+				receivers++
+			}
+		}
+	}
+
+	return receivers
+}
+
+// Removes all the subscriptions of a connection. Called when the client disconnects.
+func (ps *pubSubRegistry) UnsubscribeAll(c *Connection) {
+	for _, name := range c.subscribedTo(false) {
+		ps.unsubscribe(c, name, false)
+	}
+
+	for _, name := range c.subscribedTo(true) {
+		ps.unsubscribe(c, name, true)
+	}
+}
+
+func (ps *pubSubRegistry) subscribe(c *Connection, name string, isPattern bool) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	registry := ps.channels
+	if isPattern {
+		registry = ps.patterns
+	}
+
+	if registry[name] == nil {
+		registry[name] = make(map[*Connection]bool)
+	}
+
+	registry[name][c] = true
+
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+
+	if c.channels == nil {
+		c.channels = make(map[string]bool)
+		c.patterns = make(map[string]bool)
+	}
+
+	if c.outbox == nil && c.Conn != nil {
+		c.outbox = make(chan string, subscriberQueueSize)
+		go c.writeMessages(c.outbox)
+	}
+
+	if isPattern {
+		c.patterns[name] = true
+	} else {
+		c.channels[name] = true
+	}
+}
+
+func (ps *pubSubRegistry) unsubscribe(c *Connection, name string, isPattern bool) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	registry := ps.channels
+	if isPattern {
+		registry = ps.patterns
+	}
+
+	delete(registry[name], c)
+
+	if len(registry[name]) == 0 {
+		delete(registry, name)
+	}
+
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+
+	if isPattern {
+		delete(c.patterns, name)
+	} else {
+		delete(c.channels, name)
+	}
+
+	// The writer stops once it has written what's already queued
+	if len(c.channels)+len(c.patterns) == 0 && c.outbox != nil {
+		close(c.outbox)
+		c.outbox = nil
+	}
+}
+
+func (c *Connection) subscribedTo(isPattern bool) []string {
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+
+	subscriptions := c.channels
+	if isPattern {
+		subscriptions = c.patterns
+	}
+
+	names := make([]string, 0, len(subscriptions))
+	for name := range subscriptions {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+func (c *Connection) subscriptionCount() int {
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+
+	return len(c.channels) + len(c.patterns)
+}
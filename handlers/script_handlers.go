@@ -0,0 +1,339 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+var errScriptKilled = errors.New("Script killed by user with SCRIPT KILL !!!")
+var errScriptTimeout = errors.New("Script exceeded the execution time limit !!!")
+
+// Commands which can't be dispatched from inside a script with call(..)
+var scriptDeniedCommands = map[string]bool{
+	"EVAL": true, "EVALSHA": true, "SCRIPT": true,
+	"NUM": true, "SAVE": true, "RETAIN": true, "HALT": true,
+}
+
+var scriptCache = struct {
+	sync.Mutex
+	scripts map[string]string
+}{scripts: make(map[string]string)}
+
+var runningScripts = struct {
+	sync.Mutex
+	cancels map[*lua.LState]context.CancelCauseFunc
+}{cancels: make(map[*lua.LState]context.CancelCauseFunc)}
+
+// EVAL script numkeys [key ...] [arg ...]
+func EvalHandler(args []string) (string, error) {
+	if len(args) == 0 {
+		return "", fmt.Errorf("EVAL : Missing script and numkeys")
+	}
+
+	sha := cacheScript(args[0])
+
+	return runScript(sha, args[0], args[1:])
+}
+
+// EVALSHA sha1 numkeys [key ...] [arg ...]
+func EvalShaHandler(args []string) (string, error) {
+	if len(args) == 0 {
+		return "", fmt.Errorf("EVALSHA : Missing sha1 and numkeys")
+	}
+
+	sha := strings.ToLower(args[0])
+
+	scriptCache.Lock()
+	script, exists := scriptCache.scripts[sha]
+	scriptCache.Unlock()
+
+	if !exists {
+		return "", fmt.Errorf("EVALSHA %v : No matching script. Use EVAL or SCRIPT LOAD first !!!", args[0])
+	}
+
+	return runScript(sha, script, args[1:])
+}
+
+// SCRIPT LOAD script | SCRIPT EXISTS sha1 [sha1 ...] | SCRIPT FLUSH | SCRIPT KILL
+func ScriptHandler(args []string) (string, error) {
+	if len(args) == 0 {
+		return "", fmt.Errorf("SCRIPT : Missing subcommand (LOAD, EXISTS, FLUSH, KILL)")
+	}
+
+	switch strings.ToUpper(args[0]) {
+	case "LOAD":
+		if len(args) == 1 {
+			return "", fmt.Errorf("SCRIPT LOAD : Missing script")
+		}
+
+		script := args[1]
+
+		L := lua.NewState(lua.Options{SkipOpenLibs: true})
+		defer L.Close()
+
+		if _, err := L.LoadString(script); err != nil {
+			return "", fmt.Errorf("SCRIPT LOAD : %v", err)
+		}
+
+		return ">> " + cacheScript(script), nil
+
+	case "EXISTS":
+		if len(args) == 1 {
+			return "", fmt.Errorf("SCRIPT EXISTS : Missing sha1")
+		}
+
+		scriptCache.Lock()
+		defer scriptCache.Unlock()
+
+		result := make([]string, 0, len(args)-1)
+
+		for _, sha := range args[1:] {
+			if _, exists := scriptCache.scripts[strings.ToLower(sha)]; exists {
+				result = append(result, "1")
+			} else {
+				result = append(result, "0")
+			}
+		}
+
+		return formatList(result), nil
+
+	case "FLUSH":
+		scriptCache.Lock()
+		scriptCache.scripts = make(map[string]string)
+		scriptCache.Unlock()
+
+		return ">> SUCCESS", nil
+
+	case "KILL":
+		runningScripts.Lock()
+		defer runningScripts.Unlock()
+
+		if len(runningScripts.cancels) == 0 {
+			return "", fmt.Errorf("SCRIPT KILL : No scripts in execution right now !!!")
+		}
+
+		for _, cancel := range runningScripts.cancels {
+			cancel(errScriptKilled)
+		}
+
+		return ">> SUCCESS", nil
+	}
+
+	return "", fmt.Errorf("SCRIPT %v : Unknown subcommand !!!", args[0])
+}
+
+func cacheScript(script string) string {
+	hash := sha1.Sum([]byte(script))
+	sha := hex.EncodeToString(hash[:])
+
+	scriptCache.Lock()
+	scriptCache.scripts[sha] = script
+	scriptCache.Unlock()
+
+	return sha
+}
+
+// args -> numkeys [key ...] [arg ...]
+// NOTE : caller must hold CurrentCache.TransactionMutex so that the script runs atomically
+func runScript(sha string, script string, args []string) (string, error) {
+	if len(args) == 0 {
+		return "", fmt.Errorf("EVAL : Missing numkeys")
+	}
+
+	numKeys, err := strconv.Atoi(args[0])
+
+	if err != nil || numKeys < 0 {
+		return "", fmt.Errorf("EVAL : numkeys should be a non negative integer")
+	}
+
+	if numKeys > len(args)-1 {
+		return "", fmt.Errorf("EVAL : numkeys can't be greater than number of arguments")
+	}
+
+	L := newScriptState(args[1:numKeys+1], args[numKeys+1:])
+	defer L.Close()
+
+	fn, err := L.LoadString(script)
+
+	if err != nil {
+		return "", fmt.Errorf("EVAL : %v", err)
+	}
+
+	ctx, cancelTimeout := context.WithTimeoutCause(context.Background(), ScriptTimeLimit, errScriptTimeout)
+	defer cancelTimeout()
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	L.SetContext(ctx)
+
+	runningScripts.Lock()
+	runningScripts.cancels[L] = cancel
+	runningScripts.Unlock()
+
+	defer func() {
+		runningScripts.Lock()
+		delete(runningScripts.cancels, L)
+		runningScripts.Unlock()
+	}()
+
+	L.Push(fn)
+	err = L.PCall(0, 1, nil)
+
+	if ctx.Err() != nil {
+		return "", fmt.Errorf("EVAL %v : %v", sha, context.Cause(ctx))
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("EVAL %v : %v", sha, err)
+	}
+
+	return luaValueToOutput(L.Get(-1)), nil
+}
+
+func newScriptState(keys []string, argv []string) *lua.LState {
+	L := lua.NewState(lua.Options{SkipOpenLibs: true})
+
+	for _, lib := range []struct {
+		name string
+		fn   lua.LGFunction
+	}{
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.StringLibName, lua.OpenString},
+		{lua.MathLibName, lua.OpenMath},
+	} {
+		L.Push(L.NewFunction(lib.fn))
+		L.Push(lua.LString(lib.name))
+		L.Call(1, 0)
+	}
+
+	// No access to the file system from scripts
+	for _, name := range []string{"dofile", "loadfile", "load", "loadstring", "require", "module"} {
+		L.SetGlobal(name, lua.LNil)
+	}
+
+	keysTable := L.NewTable()
+	for _, key := range keys {
+		keysTable.Append(lua.LString(key))
+	}
+
+	argvTable := L.NewTable()
+	for _, arg := range argv {
+		argvTable.Append(lua.LString(arg))
+	}
+
+	L.SetGlobal("KEYS", keysTable)
+	L.SetGlobal("ARGV", argvTable)
+	L.SetGlobal("call", L.NewFunction(scriptCall))
+
+	return L
+}
+
+// call(command, args...) -> output of the command without the ">> " prefix, lists as tables (false for (nil) items)
+// and nil for (nil). Errors are raised as lua errors.
+func scriptCall(L *lua.LState) int {
+	command := strings.ToUpper(L.CheckString(1))
+
+	if scriptDeniedCommands[command] {
+		L.RaiseError("%v can't be called from a script !!!", command)
+		return 0
+	}
+
+	args := make([]string, 0, L.GetTop()-1)
+
+	for i := 2; i <= L.GetTop(); i++ {
+		args = append(args, L.Get(i).String())
+	}
+
+	successMsg, err := CommandHandler(command, args)
+
+	if err != nil {
+		L.RaiseError("%v", err.Error())
+		return 0
+	}
+
+	L.Push(outputToLuaValue(L, successMsg))
+	return 1
+}
+
+func outputToLuaValue(L *lua.LState, output string) lua.LValue {
+	if output == nilOutput {
+		return lua.LNil
+	}
+
+	items, isList := parseListOutput(output)
+	if !isList {
+		value, isValue := strings.CutPrefix(output, ">> ")
+		if isValue && !strings.Contains(value, "\n") {
+			value = unescapeValue(value)
+		}
+
+		return lua.LString(value)
+	}
+
+	table := L.NewTable()
+
+	for _, item := range items {
+		if item == nil {
+			table.Append(lua.LFalse)
+		} else {
+			table.Append(lua.LString(*item))
+		}
+	}
+
+	return table
+}
+
+// Output for the value returned by a script -> tables as lists and nil as (nil)
+func luaValueToOutput(v lua.LValue) string {
+	if v == lua.LNil {
+		return nilOutput
+	}
+
+	if table, isTable := v.(*lua.LTable); isTable {
+		return formatNullableList(luaTableItems(table))
+	}
+
+	return formatValue(luaValueToString(v))
+}
+
+// nil for the nil items
+func luaTableItems(table *lua.LTable) []*string {
+	items := []*string{}
+
+	for i := 1; i <= table.MaxN(); i++ {
+		if item := table.RawGetInt(i); item == lua.LNil {
+			items = append(items, nil)
+		} else {
+			val := luaValueToString(item)
+			items = append(items, &val)
+		}
+	}
+
+	return items
+}
+
+func luaValueToString(v lua.LValue) string {
+	switch val := v.(type) {
+	case *lua.LTable:
+		// nested lists are kept as one item
+		return strings.TrimPrefix(formatNullableList(luaTableItems(val)), ">>\n")
+
+	case lua.LBool:
+		if val {
+			return "true"
+		}
+		return "false"
+	}
+
+	return v.String()
+}
@@ -0,0 +1,359 @@
+package handlers
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Most members SRANDMEMBER returns for a negative count, as repeats let the reply grow without bound
+const maxRandomMembers = 1024 * 1024
+
+func SetTypeHandler(command string, args []string) (string, error) {
+	if len(args) == 0 {
+		return "", fmt.Errorf("%v : Missing Key", command)
+	}
+
+	switch command {
+	case "SINTER", "SUNION", "SDIFF":
+		return setAlgebraHandler(command, "", args)
+
+	// SINTERSTORE destination key [key ...]
+	case "SINTERSTORE", "SUNIONSTORE", "SDIFFSTORE":
+		if len(args) < 2 {
+			return "", fmt.Errorf("%v %v : Missing Key", command, args[0])
+		}
+
+		return setAlgebraHandler(strings.TrimSuffix(command, "STORE"), args[0], args[1:])
+	}
+
+	cache := CurrentCache
+	key := args[0]
+
+	cache.Mutex.Lock()
+	defer cache.Mutex.Unlock()
+
+	item, exists := cache.Data[key]
+
+	if exists && item.Kind != KindSet {
+		return "", wrongTypeError(command, key)
+	}
+
+	switch command {
+	// SADD key member [member ...]
+	case "SADD":
+		if len(args) == 1 {
+			return "", fmt.Errorf("SADD %v : Missing member", key)
+		}
+
+		if !exists {
+			item = CacheItem{Kind: KindSet, Set: make(map[string]bool)}
+		}
+
+		added := 0
+
+		for _, member := range args[1:] {
+			if !item.Set[member] {
+				item.Set[member] = true
+				added++
+			}
+		}
+
+		cache.Data[key] = item
+
+		if added > 0 {
+			NotifyKeyspaceEvent(NotifySet, "sadd", key, cache.Index)
+		}
+
+		return fmt.Sprintf(">> %v", added), nil
+
+	// SREM key member [member ...]
+	case "SREM":
+		if len(args) == 1 {
+			return "", fmt.Errorf("SREM %v : Missing member", key)
+		}
+
+		removed := 0
+
+		for _, member := range args[1:] {
+			if item.Set[member] {
+				delete(item.Set, member)
+				removed++
+			}
+		}
+
+		if exists && len(item.Set) == 0 {
+			cache.deleteItem(key, item)
+		}
+
+		if removed > 0 {
+			cache.notifyWrite(NotifySet, "srem", key)
+		}
+
+		return fmt.Sprintf(">> %v", removed), nil
+
+	// SISMEMBER key member
+	case "SISMEMBER":
+		if len(args) == 1 {
+			return "", fmt.Errorf("SISMEMBER %v : Missing member", key)
+		}
+
+		return fmt.Sprintf(">> %v", item.Set[args[1]]), nil
+
+	// SMISMEMBER key member [member ...]
+	case "SMISMEMBER":
+		if len(args) == 1 {
+			return "", fmt.Errorf("SMISMEMBER %v : Missing member", key)
+		}
+
+		result := make([]string, 0, len(args)-1)
+
+		for _, member := range args[1:] {
+			result = append(result, strconv.FormatBool(item.Set[member]))
+		}
+
+		return formatList(result), nil
+
+	// SMEMBERS key
+	case "SMEMBERS":
+		return formatList(sortedMembers(item.Set)), nil
+
+	// SCARD key
+	case "SCARD":
+		return fmt.Sprintf(">> %v", len(item.Set)), nil
+
+	// SPOP key [count]
+	case "SPOP":
+		count, err := parseSetCount(command, args)
+		if err != nil {
+			return "", err
+		}
+
+		if count < 0 {
+			return "", fmt.Errorf("SPOP %v : Count should be a non negative integer", key)
+		}
+
+		popped := sampleMembers(item.Set, count)
+
+		for _, member := range popped {
+			delete(item.Set, member)
+		}
+
+		if exists && len(item.Set) == 0 {
+			cache.deleteItem(key, item)
+		}
+
+		if len(popped) > 0 {
+			cache.notifyWrite(NotifySet, "spop", key)
+		}
+
+		if len(args) == 1 {
+			if len(popped) == 0 {
+				return nilOutput, nil
+			}
+			return formatValue(popped[0]), nil
+		}
+
+		return formatList(popped), nil
+
+	// SRANDMEMBER key [count] -> negative count can return the same member multiple times
+	case "SRANDMEMBER":
+		count, err := parseSetCount(command, args)
+		if err != nil {
+			return "", err
+		}
+
+		if count < -maxRandomMembers {
+			return "", fmt.Errorf("%v %v : Negative count can't be less than -%v", command, args[0], maxRandomMembers)
+		}
+
+		var result []string
+
+		if count < 0 {
+			result = sampleMembersWithRepeats(item.Set, -count)
+		} else {
+			result = sampleMembers(item.Set, count)
+		}
+
+		if len(args) == 1 {
+			if len(result) == 0 {
+				return nilOutput, nil
+			}
+			return formatValue(result[0]), nil
+		}
+
+		return formatList(result), nil
+	}
+
+	return "", fmt.Errorf("Unknown command !!!")
+}
+
+// SINTER/SUNION/SDIFF key [key ...]. Stores the result in destination if it isn't empty.
+func setAlgebraHandler(operation string, destination string, keys []string) (string, error) {
+	cache := CurrentCache
+
+	cache.Mutex.Lock()
+	defer cache.Mutex.Unlock()
+
+	sets := make([]map[string]bool, 0, len(keys))
+
+	for _, key := range keys {
+		item, exists := cache.Data[key]
+
+		if exists && item.Kind != KindSet {
+			return "", wrongTypeError(operation, key)
+		}
+
+		sets = append(sets, item.Set)
+	}
+
+	result := make(map[string]bool)
+
+	switch operation {
+	case "SUNION":
+		for _, set := range sets {
+			for member := range set {
+				result[member] = true
+			}
+		}
+
+	case "SINTER":
+		for member := range sets[0] {
+			inAll := true
+
+			for _, set := range sets[1:] {
+				if !set[member] {
+					inAll = false
+					break
+				}
+			}
+
+			if inAll {
+				result[member] = true
+			}
+		}
+
+	case "SDIFF":
+		for member := range sets[0] {
+			inOthers := false
+
+			for _, set := range sets[1:] {
+				if set[member] {
+					inOthers = true
+					break
+				}
+			}
+
+			if !inOthers {
+				result[member] = true
+			}
+		}
+	}
+
+	if destination == "" {
+		return formatList(sortedMembers(result)), nil
+	}
+
+	item, exists := cache.Data[destination]
+	if exists {
+		cache.deleteItem(destination, item)
+	}
+
+	if len(result) > 0 {
+		cache.Data[destination] = CacheItem{Kind: KindSet, Set: result}
+		NotifyKeyspaceEvent(NotifySet, strings.ToLower(operation)+"store", destination, cache.Index)
+	} else if exists {
+		NotifyKeyspaceEvent(NotifyGeneric, "del", destination, cache.Index)
+	}
+
+	return fmt.Sprintf(">> %v", len(result)), nil
+}
+
+func parseSetCount(command string, args []string) (int, error) {
+	if len(args) == 1 {
+		return 1, nil
+	}
+
+	count, err := strconv.Atoi(args[1])
+	if err != nil {
+		return 0, fmt.Errorf("%v %v : Count should be an integer", command, args[0])
+	}
+
+	return count, nil
+}
+
+func sortedMembers(set map[string]bool) []string {
+	members := make([]string, 0, len(set))
+
+	for member := range set {
+		members = append(members, member)
+	}
+
+	sort.Strings(members)
+
+	return members
+}
+
+// Picks count distinct members in random order with reservoir sampling, one walk over the set
+// and O(count) memory instead of sorting and shuffling every member
+func sampleMembers(set map[string]bool, count int) []string {
+	if count > len(set) {
+		count = len(set)
+	}
+
+	sample := make([]string, 0, count)
+	if count == 0 {
+		return sample
+	}
+
+	seen := 0
+	for member := range set {
+		if seen < count {
+			sample = append(sample, member)
+		} else if j := rand.Intn(seen + 1); j < count {
+			sample[j] = member
+		}
+		seen++
+	}
+
+	// The first count members keep the map's order, so shuffle the (small) sample itself
+	rand.Shuffle(len(sample), func(i, j int) { sample[i], sample[j] = sample[j], sample[i] })
+
+	return sample
+}
+
+// Picks count members that may repeat. Draws the random positions first, then fills them
+// in one walk over the set.
+func sampleMembersWithRepeats(set map[string]bool, count int) []string {
+	if len(set) == 0 {
+		return []string{}
+	}
+
+	positions := make([]int, count)
+	for i := range positions {
+		positions[i] = rand.Intn(len(set))
+	}
+
+	order := make([]int, count)
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool { return positions[order[a]] < positions[order[b]] })
+
+	sample := make([]string, count)
+	next, index := 0, 0
+	for member := range set {
+		for next < count && positions[order[next]] == index {
+			sample[order[next]] = member
+			next++
+		}
+		if next == count {
+			break
+		}
+		index++
+	}
+
+	return sample
+}
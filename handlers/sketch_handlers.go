@@ -0,0 +1,294 @@
+package handlers
+
+import (
+	"fmt"
+	"prac/utils"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Guards CountMinSketchMap, TopKMap and the sketches stored in them
+var sketchMutex sync.Mutex
+
+func CountMinSketchHandler(command string, args []string) (string, error) {
+	if len(args) == 0 {
+		return "", fmt.Errorf("%v : Missing name of the sketch", command)
+	}
+
+	key := args[0]
+
+	sketchMutex.Lock()
+	defer sketchMutex.Unlock()
+
+	cms, exists := CountMinSketchMap[key]
+
+	switch command {
+	// CMS_INITBYDIM name width depth
+	case "CMS_INITBYDIM":
+		if len(args) < 3 {
+			return "", fmt.Errorf("CMS_INITBYDIM %v : Missing width and depth", key)
+		}
+
+		if exists {
+			return "", fmt.Errorf("CMS_INITBYDIM %v : Sketch already exists !!!", key)
+		}
+
+		width, widthErr := strconv.ParseUint(args[1], 10, 32)
+		depth, depthErr := strconv.ParseUint(args[2], 10, 32)
+
+		if widthErr != nil || depthErr != nil {
+			return "", fmt.Errorf("CMS_INITBYDIM %v : Width and depth should be positive integers", key)
+		}
+
+		cms, err := utils.CreateCountMinSketch(uint(width), uint(depth))
+		if err != nil {
+			return "", fmt.Errorf("CMS_INITBYDIM %v : %v", key, err)
+		}
+
+		CountMinSketchMap[key] = cms
+
+		return ">> SUCCESS", nil
+
+	// CMS_INITBYPROB name error_rate probability
+	case "CMS_INITBYPROB":
+		if len(args) < 3 {
+			return "", fmt.Errorf("CMS_INITBYPROB %v : Missing error rate and probability", key)
+		}
+
+		if exists {
+			return "", fmt.Errorf("CMS_INITBYPROB %v : Sketch already exists !!!", key)
+		}
+
+		errorRate, errorRateErr := strconv.ParseFloat(args[1], 64)
+		probability, probabilityErr := strconv.ParseFloat(args[2], 64)
+
+		if errorRateErr != nil || probabilityErr != nil {
+			return "", fmt.Errorf("CMS_INITBYPROB %v : Error rate and probability should be numbers", key)
+		}
+
+		cms, err := utils.CreateCountMinSketchByProb(errorRate, probability)
+		if err != nil {
+			return "", fmt.Errorf("CMS_INITBYPROB %v : %v", key, err)
+		}
+
+		CountMinSketchMap[key] = cms
+
+		return ">> SUCCESS", nil
+
+	// CMS_MERGE destination numkeys source [source ...] [WEIGHTS weight [weight ...]]
+	case "CMS_MERGE":
+		return countMinSketchMergeHandler(key, args[1:])
+	}
+
+	if !exists {
+		return "", fmt.Errorf("%v %v : Sketch doesn't exist !!!", command, key)
+	}
+
+	switch command {
+	// CMS_INCRBY name item increment [item increment ...] -> estimated counts after incrementing
+	case "CMS_INCRBY":
+		if len(args) == 1 || (len(args)-1)%2 != 0 {
+			return "", fmt.Errorf("CMS_INCRBY %v : Item and increment should be given in pairs", key)
+		}
+
+		increments := make([]uint64, 0, (len(args)-1)/2)
+
+		// Everything is validated before incrementing, so that a bad pair doesn't leave the sketch half updated
+		for i := 1; i < len(args); i += 2 {
+			increment, err := strconv.ParseUint(args[i+1], 10, 64)
+			if err != nil {
+				return "", fmt.Errorf("CMS_INCRBY %v : Increment should be a non negative integer", key)
+			}
+
+			increments = append(increments, increment)
+		}
+
+		counts := make([]string, 0, len(increments))
+
+		for i, increment := range increments {
+			counts = append(counts, strconv.FormatUint(cms.IncrBy(args[2*i+1], increment), 10))
+		}
+
+		return formatList(counts), nil
+
+	// CMS_QUERY name item [item ...]
+	case "CMS_QUERY":
+		if len(args) == 1 {
+			return "", fmt.Errorf("CMS_QUERY %v : Missing item", key)
+		}
+
+		counts := make([]string, 0, len(args)-1)
+
+		for _, item := range args[1:] {
+			counts = append(counts, strconv.FormatUint(cms.Query(item), 10))
+		}
+
+		return formatList(counts), nil
+	}
+
+	return "", fmt.Errorf("Unknown command !!!")
+}
+
+// Destination is overwritten by the merge, and created with the dimensions of the sources if it doesn't exist
+func countMinSketchMergeHandler(destination string, args []string) (string, error) {
+	if len(args) == 0 {
+		return "", fmt.Errorf("CMS_MERGE %v : Missing number of sources", destination)
+	}
+
+	numKeys, err := strconv.Atoi(args[0])
+	if err != nil || numKeys < 1 || len(args) < numKeys+1 {
+		return "", fmt.Errorf("CMS_MERGE %v : Number of sources should match the sources given", destination)
+	}
+
+	sources := make([]*utils.CountMinSketch, 0, numKeys)
+
+	for _, key := range args[1 : numKeys+1] {
+		source, exists := CountMinSketchMap[key]
+		if !exists {
+			return "", fmt.Errorf("CMS_MERGE %v : Sketch %v doesn't exist !!!", destination, key)
+		}
+
+		sources = append(sources, source)
+	}
+
+	weights := make([]uint64, numKeys)
+	for i := range weights {
+		weights[i] = 1
+	}
+
+	if rest := args[numKeys+1:]; len(rest) > 0 {
+		if strings.ToUpper(rest[0]) != "WEIGHTS" || len(rest)-1 != numKeys {
+			return "", fmt.Errorf("CMS_MERGE %v : WEIGHTS should be given for every source", destination)
+		}
+
+		for i, weight := range rest[1:] {
+			if weights[i], err = strconv.ParseUint(weight, 10, 64); err != nil {
+				return "", fmt.Errorf("CMS_MERGE %v : Weights should be non negative integers", destination)
+			}
+		}
+	}
+
+	dest, exists := CountMinSketchMap[destination]
+
+	if !exists {
+		if dest, err = utils.CreateCountMinSketch(sources[0].Width, sources[0].Depth); err != nil {
+			return "", fmt.Errorf("CMS_MERGE %v : %v", destination, err)
+		}
+	}
+
+	if err := dest.Merge(sources, weights); err != nil {
+		return "", fmt.Errorf("CMS_MERGE %v : %v", destination, err)
+	}
+
+	CountMinSketchMap[destination] = dest
+
+	return ">> SUCCESS", nil
+}
+
+func TopKHandler(command string, args []string) (string, error) {
+	if len(args) == 0 {
+		return "", fmt.Errorf("%v : Missing name of the sketch", command)
+	}
+
+	key := args[0]
+
+	sketchMutex.Lock()
+	defer sketchMutex.Unlock()
+
+	topk, exists := TopKMap[key]
+
+	// TOPK_RESERVE name k [width depth decay]
+	if command == "TOPK_RESERVE" {
+		if exists {
+			return "", fmt.Errorf("TOPK_RESERVE %v : Sketch already exists !!!", key)
+		}
+
+		if len(args) != 2 && len(args) != 5 {
+			return "", fmt.Errorf("TOPK_RESERVE %v : Expected k or k, width, depth and decay", key)
+		}
+
+		k, err := strconv.ParseUint(args[1], 10, 32)
+		if err != nil {
+			return "", fmt.Errorf("TOPK_RESERVE %v : K should be a positive integer", key)
+		}
+
+		width, depth, decay := uint64(8), uint64(7), 0.9
+
+		if len(args) == 5 {
+			var widthErr, depthErr, decayErr error
+
+			width, widthErr = strconv.ParseUint(args[2], 10, 32)
+			depth, depthErr = strconv.ParseUint(args[3], 10, 32)
+			decay, decayErr = strconv.ParseFloat(args[4], 64)
+
+			if widthErr != nil || depthErr != nil || decayErr != nil {
+				return "", fmt.Errorf("TOPK_RESERVE %v : Width and depth should be positive integers and decay a number", key)
+			}
+		}
+
+		topk, err := utils.CreateTopK(uint(k), uint(width), uint(depth), decay)
+		if err != nil {
+			return "", fmt.Errorf("TOPK_RESERVE %v : %v", key, err)
+		}
+
+		TopKMap[key] = topk
+
+		return ">> SUCCESS", nil
+	}
+
+	if !exists {
+		return "", fmt.Errorf("%v %v : Sketch doesn't exist !!!", command, key)
+	}
+
+	switch command {
+	// TOPK_ADD name item [item ...] -> items expelled from the top k list
+	case "TOPK_ADD":
+		if len(args) == 1 {
+			return "", fmt.Errorf("TOPK_ADD %v : Missing item", key)
+		}
+
+		result := make([]*string, 0, len(args)-1)
+
+		for _, item := range args[1:] {
+			if expelled, ok := topk.Add(item); ok {
+				result = append(result, &expelled)
+			} else {
+				result = append(result, nil)
+			}
+		}
+
+		return formatNullableList(result), nil
+
+	// TOPK_QUERY name item [item ...]
+	case "TOPK_QUERY":
+		if len(args) == 1 {
+			return "", fmt.Errorf("TOPK_QUERY %v : Missing item", key)
+		}
+
+		result := make([]string, 0, len(args)-1)
+
+		for _, item := range args[1:] {
+			result = append(result, strconv.FormatBool(topk.Query(item)))
+		}
+
+		return formatList(result), nil
+
+	// TOPK_LIST name [WITHCOUNT]
+	case "TOPK_LIST":
+		withCount := len(args) > 1 && strings.ToUpper(args[1]) == "WITHCOUNT"
+		result := []string{}
+
+		for _, item := range topk.List() {
+			if withCount {
+				result = append(result, fmt.Sprintf("%v : %v", item.Item, item.Count))
+			} else {
+				result = append(result, item.Item)
+			}
+		}
+
+		return formatList(result), nil
+	}
+
+	return "", fmt.Errorf("Unknown command !!!")
+}
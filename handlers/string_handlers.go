@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Largest string SETRANGE can create (same as redis' proto-max-bulk-len)
+const maxStringLength = 512 * 1024 * 1024
+
+func StringHandler(command string, args []string) (string, error) {
+	if len(args) == 0 {
+		return "", fmt.Errorf("%v : Missing Key", command)
+	}
+
+	cache := CurrentCache
+	key := args[0]
+
+	cache.Mutex.Lock()
+	defer cache.Mutex.Unlock()
+
+	item, exists := cache.Data[key]
+
+	if exists && item.Kind != KindString {
+		return "", wrongTypeError(command, key)
+	}
+
+	switch command {
+	// INCR key, DECR key, INCRBY key increment, DECRBY key decrement
+	case "INCR", "DECR", "INCRBY", "DECRBY":
+		delta := int64(1)
+
+		if command == "INCRBY" || command == "DECRBY" {
+			if len(args) == 1 {
+				return "", fmt.Errorf("%v %v : Missing increment", command, key)
+			}
+
+			var err error
+			if delta, err = strconv.ParseInt(args[1], 10, 64); err != nil {
+				return "", fmt.Errorf("%v %v : Increment should be an integer", command, key)
+			}
+		}
+
+		if command == "DECR" || command == "DECRBY" {
+			if delta == math.MinInt64 {
+				return "", fmt.Errorf("%v %v : Decrement would overflow !!!", command, key)
+			}
+			delta = -delta
+		}
+
+		current := int64(0)
+
+		if exists {
+			var err error
+			if current, err = strconv.ParseInt(item.Val, 10, 64); err != nil {
+				return "", fmt.Errorf("%v %v : Value is not an integer or out of range !!!", command, key)
+			}
+		}
+
+		if (delta > 0 && current > math.MaxInt64-delta) || (delta < 0 && current < math.MinInt64-delta) {
+			return "", fmt.Errorf("%v %v : Increment or decrement would overflow !!!", command, key)
+		}
+
+		item.Val = strconv.FormatInt(current+delta, 10)
+		cache.Data[key] = item
+
+		NotifyKeyspaceEvent(NotifyString, "incrby", key, cache.Index)
+
+		return ">> " + item.Val, nil
+
+	// INCRBYFLOAT key increment
+	case "INCRBYFLOAT":
+		if len(args) == 1 {
+			return "", fmt.Errorf("INCRBYFLOAT %v : Missing increment", key)
+		}
+
+		delta, err := strconv.ParseFloat(args[1], 64)
+		if err != nil || math.IsNaN(delta) || math.IsInf(delta, 0) {
+			return "", fmt.Errorf("INCRBYFLOAT %v : Increment should be a number", key)
+		}
+
+		current := 0.0
+
+		if exists {
+			if current, err = strconv.ParseFloat(item.Val, 64); err != nil || math.IsNaN(current) || math.IsInf(current, 0) {
+				return "", fmt.Errorf("INCRBYFLOAT %v : Value is not a valid float !!!", key)
+			}
+		}
+
+		result := current + delta
+		if math.IsInf(result, 0) {
+			return "", fmt.Errorf("INCRBYFLOAT %v : Increment would produce an infinite value !!!", key)
+		}
+
+		item.Val = strconv.FormatFloat(result, 'f', -1, 64)
+		cache.Data[key] = item
+
+		NotifyKeyspaceEvent(NotifyString, "incrbyfloat", key, cache.Index)
+
+		return ">> " + item.Val, nil
+
+	// APPEND key value -> length of the string after appending
+	case "APPEND":
+		if len(args) == 1 {
+			return "", fmt.Errorf("APPEND %v : Missing value", key)
+		}
+
+		item.Val += args[1]
+		cache.Data[key] = item
+
+		NotifyKeyspaceEvent(NotifyString, "append", key, cache.Index)
+
+		return fmt.Sprintf(">> %v", len(item.Val)), nil
+
+	// STRLEN key
+	case "STRLEN":
+		return fmt.Sprintf(">> %v", len(item.Val)), nil
+
+	// GETRANGE key start end -> both inclusive, negative indexes count from the end
+	case "GETRANGE":
+		if len(args) < 3 {
+			return "", fmt.Errorf("GETRANGE %v : Missing start and end", key)
+		}
+
+		start, startErr := strconv.Atoi(args[1])
+		end, endErr := strconv.Atoi(args[2])
+
+		if startErr != nil || endErr != nil {
+			return "", fmt.Errorf("GETRANGE %v : Start and end should be integers", key)
+		}
+
+		length := len(item.Val)
+
+		if start < 0 {
+			start = max(length+start, 0)
+		}
+
+		if end < 0 {
+			end = length + end
+		}
+
+		end = min(end, length-1)
+
+		if start > end || length == 0 {
+			return ">> ", nil
+		}
+
+		return formatValue(item.Val[start : end+1]), nil
+
+	// SETRANGE key offset value -> overwrites from offset, padding with zero bytes if the string is shorter
+	case "SETRANGE":
+		if len(args) < 3 {
+			return "", fmt.Errorf("SETRANGE %v : Missing offset and value", key)
+		}
+
+		offset, err := strconv.Atoi(args[1])
+		if err != nil || offset < 0 {
+			return "", fmt.Errorf("SETRANGE %v : Offset should be a non negative integer", key)
+		}
+
+		value := args[2]
+
+		// offset+len(value) could overflow for a huge offset
+		if offset > maxStringLength-len(value) {
+			return "", fmt.Errorf("SETRANGE %v : String would exceed the maximum allowed size !!!", key)
+		}
+
+		// Nothing to write -> missing key isn't created
+		if len(value) == 0 {
+			return fmt.Sprintf(">> %v", len(item.Val)), nil
+		}
+
+		if len(item.Val) < offset+len(value) {
+			item.Val += strings.Repeat("\x00", offset+len(value)-len(item.Val))
+		}
+
+		item.Val = item.Val[:offset] + value + item.Val[offset+len(value):]
+		cache.Data[key] = item
+
+		NotifyKeyspaceEvent(NotifyString, "setrange", key, cache.Index)
+
+		return fmt.Sprintf(">> %v", len(item.Val)), nil
+
+	// GETDEL key
+	case "GETDEL":
+		if !exists {
+			return "", noKeyErrorf("GETDEL %v : Key doesn't exist !!!", key)
+		}
+
+		cache.deleteItem(key, item)
+
+		NotifyKeyspaceEvent(NotifyGeneric, "del", key, cache.Index)
+
+		return formatValue(item.Val), nil
+	}
+
+	return "", fmt.Errorf("Unknown command !!!")
+}
+
+// MSET key value [key value ...] | MSETNX key value [key value ...] | MGET key [key ...]
+func MultiStringHandler(command string, args []string) (string, error) {
+	if len(args) == 0 {
+		return "", fmt.Errorf("%v : Missing Key", command)
+	}
+
+	cache := CurrentCache
+
+	cache.Mutex.Lock()
+	defer cache.Mutex.Unlock()
+
+	switch command {
+	// MSET -> replaces existing values and clears their ttl, like SET
+	// MSETNX -> sets nothing if even one of the keys exists, output 1 when the keys were set and 0 otherwise
+	case "MSET", "MSETNX":
+		if len(args)%2 != 0 {
+			return "", fmt.Errorf("%v : Every key should have a value", command)
+		}
+
+		if command == "MSETNX" {
+			for i := 0; i < len(args); i += 2 {
+				if _, exists := cache.Data[args[i]]; exists {
+					return ">> 0", nil
+				}
+			}
+		}
+
+		for i := 0; i < len(args); i += 2 {
+			if item, exists := cache.Data[args[i]]; exists {
+				cache.deleteItem(args[i], item)
+			}
+
+			cache.Data[args[i]] = CacheItem{Val: args[i+1]}
+
+			NotifyKeyspaceEvent(NotifyString, "set", args[i], cache.Index)
+		}
+
+		if command == "MSETNX" {
+			return ">> 1", nil
+		}
+
+		return ">> SUCCESS", nil
+
+	// MGET -> (nil) for keys which don't exist or don't hold a string
+	case "MGET":
+		vals := make([]*string, 0, len(args))
+
+		for _, key := range args {
+			item, exists := cache.Data[key]
+
+			if !exists || item.Kind != KindString {
+				vals = append(vals, nil)
+			} else {
+				vals = append(vals, &item.Val)
+			}
+		}
+
+		return formatNullableList(vals), nil
+	}
+
+	return "", fmt.Errorf("Unknown command !!!")
+}
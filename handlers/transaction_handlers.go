@@ -3,9 +3,16 @@ package handlers
 import (
 	"fmt"
 	"prac/utils"
-	"strconv"
+	"strings"
 )
 
+// Commands changing whole caches aren't allowed in transactions, as their previous state isn't kept for rollback
+var nonTransactionalCommands = map[string]bool{"SWAPDB": true, "FLUSHDB": true, "FLUSHALL": true}
+
+func nonTransactionalError(command string) error {
+	return fmt.Errorf("%v : Can't be used in a transaction as it can't be rolled back !!!", command)
+}
+
 func TransactionHandler(command string, args []string, connectionObj *Connection) (string, error) {
 
 	switch command {
@@ -54,30 +61,45 @@ func TransactionHandler(command string, args []string, connectionObj *Connection
 	return "", fmt.Errorf("Unknown command !!!")
 }
 
+type rollbackEntry struct {
+	cache   *Cache
+	key     string
+	item    CacheItem
+	existed bool
+}
+
 func CommitHandler(statements []Statement) ([]string, error) {
-	rollBackLog := []Statement{}
+	rollBackLog := []rollbackEntry{}
 	successMsgLog := []string{}
 
+	for _, statement := range statements {
+		if nonTransactionalCommands[statement.Command] {
+			return nil, nonTransactionalError(statement.Command)
+		}
+	}
+
 	CurrentCache.TransactionMutex.Lock()
 	defer CurrentCache.TransactionMutex.Unlock()
 
 	for _, statement := range statements {
 
-		var previousItem CacheItem
-		var keyExists bool
+		keys := statementKeys(statement)
+		entries := make([]rollbackEntry, 0, len(keys))
+
+		for _, key := range keys {
+			key.cache.Mutex.Lock()
+			item, keyExists := key.cache.Data[key.key]
+			key.cache.Mutex.Unlock()
 
-		if (statement.Command == "DEL" && len(statement.Args) != 0) || (statement.Command == "SET" && len(statement.Args) == 2) {
-			CurrentCache.Mutex.Lock()
-			previousItem, keyExists = CurrentCache.Data[statement.Args[0]]
-			CurrentCache.Mutex.Unlock()
+			entries = append(entries, rollbackEntry{key.cache, key.key, item.Clone(), keyExists})
 		}
 
 		successMsg, err := CommandHandler(statement.Command, statement.Args)
 
 		if err != nil {
-			//fmt.Println(rollBackLog)
-			for _, st := range rollBackLog {
-				CommandHandler(st.Command, st.Args)
+			// failed statement may have partially run as well
+			for _, entry := range append(entries, rollBackLog...) {
+				entry.restore()
 			}
 
 			return nil, err
@@ -85,21 +107,116 @@ func CommitHandler(statements []Statement) ([]string, error) {
 
 		successMsgLog = append(successMsgLog, successMsg)
 
-		var rollbackStatement Statement
+		for _, entry := range entries {
+			rollBackLog = utils.Prepend(rollBackLog, entry)
+		}
+	}
+
+	return successMsgLog, nil
+}
+
+type statementKey struct {
+	cache *Cache
+	key   string
+}
+
+// Keys which the statement can modify, their previous state is kept for rollback
+func statementKeys(statement Statement) []statementKey {
+	args := statement.Args
+	keys := []statementKey{}
+
+	for _, key := range currentCacheKeys(statement) {
+		keys = append(keys, statementKey{CurrentCache, key})
+	}
 
-		// TODO : expand this properly for other commands
-		if statement.Command == "SET" {
-			if keyExists {
-				rollbackStatement = Statement{"SET", []string{statement.Args[0], previousItem.Val, strconv.Itoa(int(previousItem.TTL))}}
-			} else {
-				rollbackStatement = Statement{"DEL", statement.Args}
+	switch statement.Command {
+	// MOVE key index
+	case "MOVE":
+		if len(args) > 1 {
+			if dest, err := cacheByIndex("MOVE", args[1]); err == nil {
+				keys = append(keys, statementKey{dest, args[0]})
 			}
-		} else if statement.Command == "DEL" {
-			rollbackStatement = Statement{"SET", []string{statement.Args[0], previousItem.Val, strconv.Itoa(int(previousItem.TTL))}}
 		}
 
-		rollBackLog = utils.Prepend(rollBackLog, rollbackStatement)
+	// COPY source destination DB index -> destination is in the other cache
+	case "COPY":
+		for i := 2; i+1 < len(args); i++ {
+			if strings.ToUpper(args[i]) != "DB" {
+				continue
+			}
+
+			i++
+			if dest, err := cacheByIndex("COPY", args[i]); err == nil {
+				keys[len(keys)-1].cache = dest
+			}
+		}
 	}
 
-	return successMsgLog, nil
+	return keys
+}
+
+// Keys of the current cache which the statement can modify
+func currentCacheKeys(statement Statement) []string {
+	args := statement.Args
+
+	switch statement.Command {
+	case "NUM", "SAVE", "RETAIN", "HALT", "PING", "HELP", "NOTIFY", "PUBLISH", "PUBSUB", "SCRIPT", "EVAL", "EVALSHA",
+		"CF_CREATE", "CF_ADD", "CF_ADDNX", "CF_DEL", "CF_EXISTS", "CF_COUNT",
+		"CMS_INITBYDIM", "CMS_INITBYPROB", "CMS_INCRBY", "CMS_QUERY", "CMS_MERGE", "TOPK_RESERVE", "TOPK_ADD", "TOPK_QUERY", "TOPK_LIST":
+		// filters and sketches live outside the caches
+		return nil
+
+	case "SWAPDB", "FLUSHDB", "FLUSHALL":
+		// rejected before the transaction runs
+		return nil
+
+	case "RENAME", "RENAMENX", "COPY":
+		if len(args) > 1 {
+			return args[:2]
+		}
+
+	case "BLPOP", "BRPOP":
+		if len(args) > 1 {
+			return args[:len(args)-1]
+		}
+
+	case "LMOVE", "BLMOVE":
+		if len(args) > 1 {
+			return args[:2]
+		}
+
+	case "MSET", "MSETNX":
+		keys := []string{}
+		for i := 0; i < len(args); i += 2 {
+			keys = append(keys, args[i])
+		}
+
+		return keys
+	}
+
+	if len(args) == 0 {
+		return nil
+	}
+
+	return args[:1]
+}
+
+// Puts the key back in the state it was before the statement ran
+func (entry rollbackEntry) restore() {
+	cache := entry.cache
+
+	cache.Mutex.Lock()
+	defer cache.Mutex.Unlock()
+
+	if current, exists := cache.Data[entry.key]; exists {
+		cache.deleteItem(entry.key, current)
+	}
+
+	if entry.existed {
+		cache.Data[entry.key] = entry.item
+
+		if entry.item.CanExpire {
+			cache.SkipList.Insert(entry.key, entry.item.TTL)
+		}
+	}
 }
@@ -1,12 +1,15 @@
 package main
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -29,6 +32,17 @@ func main() {
 		PORT = ":" + PORT
 	}
 
+	// SCRIPT_TIME_LIMIT in milliseconds
+	if limit, err := strconv.Atoi(os.Getenv("SCRIPT_TIME_LIMIT")); err == nil && limit > 0 {
+		handlers.ScriptTimeLimit = time.Duration(limit) * time.Millisecond
+	}
+
+	if flags := os.Getenv("NOTIFY_KEYSPACE_EVENTS"); flags != "" {
+		if _, err := handlers.NotifyConfigHandler([]string{flags}); err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 
 	l, err := net.Listen("tcp4", PORT)
@@ -65,13 +79,25 @@ func handleConnection(c net.Conn) {
 
 	id, _ := utils.GenerateRandomId(6)
 
-	connObj := handlers.Connection{IP: c.RemoteAddr().String(), Id: id}
+	// Commands sent together (pipelining) are read one after the other from the buffer
+	reader := bufio.NewReader(c)
+	connObj := handlers.Connection{IP: c.RemoteAddr().String(), Id: id, Conn: c, Reader: reader}
 	handlers.ConnectionMap[c.RemoteAddr().String()] = &connObj
-
-	buffer := make([]byte, 1024)
+	defer handlers.PubSub.UnsubscribeAll(&connObj)
 
 	for {
-		n, err := c.Read(buffer)
+		command, args, err := utils.ReadCommand(reader)
+		if errors.Is(err, utils.ErrEmptyCommand) {
+			c.Write([]byte(utils.SerializeOutput("ERR", err.Error())))
+			continue
+		}
+
+		if errors.Is(err, utils.ErrCommandTooLarge) {
+			c.Write([]byte(utils.SerializeOutput("ERR", err.Error())))
+			log.Println(connObj.IP+":", err)
+			return
+		}
+
 		if err != nil {
 			if err == io.EOF {
 				log.Println(connObj.IP + ": Client disconnected")
@@ -81,15 +107,6 @@ func handleConnection(c net.Conn) {
 			return
 		}
 
-		data := string(buffer[:n])
-
-		command, args, err := utils.DeserializeInput(data)
-
-		if err != nil {
-			c.Write([]byte(utils.SerializeOutput("ERR", err.Error())))
-			continue
-		}
-
 		if command == "EXIT" {
 			log.Println(connObj.IP + ": Client disconnected")
 			break
@@ -101,7 +118,7 @@ func handleConnection(c net.Conn) {
 	}
 }
 
-// TODO : expire keys from inactive caches as well
+// Expires keys from every cache, the skiplist is swapped by SWAPDB and FLUSHDB so it's read under the cache's lock
 func handleSkipListExpiry(ctx context.Context) {
 	ticker := time.NewTicker(60 * time.Second)
 	defer ticker.Stop()
@@ -109,15 +126,28 @@ func handleSkipListExpiry(ctx context.Context) {
 	for {
 		select {
 		case <-ticker.C:
-			deletedKeys := handlers.CurrentCache.SkipList.DeleteExpiredKeys()
+			caches := []*handlers.Cache{handlers.CurrentCache}
+			for index := range handlers.Caches {
+				if &handlers.Caches[index] != handlers.CurrentCache {
+					caches = append(caches, &handlers.Caches[index])
+				}
+			}
 
-			handlers.CurrentCache.Mutex.Lock()
+			// Keys don't expire in the middle of a script or a commit
+			for _, cache := range caches {
+				cache.TransactionMutex.RLock()
+				cache.Mutex.Lock()
 
-			for _, key := range deletedKeys {
-				delete(handlers.CurrentCache.Data, key)
-			}
+				deletedKeys := cache.SkipList.DeleteExpiredKeys()
+
+				for _, key := range deletedKeys {
+					delete(cache.Data, key)
+					handlers.NotifyKeyspaceEvent(handlers.NotifyExpired, "expired", key, cache.Index)
+				}
 
-			handlers.CurrentCache.Mutex.Unlock()
+				cache.Mutex.Unlock()
+				cache.TransactionMutex.RUnlock()
+			}
 
 		case <-ctx.Done():
 			return
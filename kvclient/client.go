@@ -0,0 +1,332 @@
+/*
+Package kvclient talks to the kv server over a pool of connections.
+
+	client, err := kvclient.CreateClient(kvclient.Options{Addr: "localhost:9376"})
+	defer client.Close()
+
+	err = client.Set(ctx, "name", "milan", &kvclient.SetOptions{TTL: time.Minute})
+	val, err := client.Get(ctx, "name")
+
+Errors sent by the server are returned as ServerError, everything else is a network or context error.
+*/
+package kvclient
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultAddr        = "localhost:9376"
+	DefaultPoolSize    = 4
+	DefaultDialTimeout = 5 * time.Second
+	DefaultMaxRetries  = 2
+	DefaultRetryDelay  = 100 * time.Millisecond
+)
+
+var ErrClientClosed = errors.New("kvclient: client is closed")
+
+// (P)SUBSCRIBE and (P)UNSUBSCRIBE go on the subscriber connection, so they can't share a pipeline or transaction with other commands
+var ErrSubscribeMixed = errors.New("kvclient: (P)SUBSCRIBE and (P)UNSUBSCRIBE can't be sent with other commands")
+
+// ErrNil is returned by the typed methods when the key doesn't exist or the server replied (nil)
+var ErrNil = errors.New("kvclient: nil reply")
+
+// Error sent by the server (ERR reply)
+type ServerError string
+
+func (e ServerError) Error() string {
+	return string(e)
+}
+
+// Whether the command failed because the key doesn't exist
+func (e ServerError) NoKey() bool {
+	return strings.HasPrefix(string(e), "NOKEY ")
+}
+
+type Options struct {
+	Addr        string        // default localhost:9376
+	PoolSize    int           // maximum number of open connections, default 4
+	DialTimeout time.Duration // default 5s
+	Timeout     time.Duration // used for commands whose ctx has no deadline, 0 -> wait as long as ctx allows
+	MaxRetries  int           // times a command is retried when the connection can't be made or the command can't be sent, default 2, -1 -> never
+	RetryDelay  time.Duration // wait between retries, default 100ms
+
+	// Called from the subscriber connection's reader for messages pushed to it
+	OnMessage func(Message)
+}
+
+type Client struct {
+	options Options
+
+	idle  chan *conn    // connections ready to be used
+	slots chan struct{} // one entry per open connection, limits the pool size
+
+	// Subscriptions are made on their own connection, outside the pool, as a subscribed connection
+	// only accepts pub/sub commands. subscriberMu runs one command at a time on it.
+	subscriberMu sync.Mutex
+	subscriber   *conn
+
+	mu     sync.Mutex
+	closed bool
+}
+
+func CreateClient(options Options) (*Client, error) {
+	if options.Addr == "" {
+		options.Addr = DefaultAddr
+	}
+
+	if options.PoolSize < 0 {
+		return nil, errors.New("kvclient: PoolSize should be a positive number")
+	}
+
+	if options.PoolSize == 0 {
+		options.PoolSize = DefaultPoolSize
+	}
+
+	if options.DialTimeout == 0 {
+		options.DialTimeout = DefaultDialTimeout
+	}
+
+	if options.MaxRetries == 0 {
+		options.MaxRetries = DefaultMaxRetries
+	} else if options.MaxRetries < 0 {
+		options.MaxRetries = 0
+	}
+
+	if options.RetryDelay == 0 {
+		options.RetryDelay = DefaultRetryDelay
+	}
+
+	return &Client{
+		options: options,
+		idle:    make(chan *conn, options.PoolSize),
+		slots:   make(chan struct{}, options.PoolSize),
+	}, nil
+}
+
+// Sends the command on a pooled connection and returns the raw reply, (P)SUBSCRIBE and (P)UNSUBSCRIBE are sent on the subscriber connection.
+// Broken connections are replaced by new ones, and the command is sent again only if it never reached the server.
+func (client *Client) Do(ctx context.Context, command string, args ...string) (Reply, error) {
+	replies, err := client.doPipeline(ctx, [][]string{append([]string{command}, args...)})
+	if err != nil {
+		return Reply{}, err
+	}
+
+	return replies[0], replies[0].Err()
+}
+
+func (client *Client) doPipeline(ctx context.Context, statements [][]string) ([]Reply, error) {
+	subscribe, err := isSubscription(statements)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := client.withTimeout(ctx)
+	defer cancel()
+
+	for attempt := 0; ; attempt++ {
+		var replies []Reply
+
+		if subscribe {
+			replies, err = client.doSubscriber(ctx, statements)
+		} else {
+			replies, err = client.doPooled(ctx, statements)
+		}
+
+		var writeErr *writeError
+		if !errors.As(err, &writeErr) {
+			return replies, err
+		}
+
+		if !client.canRetry(ctx, err, attempt) {
+			return nil, err
+		}
+	}
+}
+
+func (client *Client) doPooled(ctx context.Context, statements [][]string) ([]Reply, error) {
+	c, err := client.getConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer client.putConn(c)
+
+	return c.doPipeline(ctx, statements)
+}
+
+// Runs the statements on the subscriber connection, dialing it if there's none yet.
+// If it was closed, a new one is dialed and the earlier subscriptions are lost with the old one.
+func (client *Client) doSubscriber(ctx context.Context, statements [][]string) ([]Reply, error) {
+	client.subscriberMu.Lock()
+	defer client.subscriberMu.Unlock()
+
+	client.mu.Lock()
+	c, closed := client.subscriber, client.closed
+	client.mu.Unlock()
+
+	if closed {
+		return nil, ErrClientClosed
+	}
+
+	if c == nil || c.isClosed() {
+		var err error
+		c, err = dial(ctx, client.options.Addr, client.options.DialTimeout, client.options.OnMessage)
+		if err != nil {
+			return nil, &writeError{err}
+		}
+
+		client.mu.Lock()
+		if client.closed {
+			client.mu.Unlock()
+			c.close()
+			return nil, ErrClientClosed
+		}
+		client.subscriber = c
+		client.mu.Unlock()
+	}
+
+	return c.doPipeline(ctx, statements)
+}
+
+// Whether the statements have to go on the subscriber connection, ErrSubscribeMixed if only some of them do
+func isSubscription(statements [][]string) (bool, error) {
+	count := 0
+
+	for _, statement := range statements {
+		switch strings.ToUpper(statement[0]) {
+		case "SUBSCRIBE", "PSUBSCRIBE", "UNSUBSCRIBE", "PUNSUBSCRIBE":
+			count++
+		}
+	}
+
+	if count > 0 && count < len(statements) {
+		return false, ErrSubscribeMixed
+	}
+
+	return count > 0, nil
+}
+
+// Waits before the next attempt, returns false if the command shouldn't be retried
+func (client *Client) canRetry(ctx context.Context, err error, attempt int) bool {
+	if attempt >= client.options.MaxRetries || errors.Is(err, ErrClientClosed) || ctx.Err() != nil {
+		return false
+	}
+
+	select {
+	case <-time.After(client.options.RetryDelay):
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (client *Client) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok || client.options.Timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, client.options.Timeout)
+}
+
+// Idle connection if one is available, otherwise a new one if the pool isn't full, otherwise waits for one
+func (client *Client) getConn(ctx context.Context) (*conn, error) {
+	for {
+		if client.isClosed() {
+			return nil, ErrClientClosed
+		}
+
+		var c *conn
+
+		select {
+		case c = <-client.idle:
+		default:
+			select {
+			case c = <-client.idle:
+
+			case client.slots <- struct{}{}:
+				newConn, err := dial(ctx, client.options.Addr, client.options.DialTimeout, nil)
+				if err != nil {
+					<-client.slots
+					return nil, &writeError{err}
+				}
+
+				return newConn, nil
+
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		// Closed by the server while idle -> reconnect
+		if c.isClosed() {
+			client.discardConn(c)
+			continue
+		}
+
+		return c, nil
+	}
+}
+
+func (client *Client) putConn(c *conn) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	if c.isClosed() || client.closed {
+		client.discardConn(c)
+		return
+	}
+
+	client.idle <- c
+}
+
+func (client *Client) discardConn(c *conn) {
+	c.close()
+	<-client.slots
+}
+
+func (client *Client) isClosed() bool {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	return client.closed
+}
+
+// Closes the idle connections and the subscriber connection, connections in use are closed when they're given back
+func (client *Client) Close() error {
+	client.mu.Lock()
+	client.closed = true
+	if client.subscriber != nil {
+		client.subscriber.close()
+	}
+	client.mu.Unlock()
+
+	for {
+		select {
+		case c := <-client.idle:
+			client.discardConn(c)
+		default:
+			return nil
+		}
+	}
+}
+
+// Output without the leading ">> ". Values are sent with \ and newlines escaped as \\ and \n, which is undone.
+func (reply Reply) Value() string {
+	value := strings.TrimPrefix(reply.Output, ">> ")
+
+	// Outputs spanning lines (lists, SCAN) aren't single values
+	if strings.Contains(value, "\n") {
+		return value
+	}
+
+	return unescapeValue(value)
+}
+
+// Whether the server replied (nil). Nil and lists start with ">>\n", so a value reading (nil) isn't taken for it.
+func (reply Reply) IsNil() bool {
+	return reply.Output == ">>\n(nil)"
+}
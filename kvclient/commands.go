@@ -0,0 +1,390 @@
+package kvclient
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func (client *Client) Ping(ctx context.Context) error {
+	_, err := client.Do(ctx, "PING")
+	return err
+}
+
+// Returns ErrNil if the key doesn't exist
+func (client *Client) Get(ctx context.Context, key string) (string, error) {
+	reply, err := client.Do(ctx, "GET", key)
+
+	if serverErr, ok := err.(ServerError); ok && serverErr.NoKey() {
+		return "", ErrNil
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return reply.Value(), nil
+}
+
+type SetOptions struct {
+	NX       bool          // only set if the key doesn't exist
+	XX       bool          // only set if the key exists
+	KeepTTL  bool          // keep the ttl of the existing key
+	TTL      time.Duration // sent as EX when it's whole seconds, PX otherwise
+	ExpireAt time.Time     // sent as PXAT
+}
+
+// Returns false when NX or XX stopped the value from being set. options can be nil.
+func (client *Client) Set(ctx context.Context, key string, value string, options *SetOptions) (bool, error) {
+	args := []string{key, value}
+
+	if options != nil {
+		if options.NX {
+			args = append(args, "NX")
+		}
+
+		if options.XX {
+			args = append(args, "XX")
+		}
+
+		if options.KeepTTL {
+			args = append(args, "KEEPTTL")
+		}
+
+		if options.TTL > 0 {
+			if options.TTL%time.Second == 0 {
+				args = append(args, "EX", strconv.FormatInt(int64(options.TTL/time.Second), 10))
+			} else {
+				args = append(args, "PX", strconv.FormatInt(options.TTL.Milliseconds(), 10))
+			}
+		}
+
+		if !options.ExpireAt.IsZero() {
+			args = append(args, "PXAT", strconv.FormatInt(options.ExpireAt.UnixMilli(), 10))
+		}
+	}
+
+	reply, err := client.Do(ctx, "SET", args...)
+	if err != nil {
+		return false, err
+	}
+
+	return !reply.IsNil(), nil
+}
+
+func (client *Client) Del(ctx context.Context, key string) error {
+	_, err := client.Do(ctx, "DEL", key)
+	return err
+}
+
+// Number of the given keys which exist
+func (client *Client) Exists(ctx context.Context, keys ...string) (int, error) {
+	reply, err := client.Do(ctx, "EXISTS", keys...)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.Atoi(reply.Value())
+}
+
+func (client *Client) IncrBy(ctx context.Context, key string, increment int64) (int64, error) {
+	reply, err := client.Do(ctx, "INCRBY", key, strconv.FormatInt(increment, 10))
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseInt(reply.Value(), 10, 64)
+}
+
+// ttl is rounded up to seconds
+func (client *Client) Expire(ctx context.Context, key string, ttl time.Duration) error {
+	seconds := int64((ttl + time.Second - 1) / time.Second)
+
+	_, err := client.Do(ctx, "EXPIRE", key, strconv.FormatInt(seconds, 10))
+	return err
+}
+
+// Switches the cache used by the server. NOTE: the current cache is shared by every connection to the server.
+func (client *Client) Select(ctx context.Context, index int) error {
+	_, err := client.Do(ctx, "NUM", strconv.Itoa(index))
+	return err
+}
+
+/*
+***************************
+Transactions
+***************************
+*/
+
+type Tx struct {
+	statements [][]string
+}
+
+func (tx *Tx) Queue(command string, args ...string) {
+	tx.statements = append(tx.statements, append([]string{command}, args...))
+}
+
+// Runs the commands queued by fn in a single transaction (BEGIN ... COMMIT) on one connection.
+// If the server rejects a command, the transaction is discarded and the error returned.
+func (client *Client) Transaction(ctx context.Context, fn func(tx *Tx)) error {
+	tx := &Tx{}
+	fn(tx)
+
+	if subscribe, err := isSubscription(tx.statements); subscribe || err != nil {
+		return ErrSubscribeMixed
+	}
+
+	ctx, cancel := client.withTimeout(ctx)
+	defer cancel()
+
+	c, err := client.getConn(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.putConn(c)
+
+	if _, err := c.do(ctx, "BEGIN", nil); err != nil {
+		return err
+	}
+
+	for _, statement := range tx.statements {
+		if _, err := c.do(ctx, statement[0], statement[1:]); err != nil {
+			c.do(ctx, "DISCARD", nil)
+			return err
+		}
+	}
+
+	_, err = c.do(ctx, "COMMIT", nil)
+	return err
+}
+
+/*
+***************************
+Bloom filters
+***************************
+*/
+
+type BloomOptions struct {
+	ErrorRate       float64 // default 0.01
+	Capacity        int     // default 1000
+	Scalable        bool    // adds filters as it fills up
+	Expansion       int     // capacity multiplier of every new filter, default 2
+	TighteningRatio float64 // error rate multiplier of every new filter, default 0.5
+}
+
+// options can be nil
+func (client *Client) BFCreate(ctx context.Context, name string, options *BloomOptions) error {
+	args := []string{name}
+
+	if options != nil {
+		errorRate, capacity := options.ErrorRate, options.Capacity
+
+		if errorRate == 0 {
+			errorRate = 0.01
+		}
+
+		if capacity == 0 {
+			capacity = 1000
+		}
+
+		args = append(args, strconv.FormatFloat(errorRate, 'f', -1, 64), strconv.Itoa(capacity))
+
+		if options.Scalable {
+			expansion, ratio := options.Expansion, options.TighteningRatio
+
+			if expansion == 0 {
+				expansion = 2
+			}
+
+			if ratio == 0 {
+				ratio = 0.5
+			}
+
+			args = append(args, "T", strconv.Itoa(expansion), strconv.FormatFloat(ratio, 'f', -1, 64))
+		}
+	}
+
+	_, err := client.Do(ctx, "BF_CREATE", args...)
+	return err
+}
+
+func (client *Client) BFAdd(ctx context.Context, name string, item string) error {
+	_, err := client.Do(ctx, "BF_ADD", name, item)
+	return err
+}
+
+func (client *Client) BFExists(ctx context.Context, name string, item string) (bool, error) {
+	reply, err := client.Do(ctx, "BF_EXISTS", name, item)
+	if err != nil {
+		return false, err
+	}
+
+	return reply.Value() == "true", nil
+}
+
+// For every item, whether it was newly added
+func (client *Client) BFMAdd(ctx context.Context, name string, items ...string) ([]bool, error) {
+	return client.boolList(ctx, "BF_MADD", append([]string{name}, items...))
+}
+
+func (client *Client) BFMExists(ctx context.Context, name string, items ...string) ([]bool, error) {
+	return client.boolList(ctx, "BF_MEXISTS", append([]string{name}, items...))
+}
+
+func (client *Client) BFDrop(ctx context.Context, name string) error {
+	_, err := client.Do(ctx, "BF_DROP", name)
+	return err
+}
+
+func (client *Client) boolList(ctx context.Context, command string, args []string) ([]bool, error) {
+	reply, err := client.Do(ctx, command, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := ParseList(reply.Output)
+	if err != nil {
+		return nil, err
+	}
+
+	vals := make([]bool, len(items))
+	for i, item := range items {
+		vals[i] = item == "true"
+	}
+
+	return vals, nil
+}
+
+// ">>\n1) a\n2) b" -> [a b], ">>\n(empty list)" -> []. (nil) items are returned as empty strings.
+func ParseList(output string) ([]string, error) {
+	items, err := ParseNullableList(output)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]string, len(items))
+	for i, item := range items {
+		if item != nil {
+			values[i] = *item
+		}
+	}
+
+	return values, nil
+}
+
+/*
+Same as ParseList, with nil for (nil) items. Items are sent one per line :
+\ and newlines in them are escaped as \\ and \n, and a value reading (nil) as \(nil).
+*/
+func ParseNullableList(output string) ([]*string, error) {
+	body, isList := strings.CutPrefix(output, ">>\n")
+	if !isList || body == "(nil)" {
+		return nil, fmt.Errorf("kvclient: reply isn't a list")
+	}
+
+	return parseListBody(body)
+}
+
+// SCAN reply -> next cursor and the keys
+func ParseScan(output string) (string, []string, error) {
+	cursor, body, _ := strings.Cut(strings.TrimPrefix(output, ">> "), "\n")
+
+	items, err := parseListBody(body)
+	if err != nil {
+		return "", nil, err
+	}
+
+	keys := make([]string, len(items))
+	for i, item := range items {
+		keys[i] = *item
+	}
+
+	return cursor, keys, nil
+}
+
+func parseListBody(body string) ([]*string, error) {
+	if body == "(empty list)" {
+		return []*string{}, nil
+	}
+
+	lines := strings.Split(body, "\n")
+	items := make([]*string, 0, len(lines))
+
+	for i, line := range lines {
+		item, found := strings.CutPrefix(line, strconv.Itoa(i+1)+") ")
+		if !found {
+			return nil, fmt.Errorf("kvclient: unexpected list item %q", line)
+		}
+
+		if item == "(nil)" {
+			items = append(items, nil)
+			continue
+		}
+
+		item = unescapeValue(item)
+		items = append(items, &item)
+	}
+
+	return items, nil
+}
+
+func unescapeValue(value string) string {
+	if !strings.Contains(value, `\`) {
+		return value
+	}
+
+	var sb strings.Builder
+
+	for i := 0; i < len(value); i++ {
+		if value[i] == '\\' && i+1 < len(value) {
+			i++
+			if value[i] == 'n' {
+				sb.WriteByte('\n')
+				continue
+			}
+		}
+
+		sb.WriteByte(value[i])
+	}
+
+	return sb.String()
+}
+
+/*
+***************************
+Pipelining
+***************************
+*/
+
+// Commands queued on a pipeline are sent in one write, saving a round trip per command
+type Pipeline struct {
+	client     *Client
+	statements [][]string
+}
+
+func (client *Client) Pipeline() *Pipeline {
+	return &Pipeline{client: client}
+}
+
+func (pipeline *Pipeline) Queue(command string, args ...string) {
+	pipeline.statements = append(pipeline.statements, append([]string{command}, args...))
+}
+
+func (pipeline *Pipeline) Len() int {
+	return len(pipeline.statements)
+}
+
+// Sends the queued commands and returns their replies in the same order, the pipeline is empty afterwards.
+// A command failing on the server doesn't stop the others, check Reply.Err of every reply.
+func (pipeline *Pipeline) Exec(ctx context.Context) ([]Reply, error) {
+	statements := pipeline.statements
+	pipeline.statements = nil
+
+	if len(statements) == 0 {
+		return []Reply{}, nil
+	}
+
+	return pipeline.client.doPipeline(ctx, statements)
+}
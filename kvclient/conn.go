@@ -0,0 +1,223 @@
+package kvclient
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+var errConnClosed = errors.New("kvclient: connection closed")
+
+// Reply as sent by the server -> COMMAND\r\nOUTPUT\r\n
+type Reply struct {
+	Command string
+	Output  string
+}
+
+// ServerError if the server replied with ERR
+func (reply Reply) Err() error {
+	if reply.Command == "ERR" {
+		return ServerError(unescapeValue(reply.Output))
+	}
+
+	return nil
+}
+
+// Message pushed by the server on a subscribed connection
+type Message struct {
+	Command string // MESSAGE or PMESSAGE
+	Output  string // \ and newlines in the channel and message are escaped as \\ and \n
+}
+
+// Single connection to the server. Replies are read in the background, so that pushed
+// pub/sub messages can be handed to onMessage while no command is running.
+type conn struct {
+	netConn   net.Conn
+	replies   chan Reply
+	closed    chan struct{}
+	closeOnce sync.Once
+	onMessage func(Message)
+}
+
+func dial(ctx context.Context, addr string, timeout time.Duration, onMessage func(Message)) (*conn, error) {
+	dialer := net.Dialer{Timeout: timeout}
+
+	netConn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &conn{netConn: netConn, replies: make(chan Reply), closed: make(chan struct{}), onMessage: onMessage}
+
+	go c.readReplies()
+
+	return c, nil
+}
+
+func (c *conn) readReplies() {
+	defer c.close()
+
+	reader := bufio.NewReader(c.netConn)
+
+	for {
+		command, err := readLine(reader)
+		if err != nil {
+			return
+		}
+
+		output, err := readLine(reader)
+		if err != nil {
+			return
+		}
+
+		if command == "MESSAGE" || command == "PMESSAGE" {
+			if c.onMessage != nil {
+				c.onMessage(Message{Command: command, Output: output})
+			}
+			continue
+		}
+
+		select {
+		case c.replies <- Reply{Command: command, Output: output}:
+		case <-c.closed:
+			return
+		}
+	}
+}
+
+// Reads till \r\n. Output can span multiple lines seperated by \n.
+func readLine(reader *bufio.Reader) (string, error) {
+	var line string
+
+	for !strings.HasSuffix(line, "\r\n") {
+		part, err := reader.ReadString('\n')
+		if err != nil {
+			return "", err
+		}
+
+		line += part
+	}
+
+	return strings.TrimSuffix(line, "\r\n"), nil
+}
+
+// Sends the command and waits for its reply
+func (c *conn) do(ctx context.Context, command string, args []string) (Reply, error) {
+	replies, err := c.doPipeline(ctx, [][]string{append([]string{command}, args...)})
+	if err != nil {
+		return Reply{}, err
+	}
+
+	return replies[0], replies[0].Err()
+}
+
+// Sends all the commands in one write and reads their replies in order. The connection is closed if ctx ends first,
+// as the remaining replies would otherwise be read as the replies of the next commands.
+func (c *conn) doPipeline(ctx context.Context, statements [][]string) ([]Reply, error) {
+	var input strings.Builder
+
+	for _, statement := range statements {
+		serialized, err := SerializeCommand(statement[0], statement[1:])
+		if err != nil {
+			return nil, err
+		}
+
+		input.WriteString(serialized)
+	}
+
+	if c.isClosed() {
+		return nil, &writeError{errConnClosed}
+	}
+
+	deadline, _ := ctx.Deadline()
+	c.netConn.SetWriteDeadline(deadline)
+
+	// Replies are read while writing, otherwise the server could block on sending replies of a big pipeline
+	// while we block on sending it the rest of the pipeline
+	writeDone := make(chan error, 1)
+
+	go func() {
+		n, err := c.netConn.Write([]byte(input.String()))
+		if err != nil && n == 0 {
+			err = &writeError{err}
+		}
+
+		writeDone <- err
+	}()
+
+	replies := make([]Reply, 0, len(statements))
+
+	for len(replies) < len(statements) {
+		select {
+		case reply := <-c.replies:
+			replies = append(replies, reply)
+
+		case err := <-writeDone:
+			if err != nil {
+				c.close()
+				return nil, err
+			}
+
+		case <-c.closed:
+			return nil, errConnClosed
+
+		case <-ctx.Done():
+			c.close()
+			return nil, ctx.Err()
+		}
+	}
+
+	return replies, nil
+}
+
+func (c *conn) close() {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+		c.netConn.Close()
+	})
+}
+
+func (c *conn) isClosed() bool {
+	select {
+	case <-c.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// Command couldn't be sent, so it's safe to send it again on another connection
+type writeError struct {
+	err error
+}
+
+func (e *writeError) Error() string {
+	return e.err.Error()
+}
+
+func (e *writeError) Unwrap() error {
+	return e.err
+}
+
+// *<number of parts>\r\nCOMMAND\r\nARG\r\n... -> arguments are sent as they are, so they can't contain \r\n
+func SerializeCommand(command string, args []string) (string, error) {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "*%v\r\n", len(args)+1)
+	sb.WriteString(strings.ToUpper(command) + "\r\n")
+
+	for _, arg := range args {
+		if strings.Contains(arg, "\r\n") {
+			return "", fmt.Errorf("%v : Arguments can't contain \\r\\n", strings.ToUpper(command))
+		}
+
+		sb.WriteString(arg + "\r\n")
+	}
+
+	return sb.String(), nil
+}
@@ -1,7 +1,14 @@
 package tests
 
 import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"prac/handlers"
 	"prac/utils"
+	"sync"
 	"testing"
 )
 
@@ -67,3 +74,490 @@ func TestSetGetBit(t *testing.T) {
 		t.Error("Expected 5th bit to be set to 0 but found 1")
 	}
 }
+
+func TestBloomFilterHandlers(t *testing.T) {
+	handlers.SetUpCaches(8, 16)
+
+	tests := []struct {
+		name        string
+		command     string
+		args        []string
+		expectError bool
+		expectedErr string
+		expectedVal string
+	}{
+		{name: "BF_CREATE", command: "BF_CREATE", args: []string{"users", "0.01", "100"}, expectedVal: ">> SUCCESS"},
+		{name: "BF_CREATE existing", command: "BF_CREATE", args: []string{"users"}, expectError: true, expectedErr: "BF_CREATE users : Bloom filter already exists !!!"},
+		{name: "BF_CREATE scalable", command: "BF_CREATE", args: []string{"emails", "0.01", "100", "T", "4", "0.8"}, expectedVal: ">> SUCCESS"},
+		{name: "BF_CREATE invalid expansion", command: "BF_CREATE", args: []string{"bad", "0.01", "100", "T", "0"}, expectError: true, expectedErr: "BF_CREATE bad : Expansion should be a positive integer"},
+		{name: "BF_CREATE invalid ratio", command: "BF_CREATE", args: []string{"bad", "0.01", "100", "T", "2", "1.5"}, expectError: true, expectedErr: "BF_CREATE bad : Tightening ratio should lie in the range of (0, 1)"},
+		{name: "BF_MADD", command: "BF_MADD", args: []string{"users", "alice", "bob", "alice"}, expectedVal: ">>\n1) true\n2) true\n3) false"},
+		{name: "BF_MEXISTS", command: "BF_MEXISTS", args: []string{"users", "alice", "carol", "bob"}, expectedVal: ">>\n1) true\n2) false\n3) true"},
+		{name: "BF_MEXISTS missing filter", command: "BF_MEXISTS", args: []string{"missing", "alice"}, expectError: true, expectedErr: "BF_MEXISTS : Wrong name of the bloom filter"},
+		{name: "BF_LIST", command: "BF_LIST", args: []string{}, expectedVal: ">>\n1) emails\n2) users"},
+		{name: "BF_LIST pattern", command: "BF_LIST", args: []string{"u*"}, expectedVal: ">>\n1) users"},
+		{name: "BF_DROP", command: "BF_DROP", args: []string{"emails"}, expectedVal: ">> SUCCESS"},
+		{name: "BF_DROP missing", command: "BF_DROP", args: []string{"emails"}, expectError: true, expectedErr: "BF_DROP : Wrong name of the bloom filter"},
+		{name: "BF_LIST after drop", command: "BF_LIST", args: []string{}, expectedVal: ">>\n1) users"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			val, err := handlers.CommandHandler(test.command, test.args)
+
+			if test.expectError {
+				if err == nil {
+					t.Errorf("Expected error but got none")
+				} else if err.Error() != test.expectedErr {
+					t.Errorf("Expected error: %v, but got: %v", test.expectedErr, err.Error())
+				}
+			} else {
+				if err != nil {
+					t.Errorf("Did not expect an error but got: %v", err)
+				}
+				if val != test.expectedVal {
+					t.Errorf("Expected value %q, but got %q", test.expectedVal, val)
+				}
+			}
+		})
+	}
+}
+
+func TestBloomFilterInfo(t *testing.T) {
+	bl := utils.CreateBloomFilter(100, 0.01)
+
+	for i := 0; i < 100; i++ {
+		bl.Set(fmt.Sprintf("item-%v", i))
+	}
+
+	info := bl.Info()
+
+	if info.NumItems != 100 || info.Capacity != 100 || info.NumFilters != 1 {
+		t.Errorf("Unexpected info %+v", info)
+	}
+
+	if info.SizeInBytes != len(bl.Arr)*8 || info.BitsSet == 0 || info.BitsSet > info.TotalBits {
+		t.Errorf("Unexpected bit counts %+v", info)
+	}
+
+	// Filled up to its capacity -> close to the requested error rate
+	if info.FalsePositiveRate < 0.001 || info.FalsePositiveRate > 0.05 {
+		t.Errorf("Expected false positive rate close to 0.01, got %v", info.FalsePositiveRate)
+	}
+
+	abl := utils.CreateAdaptiveBloomFilter(10, 0.01, 2, 0.5)
+
+	for i := 0; i < 30; i++ {
+		abl.Set(fmt.Sprintf("item-%v", i))
+	}
+
+	if info := abl.Info(); info.NumItems != 30 || info.NumFilters < 2 {
+		t.Errorf("Expected scalable filter to grow, got %+v", info)
+	}
+}
+
+func TestScalableBloomFilterErrorRate(t *testing.T) {
+	abl := utils.CreateAdaptiveBloomFilter(100, 0.01, 2, 0.5)
+
+	for i := 0; i < 20000; i++ {
+		if err := abl.Set(fmt.Sprintf("item-%v", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i := 0; i < 20000; i++ {
+		if !abl.DoesExist(fmt.Sprintf("item-%v", i)) {
+			t.Fatalf("Expected item-%v to exist", i)
+		}
+	}
+
+	if len(abl.Filters) < 7 {
+		t.Errorf("Expected the filter to grow, got %v filters", len(abl.Filters))
+	}
+
+	falsePositives := 0
+	for i := 0; i < 20000; i++ {
+		if abl.DoesExist(fmt.Sprintf("other-%v", i)) {
+			falsePositives++
+		}
+	}
+
+	// Compound error rate should stay within the requested bound (with some slack for randomness)
+	if rate := float64(falsePositives) / 20000; rate > 0.015 {
+		t.Errorf("Expected false positive rate below 0.01, got %v", rate)
+	}
+
+	if info := abl.Info(); info.FalsePositiveRate > 0.015 {
+		t.Errorf("Expected estimated false positive rate close to 0.01, got %v", info.FalsePositiveRate)
+	}
+}
+
+// Run with -race to catch unsynchronized access
+func TestBloomFilterConcurrentAccess(t *testing.T) {
+	filters := map[string]utils.BloomFilter{
+		"plain":    utils.CreateBloomFilter(8000, 0.01),
+		"scalable": utils.CreateAdaptiveBloomFilter(100, 0.01, 2, 0.5),
+	}
+
+	for name, bl := range filters {
+		t.Run(name, func(t *testing.T) {
+			var wg sync.WaitGroup
+
+			for g := 0; g < 8; g++ {
+				wg.Add(1)
+
+				go func(g int) {
+					defer wg.Done()
+
+					for i := 0; i < 1000; i++ {
+						item := fmt.Sprintf("item-%v-%v", g, i)
+
+						if err := bl.Set(item); err != nil {
+							t.Error(err)
+							return
+						}
+
+						if !bl.DoesExist(item) {
+							t.Errorf("Expected %v to exist right after adding it", item)
+							return
+						}
+
+						bl.DoesExist(fmt.Sprintf("other-%v-%v", g, i))
+					}
+				}(g)
+			}
+
+			wg.Wait()
+
+			for g := 0; g < 8; g++ {
+				for i := 0; i < 1000; i++ {
+					if !bl.DoesExist(fmt.Sprintf("item-%v-%v", g, i)) {
+						t.Fatalf("Expected item-%v-%v to exist, a concurrent set was lost", g, i)
+					}
+				}
+			}
+
+			// Scalable filter skips false positives, so it may count a few less
+			if info := bl.Info(); info.NumItems > 8000 || info.NumItems < 7900 {
+				t.Errorf("Expected about 8000 items, got %v", info.NumItems)
+			}
+		})
+	}
+}
+
+func TestBloomFilterHandlersConcurrent(t *testing.T) {
+	handlers.SetUpCaches(8, 16)
+
+	var wg sync.WaitGroup
+
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+
+		go func(g int) {
+			defer wg.Done()
+
+			name := fmt.Sprintf("filter-%v", g%4)
+
+			// Half of the creates race on the same name, one of them has to fail
+			handlers.CommandHandler("BF_CREATE", []string{name, "0.01", "1000", "T"})
+
+			for i := 0; i < 200; i++ {
+				item := fmt.Sprintf("item-%v-%v", g, i)
+
+				if _, err := handlers.CommandHandler("BF_ADD", []string{name, item}); err != nil {
+					t.Error(err)
+					return
+				}
+
+				if val, err := handlers.CommandHandler("BF_EXISTS", []string{name, item}); err != nil || val != ">> true" {
+					t.Errorf("Expected %v to exist in %v, got %v %v", item, name, val, err)
+					return
+				}
+
+				handlers.CommandHandler("BF_MEXISTS", []string{name, item, "missing"})
+				handlers.CommandHandler("BF_LIST", []string{})
+				handlers.CommandHandler("BF_INFO", []string{name})
+			}
+		}(g)
+	}
+
+	wg.Wait()
+
+	if val, _ := handlers.CommandHandler("BF_LIST", []string{}); val != ">>\n1) filter-0\n2) filter-1\n3) filter-2\n4) filter-3" {
+		t.Errorf("Unexpected filters %q", val)
+	}
+}
+
+func TestBloomFilterScanDumpLoadChunk(t *testing.T) {
+	handlers.SetUpCaches(8, 16)
+
+	for _, scalable := range []string{"F", "T"} {
+		source, loaded := "source-"+scalable, "copy-"+scalable
+
+		if _, err := handlers.CommandHandler("BF_CREATE", []string{source, "0.01", "500", scalable}); err != nil {
+			t.Fatal(err)
+		}
+
+		for i := 0; i < 2000; i++ {
+			handlers.CommandHandler("BF_ADD", []string{source, fmt.Sprintf("item-%v", i)})
+		}
+
+		iterator := "0"
+		chunks := 0
+
+		for {
+			val, err := handlers.CommandHandler("BF_SCANDUMP", []string{source, iterator})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var data string
+			fmt.Sscanf(val, ">>\n1) %s\n2) %s", &iterator, &data)
+
+			if iterator == "0" {
+				break
+			}
+
+			// Every chunk has to fit in a single command read by the server
+			if len(data) > 900 {
+				t.Fatalf("Chunk of %v bytes is too big", len(data))
+			}
+
+			if _, err := handlers.CommandHandler("BF_LOADCHUNK", []string{loaded, iterator, data}); err != nil {
+				t.Fatal(err)
+			}
+
+			chunks++
+		}
+
+		if chunks < 2 {
+			t.Fatalf("Expected header and data chunks, got %v chunks", chunks)
+		}
+
+		for i := 0; i < 2000; i++ {
+			if val, _ := handlers.CommandHandler("BF_EXISTS", []string{loaded, fmt.Sprintf("item-%v", i)}); val != ">> true" {
+				t.Fatalf("Expected item-%v to exist in the loaded filter", i)
+			}
+		}
+
+		sourceInfo, _ := handlers.CommandHandler("BF_INFO", []string{source})
+		loadedInfo, _ := handlers.CommandHandler("BF_INFO", []string{loaded})
+
+		if sourceInfo != loadedInfo {
+			t.Errorf("Expected the loaded filter to match the source\n%v\n%v", sourceInfo, loadedInfo)
+		}
+	}
+
+	if _, err := handlers.CommandHandler("BF_LOADCHUNK", []string{"copy-F", "1", "AQ=="}); err == nil || err.Error() != "BF_LOADCHUNK copy-F : Bloom filter already exists !!!" {
+		t.Errorf("Expected loading a header over an existing filter to fail, got %v", err)
+	}
+
+	if _, err := handlers.CommandHandler("BF_LOADCHUNK", []string{"other", "1", "AQ=="}); err == nil || err.Error() != "BF_LOADCHUNK other : Invalid header chunk !!!" {
+		t.Errorf("Expected invalid header to fail, got %v", err)
+	}
+}
+
+// Headers are sent by the client, forged ones shouldn't crash the server or create a broken filter
+func TestBloomFilterLoadForgedHeader(t *testing.T) {
+	handlers.SetUpCaches(8, 16)
+
+	type plainHeader struct {
+		EstimatedCapacity uint64
+		HashFuncNum       uint8
+		ErrorRate         float64
+		NumItems          uint64
+		NumWords          uint64
+	}
+
+	type scalableHeader struct {
+		ErrorRate       float64
+		Expansion       uint64
+		TighteningRatio float64
+		CurrentNumItems uint64
+		MaxCapacity     uint64
+		NumFilters      uint32
+	}
+
+	encode := func(parts ...any) string {
+		var buf bytes.Buffer
+		for _, part := range parts {
+			binary.Write(&buf, binary.LittleEndian, part)
+		}
+		return base64.StdEncoding.EncodeToString(buf.Bytes())
+	}
+
+	valid := plainHeader{EstimatedCapacity: 100, HashFuncNum: 7, ErrorRate: 0.01, NumWords: 16}
+	validScalable := scalableHeader{ErrorRate: 0.01, Expansion: 2, TighteningRatio: 0.5, MaxCapacity: 100, NumFilters: 1}
+
+	with := func(change func(header *plainHeader)) plainHeader {
+		header := valid
+		change(&header)
+		return header
+	}
+
+	withScalable := func(change func(header *scalableHeader)) scalableHeader {
+		header := validScalable
+		change(&header)
+		return header
+	}
+
+	tests := []struct {
+		name        string
+		data        string
+		expectedErr string
+	}{
+		{name: "huge number of words", data: encode(false, with(func(h *plainHeader) { h.NumWords = 1 << 62 })), expectedErr: "BF_LOADCHUNK forged : Bloom filter can't be bigger than 134217728 bytes !!!"},
+		{name: "no words", data: encode(false, with(func(h *plainHeader) { h.NumWords = 0 })), expectedErr: "BF_LOADCHUNK forged : Bloom filter can't be bigger than 134217728 bytes !!!"},
+		{name: "no hash functions", data: encode(false, with(func(h *plainHeader) { h.HashFuncNum = 0 })), expectedErr: "BF_LOADCHUNK forged : Number of hash functions should be a positive integer !!!"},
+		{name: "invalid error rate", data: encode(false, with(func(h *plainHeader) { h.ErrorRate = 1.5 })), expectedErr: "BF_LOADCHUNK forged : Error rate should lie in the range of (0, 1) !!!"},
+		{name: "huge number of filters", data: encode(true, withScalable(func(h *scalableHeader) { h.NumFilters = 1<<32 - 1 }), valid), expectedErr: "BF_LOADCHUNK forged : Number of filters should lie in the range of [1, 1024] !!!"},
+		{name: "filters missing from the chunk", data: encode(true, withScalable(func(h *scalableHeader) { h.NumFilters = 3 }), valid), expectedErr: "BF_LOADCHUNK forged : Invalid header chunk !!!"},
+		{name: "no expansion", data: encode(true, withScalable(func(h *scalableHeader) { h.Expansion = 0 }), valid), expectedErr: "BF_LOADCHUNK forged : Expansion should be a positive integer !!!"},
+		{name: "invalid tightening ratio", data: encode(true, withScalable(func(h *scalableHeader) { h.TighteningRatio = 0 }), valid), expectedErr: "BF_LOADCHUNK forged : Tightening ratio should lie in the range of (0, 1) !!!"},
+		{name: "too many words in total", data: encode(true, withScalable(func(h *scalableHeader) { h.NumFilters = 2 }), with(func(h *plainHeader) { h.NumWords = 1 << 23 }), with(func(h *plainHeader) { h.NumWords = 1<<23 + 1 })), expectedErr: "BF_LOADCHUNK forged : Bloom filter can't be bigger than 134217728 bytes !!!"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := handlers.CommandHandler("BF_LOADCHUNK", []string{"forged", "1", test.data})
+
+			if err == nil || err.Error() != test.expectedErr {
+				t.Errorf("Expected error: %v, but got: %v", test.expectedErr, err)
+			}
+		})
+	}
+
+	if _, err := handlers.CommandHandler("BF_LOADCHUNK", []string{"forged", "1", encode(false, valid)}); err != nil {
+		t.Errorf("Expected a valid header to load, got %v", err)
+	}
+}
+
+func TestBloomFilterMerge(t *testing.T) {
+	handlers.SetUpCaches(8, 16)
+
+	tests := []struct {
+		name        string
+		command     string
+		args        []string
+		expectError bool
+		expectedErr string
+		expectedVal string
+	}{
+		{name: "BF_CREATE a", command: "BF_CREATE", args: []string{"a", "0.01", "100"}, expectedVal: ">> SUCCESS"},
+		{name: "BF_CREATE b", command: "BF_CREATE", args: []string{"b", "0.01", "100"}, expectedVal: ">> SUCCESS"},
+		{name: "BF_CREATE small", command: "BF_CREATE", args: []string{"small", "0.01", "10"}, expectedVal: ">> SUCCESS"},
+		{name: "BF_CREATE scalable", command: "BF_CREATE", args: []string{"scalable", "0.01", "100", "T"}, expectedVal: ">> SUCCESS"},
+		{name: "BF_MADD a", command: "BF_MADD", args: []string{"a", "x", "y"}, expectedVal: ">>\n1) true\n2) true"},
+		{name: "BF_MADD b", command: "BF_MADD", args: []string{"b", "z"}, expectedVal: ">>\n1) true"},
+		{name: "BF_MERGE new destination", command: "BF_MERGE", args: []string{"ab", "a", "b"}, expectedVal: ">> SUCCESS"},
+		{name: "BF_MEXISTS merged", command: "BF_MEXISTS", args: []string{"ab", "x", "y", "z", "w"}, expectedVal: ">>\n1) true\n2) true\n3) true\n4) false"},
+		{name: "BF_MERGE into existing", command: "BF_MERGE", args: []string{"a", "b"}, expectedVal: ">> SUCCESS"},
+		{name: "BF_EXISTS existing merged", command: "BF_EXISTS", args: []string{"a", "z"}, expectedVal: ">> true"},
+		{name: "BF_MERGE incompatible", command: "BF_MERGE", args: []string{"a", "small"}, expectError: true, expectedErr: "BF_MERGE a : Bloom filters should have the same size and number of hash functions to be merged !!!"},
+		{name: "BF_MERGE scalable", command: "BF_MERGE", args: []string{"a", "scalable"}, expectError: true, expectedErr: "BF_MERGE scalable : Only non scalable bloom filters can be merged"},
+		{name: "BF_MERGE missing source", command: "BF_MERGE", args: []string{"a", "missing"}, expectError: true, expectedErr: "BF_MERGE : Wrong name of the bloom filter missing"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			val, err := handlers.CommandHandler(test.command, test.args)
+
+			if test.expectError {
+				if err == nil {
+					t.Errorf("Expected error but got none")
+				} else if err.Error() != test.expectedErr {
+					t.Errorf("Expected error: %v, but got: %v", test.expectedErr, err.Error())
+				}
+			} else {
+				if err != nil {
+					t.Errorf("Did not expect an error but got: %v", err)
+				}
+				if val != test.expectedVal {
+					t.Errorf("Expected value %q, but got %q", test.expectedVal, val)
+				}
+			}
+		})
+	}
+}
+
+func TestBloomFilterInCaches(t *testing.T) {
+	handlers.SetUpCaches(8, 16)
+
+	handlers.CommandHandler("BF_CREATE", []string{"users", "0.01", "100"})
+	handlers.CommandHandler("BF_ADD", []string{"users", "alice"})
+	handlers.CommandHandler("SET", []string{"name", "milan"})
+
+	// Filters are per cache
+	handlers.CommandHandler("NUM", []string{"1"})
+
+	if _, err := handlers.CommandHandler("BF_EXISTS", []string{"users", "alice"}); err == nil || err.Error() != "BF_EXISTS : Wrong name of the bloom filter" {
+		t.Errorf("Expected filter to be missing in another cache, got %v", err)
+	}
+
+	handlers.CommandHandler("NUM", []string{"0"})
+
+	if val, _ := handlers.CommandHandler("BF_EXISTS", []string{"users", "alice"}); val != ">> true" {
+		t.Errorf("Expected alice to exist, got %q", val)
+	}
+
+	if _, err := handlers.CommandHandler("BF_ADD", []string{"name", "alice"}); err == nil || err.Error() != "BF_ADD name : Key holds the wrong kind of value !!!" {
+		t.Errorf("Expected wrong type error, got %v", err)
+	}
+
+	if _, err := handlers.CommandHandler("GET", []string{"users"}); err == nil || err.Error() != "GET users : Key holds the wrong kind of value !!!" {
+		t.Errorf("Expected wrong type error, got %v", err)
+	}
+
+	// Snapshots
+	var buf bytes.Buffer
+
+	if err := gob.NewEncoder(&buf).Encode(handlers.CurrentCache.Data); err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := make(map[string]handlers.CacheItem)
+	if err := gob.NewDecoder(&buf).Decode(&decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if !decoded["users"].Bloom.DoesExist("alice") || decoded["users"].Bloom.DoesExist("bob") {
+		t.Error("Expected bloom filter to survive gob encoding")
+	}
+
+	// Transactions
+	_, err := handlers.CommitHandler([]handlers.Statement{
+		{Command: "BF_ADD", Args: []string{"users", "bob"}},
+		{Command: "BF_CREATE", Args: []string{"emails"}},
+		{Command: "GET", Args: []string{"missing"}}, // fails -> rollback
+	})
+
+	if err == nil {
+		t.Fatal("Expected commit to fail")
+	}
+
+	if val, _ := handlers.CommandHandler("BF_EXISTS", []string{"users", "bob"}); val != ">> false" {
+		t.Errorf("Expected BF_ADD to be rolled back, got %q", val)
+	}
+
+	if val, _ := handlers.CommandHandler("BF_LIST", []string{}); val != ">>\n1) users" {
+		t.Errorf("Expected BF_CREATE to be rolled back, got %q", val)
+	}
+
+	// TTL
+	if val, err := handlers.CommandHandler("EXPIRE", []string{"users", "100"}); err != nil || val != ">> SUCCESS" {
+		t.Errorf("Expected EXPIRE to work on bloom filters, got %q %v", val, err)
+	}
+
+	if !handlers.CurrentCache.Data["users"].CanExpire {
+		t.Error("Expected bloom filter to be able to expire")
+	}
+
+	// DEL
+	if _, err := handlers.CommandHandler("DEL", []string{"users"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if val, _ := handlers.CommandHandler("BF_LIST", []string{}); val != ">>\n(empty list)" {
+		t.Errorf("Expected bloom filter to be deleted, got %q", val)
+	}
+}
@@ -0,0 +1,200 @@
+package tests
+
+import (
+	"prac/handlers"
+	"testing"
+)
+
+func TestCacheManagementHandler(t *testing.T) {
+	handlers.SetUpCaches(8, 16)
+
+	handlers.CommandHandler("SET", []string{"a", "1", "100"})
+	handlers.CommandHandler("SET", []string{"b", "2"})
+	handlers.CommandHandler("SET", []string{"c", "3"})
+	handlers.CommandHandler("LPUSH", []string{"queue", "x"})
+
+	tests := []struct {
+		name        string
+		command     string
+		args        []string
+		expectError bool
+		expectedErr string
+		expectedVal string
+	}{
+		{name: "DBSIZE", command: "DBSIZE", args: []string{}, expectedVal: ">> 4"},
+		{name: "RENAME", command: "RENAME", args: []string{"a", "renamed"}, expectedVal: ">> SUCCESS"},
+		{name: "RENAME keeps ttl", command: "TTL", args: []string{"renamed"}, expectedVal: ">> 100"},
+		{name: "RENAME removes old key", command: "EXISTS", args: []string{"a"}, expectedVal: ">> 0"},
+		{name: "RENAME overwrites", command: "RENAME", args: []string{"b", "c"}, expectedVal: ">> SUCCESS"},
+		{name: "RENAME overwritten value", command: "GET", args: []string{"c"}, expectedVal: ">> 2"},
+		{name: "RENAME missing key", command: "RENAME", args: []string{"missing", "x"}, expectError: true, expectedErr: "RENAME missing : Key doesn't exist !!!"},
+		{name: "RENAMENX existing", command: "RENAMENX", args: []string{"c", "renamed"}, expectedVal: ">> 0"},
+		{name: "RENAMENX", command: "RENAMENX", args: []string{"c", "d"}, expectedVal: ">> 1"},
+		{name: "COPY", command: "COPY", args: []string{"renamed", "copied"}, expectedVal: ">> 1"},
+		{name: "COPY keeps ttl", command: "TTL", args: []string{"copied"}, expectedVal: ">> 100"},
+		{name: "COPY existing", command: "COPY", args: []string{"d", "copied"}, expectedVal: ">> 0"},
+		{name: "COPY replace", command: "COPY", args: []string{"d", "copied", "REPLACE"}, expectedVal: ">> 1"},
+		{name: "COPY replaced value", command: "GET", args: []string{"copied"}, expectedVal: ">> 2"},
+		{name: "COPY replace clears ttl", command: "TTL", args: []string{"copied"}, expectedVal: ">> -1"},
+		{name: "COPY to other cache", command: "COPY", args: []string{"queue", "queue", "DB", "1"}, expectedVal: ">> 1"},
+		{name: "COPY same key", command: "COPY", args: []string{"d", "d"}, expectError: true, expectedErr: "COPY d : Source and destination should be different"},
+		{name: "COPY invalid index", command: "COPY", args: []string{"d", "e", "DB", "8"}, expectError: true, expectedErr: "COPY : Cache index should lie in the range of [0, 7]"},
+		{name: "MOVE", command: "MOVE", args: []string{"renamed", "2"}, expectedVal: ">> 1"},
+		{name: "MOVE missing key", command: "MOVE", args: []string{"renamed", "2"}, expectedVal: ">> 0"},
+		{name: "MOVE existing in destination", command: "MOVE", args: []string{"queue", "1"}, expectedVal: ">> 0"},
+		{name: "MOVE same cache", command: "MOVE", args: []string{"d", "0"}, expectError: true, expectedErr: "MOVE d : Source and destination caches should be different"},
+		{name: "DBSIZE after changes", command: "DBSIZE", args: []string{}, expectedVal: ">> 3"},
+		{name: "FLUSHDB invalid option", command: "FLUSHDB", args: []string{"NOW"}, expectError: true, expectedErr: "FLUSHDB : Unknown option NOW"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			val, err := handlers.CommandHandler(test.command, test.args)
+
+			if test.expectError {
+				if err == nil {
+					t.Errorf("Expected error but got none")
+				} else if err.Error() != test.expectedErr {
+					t.Errorf("Expected error: %v, but got: %v", test.expectedErr, err.Error())
+				}
+			} else {
+				if err != nil {
+					t.Errorf("Did not expect an error but got: %v", err)
+				}
+				if val != test.expectedVal {
+					t.Errorf("Expected value %q, but got %q", test.expectedVal, val)
+				}
+			}
+		})
+	}
+
+	// Moved key keeps its ttl entry in the destination's skiplist only
+	moved := handlers.Caches[2].Data["renamed"]
+	if !moved.CanExpire || !handlers.Caches[2].SkipList.Search("renamed", moved.TTL) {
+		t.Error("Expected the ttl entry to be moved to the destination skiplist")
+	}
+
+	if handlers.Caches[0].SkipList.Search("renamed", moved.TTL) {
+		t.Error("Expected the ttl entry to be removed from the source skiplist")
+	}
+}
+
+func TestSwapAndFlush(t *testing.T) {
+	handlers.SetUpCaches(8, 16)
+
+	handlers.CommandHandler("SET", []string{"first", "0", "100"})
+	handlers.SetCurrentCacheHandler([]string{"1"})
+	handlers.CommandHandler("SET", []string{"second", "1"})
+	handlers.SetCurrentCacheHandler([]string{"0"})
+
+	if val, err := handlers.CommandHandler("SWAPDB", []string{"0", "1"}); err != nil || val != ">> SUCCESS" {
+		t.Fatalf("SWAPDB failed: %v %v", val, err)
+	}
+
+	if val, _ := handlers.CommandHandler("GET", []string{"second"}); val != ">> 1" {
+		t.Errorf("Expected cache 0 to have the data of cache 1, got %q", val)
+	}
+
+	if !handlers.Caches[1].SkipList.Search("first", handlers.Caches[1].Data["first"].TTL) {
+		t.Error("Expected the skiplist to be swapped along with the data")
+	}
+
+	if _, err := handlers.CommandHandler("SWAPDB", []string{"0", "16"}); err == nil {
+		t.Error("Expected SWAPDB with an invalid index to fail")
+	}
+
+	// Flushing swaps in a new map, one taken before the flush is left as it was
+	before := handlers.CurrentCache.Data
+	size := len(before)
+
+	handlers.CommandHandler("FLUSHDB", []string{"SYNC"})
+
+	if val, _ := handlers.CommandHandler("DBSIZE", []string{}); val != ">> 0" {
+		t.Errorf("Expected FLUSHDB to empty the current cache, got %q", val)
+	}
+
+	if size == 0 || len(before) != size {
+		t.Errorf("Expected the flushed map to keep its %v keys, got %v", size, len(before))
+	}
+
+	if len(handlers.Caches[1].Data) != 1 {
+		t.Error("Expected FLUSHDB to leave the other caches alone")
+	}
+
+	handlers.CommandHandler("FLUSHALL", []string{"ASYNC"})
+
+	if len(handlers.Caches[1].Data) != 0 || handlers.Caches[1].SkipList.NumOfElements != 0 {
+		t.Error("Expected FLUSHALL to empty every cache along with its skiplist")
+	}
+}
+
+func TestRenameInTransaction(t *testing.T) {
+	handlers.SetUpCaches(8, 16)
+
+	handlers.CommandHandler("SET", []string{"src", "1"})
+	handlers.CommandHandler("SET", []string{"dest", "2"})
+
+	_, err := handlers.CommitHandler([]handlers.Statement{
+		{Command: "RENAME", Args: []string{"src", "dest"}},
+		{Command: "GET", Args: []string{"missing"}},
+	})
+
+	if err == nil {
+		t.Fatal("Expected the transaction to fail")
+	}
+
+	if val, _ := handlers.CommandHandler("GET", []string{"src"}); val != ">> 1" {
+		t.Errorf("Expected src to be restored, got %q", val)
+	}
+
+	if val, _ := handlers.CommandHandler("GET", []string{"dest"}); val != ">> 2" {
+		t.Errorf("Expected dest to be restored, got %q", val)
+	}
+}
+
+func TestOtherCachesInTransaction(t *testing.T) {
+	handlers.SetUpCaches(8, 16)
+
+	handlers.CommandHandler("SET", []string{"moved", "1"})
+	handlers.CommandHandler("SET", []string{"copied", "2"})
+	handlers.SetCurrentCacheHandler([]string{"2"})
+	handlers.CommandHandler("SET", []string{"copied", "old"})
+	handlers.SetCurrentCacheHandler([]string{"0"})
+
+	_, err := handlers.CommitHandler([]handlers.Statement{
+		{Command: "MOVE", Args: []string{"moved", "1"}},
+		{Command: "COPY", Args: []string{"copied", "copied", "DB", "2", "REPLACE"}},
+		{Command: "GET", Args: []string{"missing"}},
+	})
+
+	if err == nil {
+		t.Fatal("Expected the transaction to fail")
+	}
+
+	if val, _ := handlers.CommandHandler("GET", []string{"moved"}); val != ">> 1" {
+		t.Errorf("Expected the moved key to be restored, got %q", val)
+	}
+
+	if _, exists := handlers.Caches[1].Data["moved"]; exists {
+		t.Error("Expected the moved key to be removed from the destination cache")
+	}
+
+	if val := handlers.Caches[2].Data["copied"].Val; val != "old" {
+		t.Errorf("Expected the copy in the destination cache to be rolled back, got %q", val)
+	}
+
+	for _, command := range []string{"SWAPDB", "FLUSHDB", "FLUSHALL"} {
+		_, err := handlers.CommitHandler([]handlers.Statement{
+			{Command: "SET", Args: []string{"moved", "changed"}},
+			{Command: command, Args: []string{"0", "1"}},
+		})
+
+		if err == nil || err.Error() != command+" : Can't be used in a transaction as it can't be rolled back !!!" {
+			t.Errorf("Expected %v to be rejected, got %v", command, err)
+		}
+	}
+
+	if val, _ := handlers.CommandHandler("GET", []string{"moved"}); val != ">> 1" {
+		t.Errorf("Expected rejected transactions not to run, got %q", val)
+	}
+}
@@ -0,0 +1,200 @@
+package tests
+
+import (
+	"fmt"
+	"math"
+	"prac/handlers"
+	"prac/utils"
+	"testing"
+)
+
+func TestCuckooFilter(t *testing.T) {
+	cf, err := utils.CreateCuckooFilter(10000, 4, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 10000; i++ {
+		if err := cf.Set(fmt.Sprintf("item-%v", i)); err != nil {
+			t.Fatalf("Couldn't add item-%v : %v", i, err)
+		}
+	}
+
+	for i := 0; i < 10000; i++ {
+		if !cf.DoesExist(fmt.Sprintf("item-%v", i)) {
+			t.Fatalf("Expected item-%v to exist", i)
+		}
+	}
+
+	falsePositives := 0
+	for i := 0; i < 10000; i++ {
+		if cf.DoesExist(fmt.Sprintf("other-%v", i)) {
+			falsePositives++
+		}
+	}
+
+	// 8 candidate fingerprints of 16 bits -> about 0.012%
+	if falsePositives > 10 {
+		t.Errorf("Expected very few false positives, got %v", falsePositives)
+	}
+
+	for i := 0; i < 10000; i += 2 {
+		if !cf.Delete(fmt.Sprintf("item-%v", i)) {
+			t.Fatalf("Couldn't delete item-%v", i)
+		}
+	}
+
+	for i := 1; i < 10000; i += 2 {
+		if !cf.DoesExist(fmt.Sprintf("item-%v", i)) {
+			t.Fatalf("Expected item-%v to exist after deleting the others", i)
+		}
+	}
+
+	if cf.NumItems != 5000 {
+		t.Errorf("Expected 5000 items, got %v", cf.NumItems)
+	}
+}
+
+// False positive rate should follow the fingerprint width, including widths which don't divide 64
+func TestCuckooFilterFingerprintBits(t *testing.T) {
+	for _, fingerprintBits := range []uint8{7, 8, 12} {
+		cf, err := utils.CreateCuckooFilter(10000, 4, fingerprintBits)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if slots := cf.NumBuckets * cf.BucketSize; uint(len(cf.Slots)) != (slots*uint(fingerprintBits)+63)/64 {
+			t.Errorf("Expected %v slots of %v bits to be packed, got %v words", slots, fingerprintBits, len(cf.Slots))
+		}
+
+		for i := 0; i < 10000; i++ {
+			cf.Set(fmt.Sprintf("item-%v", i))
+		}
+
+		for i := 0; i < 10000; i++ {
+			if !cf.DoesExist(fmt.Sprintf("item-%v", i)) {
+				t.Fatalf("Expected item-%v to exist with %v bit fingerprints", i, fingerprintBits)
+			}
+		}
+
+		lookups := 100000
+		falsePositives := 0
+
+		for i := 0; i < lookups; i++ {
+			if cf.DoesExist(fmt.Sprintf("other-%v", i)) {
+				falsePositives++
+			}
+		}
+
+		// Each lookup compares against the fingerprints in 2 buckets
+		load := float64(cf.NumItems) / float64(cf.NumBuckets*cf.BucketSize)
+		expected := 2 * float64(cf.BucketSize) * load / float64(uint(1)<<fingerprintBits-1) * float64(lookups)
+
+		if float64(falsePositives) < expected/2 || float64(falsePositives) > expected*3/2 {
+			t.Errorf("Expected about %.0f false positives with %v bit fingerprints, got %v", expected, fingerprintBits, falsePositives)
+		}
+
+		for i := 0; i < 10000; i++ {
+			cf.Delete(fmt.Sprintf("item-%v", i))
+		}
+
+		if cf.NumItems != 0 {
+			t.Errorf("Expected the filter to be empty, got %v items", cf.NumItems)
+		}
+	}
+}
+
+func TestCuckooFilterSizeLimit(t *testing.T) {
+	for _, capacity := range []uint{math.MaxUint, math.MaxUint32, utils.CuckooMaxWords * 64} {
+		if _, err := utils.CreateCuckooFilter(capacity, 1, 8); err == nil {
+			t.Errorf("Expected a filter of capacity %v to be too big", capacity)
+		}
+	}
+
+	if _, err := utils.CreateCuckooFilter(utils.CuckooMaxWords*3, 4, 16); err != nil {
+		t.Errorf("Expected a filter right at the limit to be created, got %v", err)
+	}
+}
+
+func TestCuckooFilterFull(t *testing.T) {
+	cf, err := utils.CreateCuckooFilter(8, 2, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	added := []string{}
+
+	for i := 0; i < 100; i++ {
+		item := fmt.Sprintf("item-%v", i)
+
+		if err := cf.Set(item); err != nil {
+			break
+		}
+
+		added = append(added, item)
+	}
+
+	if len(added) == 100 {
+		t.Fatal("Expected the filter to get full")
+	}
+
+	// A failed insert shouldn't lose the fingerprints that were moved around
+	for _, item := range added {
+		if !cf.DoesExist(item) {
+			t.Errorf("Expected %v to exist after the filter got full", item)
+		}
+	}
+}
+
+func TestCuckooFilterHandler(t *testing.T) {
+	handlers.CuckooFilterMap = make(map[string]utils.DeletableFilter)
+
+	tests := []struct {
+		name        string
+		command     string
+		args        []string
+		expectError bool
+		expectedErr string
+		expectedVal string
+	}{
+		{name: "CF_CREATE", command: "CF_CREATE", args: []string{"subscribers", "1000", "4", "16"}, expectedVal: ">> SUCCESS"},
+		{name: "CF_CREATE existing", command: "CF_CREATE", args: []string{"subscribers"}, expectError: true, expectedErr: "CF_CREATE subscribers : Cuckoo filter already exists !!!"},
+		{name: "CF_CREATE invalid", command: "CF_CREATE", args: []string{"bad", "1000", "4", "40"}, expectError: true, expectedErr: "CF_CREATE bad : Fingerprint bits should lie in the range of [1, 32] !!!"},
+		{name: "CF_CREATE too big", command: "CF_CREATE", args: []string{"huge", "4294967295", "4", "32"}, expectError: true, expectedErr: "CF_CREATE huge : Cuckoo filter can't be bigger than 134217728 bytes !!!"},
+		{name: "CF_CREATE big buckets", command: "CF_CREATE", args: []string{"huge", "1", "255", "32"}, expectedVal: ">> SUCCESS"},
+		{name: "CF_ADD", command: "CF_ADD", args: []string{"subscribers", "alice"}, expectedVal: ">> SUCCESS"},
+		{name: "CF_ADD duplicate", command: "CF_ADD", args: []string{"subscribers", "alice"}, expectedVal: ">> SUCCESS"},
+		{name: "CF_ADDNX existing", command: "CF_ADDNX", args: []string{"subscribers", "alice"}, expectedVal: ">> false"},
+		{name: "CF_ADDNX", command: "CF_ADDNX", args: []string{"subscribers", "bob"}, expectedVal: ">> true"},
+		{name: "CF_COUNT", command: "CF_COUNT", args: []string{"subscribers", "alice"}, expectedVal: ">> 2"},
+		{name: "CF_DEL", command: "CF_DEL", args: []string{"subscribers", "alice"}, expectedVal: ">> true"},
+		{name: "CF_EXISTS after one delete", command: "CF_EXISTS", args: []string{"subscribers", "alice"}, expectedVal: ">> true"},
+		{name: "CF_DEL again", command: "CF_DEL", args: []string{"subscribers", "alice"}, expectedVal: ">> true"},
+		{name: "CF_EXISTS deleted", command: "CF_EXISTS", args: []string{"subscribers", "alice"}, expectedVal: ">> false"},
+		{name: "CF_DEL missing item", command: "CF_DEL", args: []string{"subscribers", "alice"}, expectedVal: ">> false"},
+		{name: "CF_EXISTS other", command: "CF_EXISTS", args: []string{"subscribers", "bob"}, expectedVal: ">> true"},
+		{name: "CF_ADD missing value", command: "CF_ADD", args: []string{"subscribers"}, expectError: true, expectedErr: "CF_ADD : Missing Value"},
+		{name: "CF_ADD missing filter", command: "CF_ADD", args: []string{"missing", "alice"}, expectError: true, expectedErr: "CF_ADD : Wrong name of the cuckoo filter"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			val, err := handlers.CommandHandler(test.command, test.args)
+
+			if test.expectError {
+				if err == nil {
+					t.Errorf("Expected error but got none")
+				} else if err.Error() != test.expectedErr {
+					t.Errorf("Expected error: %v, but got: %v", test.expectedErr, err.Error())
+				}
+			} else {
+				if err != nil {
+					t.Errorf("Did not expect an error but got: %v", err)
+				}
+				if val != test.expectedVal {
+					t.Errorf("Expected value %q, but got %q", test.expectedVal, val)
+				}
+			}
+		})
+	}
+}
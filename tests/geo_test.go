@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"math"
+	"prac/handlers"
+	"prac/utils"
+	"strings"
+	"testing"
+)
+
+func TestGeoEncodeDecode(t *testing.T) {
+	hash, err := utils.GeoEncode(13.361389, 38.115556)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lon, lat := utils.GeoDecode(hash)
+
+	if math.Abs(lon-13.361389) > 0.00001 || math.Abs(lat-38.115556) > 0.00001 {
+		t.Errorf("Decoded position %v, %v is too far from the original", lon, lat)
+	}
+
+	if geohash := utils.GeoHashString(lon, lat); !strings.HasPrefix(geohash, "sqc8b49rny") {
+		t.Errorf("Expected geohash sqc8b49rny.., got %v", geohash)
+	}
+
+	if _, err = utils.GeoEncode(10, 86); err == nil {
+		t.Error("Expected error for latitude out of range")
+	}
+}
+
+func TestGeoHandler(t *testing.T) {
+	handlers.SetUpCaches(8, 16)
+
+	tests := []struct {
+		name        string
+		command     string
+		args        []string
+		expectError bool
+		expectedErr string
+		expectedVal string
+	}{
+		{name: "GEOADD", command: "GEOADD", args: []string{"Sicily", "13.361389", "38.115556", "Palermo", "15.087269", "37.502669", "Catania"}, expectedVal: ">> 2"},
+		{name: "GEOADD NX", command: "GEOADD", args: []string{"Sicily", "NX", "0", "0", "Palermo"}, expectedVal: ">> 0"},
+		{name: "GEOADD invalid", command: "GEOADD", args: []string{"Sicily", "13.3", "89", "Pole"}, expectError: true, expectedErr: "GEOADD Sicily : Invalid longitude,latitude pair 13.3,89"},
+		{name: "GEODIST", command: "GEODIST", args: []string{"Sicily", "Palermo", "Catania"}, expectedVal: ">> 166274.1516"},
+		{name: "GEODIST km", command: "GEODIST", args: []string{"Sicily", "Palermo", "Catania", "km"}, expectedVal: ">> 166.2742"},
+		{name: "GEODIST missing member", command: "GEODIST", args: []string{"Sicily", "Palermo", "Rome"}, expectedVal: ">>\n(nil)"},
+		{name: "GEOPOS", command: "GEOPOS", args: []string{"Sicily", "Palermo", "Rome"}, expectedVal: ">>\n1) 13.361389, 38.115556\n2) (nil)"},
+		{
+			name:        "GEOSEARCH by radius",
+			command:     "GEOSEARCH",
+			args:        []string{"Sicily", "FROMLONLAT", "15", "37", "BYRADIUS", "200", "km", "ASC", "WITHDIST"},
+			expectedVal: ">>\n1) Catania : 56.4413\n2) Palermo : 190.4424",
+		},
+		{
+			name:        "GEOSEARCH small radius",
+			command:     "GEOSEARCH",
+			args:        []string{"Sicily", "FROMLONLAT", "15", "37", "BYRADIUS", "100", "km"},
+			expectedVal: ">>\n1) Catania",
+		},
+		{
+			name:        "GEOSEARCH by box",
+			command:     "GEOSEARCH",
+			args:        []string{"Sicily", "FROMMEMBER", "Palermo", "BYBOX", "400", "400", "km", "DESC", "COUNT", "1"},
+			expectedVal: ">>\n1) Catania",
+		},
+		{
+			name:        "GEOSEARCH missing shape",
+			command:     "GEOSEARCH",
+			args:        []string{"Sicily", "FROMMEMBER", "Palermo"},
+			expectError: true,
+			expectedErr: "GEOSEARCH Sicily : Both FROMMEMBER|FROMLONLAT and BYRADIUS|BYBOX are required",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			val, err := handlers.GeoHandler(test.command, test.args)
+
+			if test.expectError {
+				if err == nil {
+					t.Errorf("Expected error but got none")
+				} else if err.Error() != test.expectedErr {
+					t.Errorf("Expected error: %v, but got: %v", test.expectedErr, err.Error())
+				}
+			} else {
+				if err != nil {
+					t.Errorf("Did not expect an error but got: %v", err)
+				}
+				if val != test.expectedVal {
+					t.Errorf("Expected value %q, but got %q", test.expectedVal, val)
+				}
+			}
+		})
+	}
+}
+
+func TestScoreSkipListRange(t *testing.T) {
+	skipList := utils.CreateScoreSkipList(16)
+
+	for i, key := range []string{"a", "b", "c", "d", "e"} {
+		skipList.Insert(key, (i+1)*10)
+	}
+
+	result := skipList.RangeByScore(15, 40)
+
+	if len(result) != 3 || result[0].Key != "b" || result[2].Key != "d" {
+		t.Errorf("Expected b, c and d in range, got %v", result)
+	}
+
+	if result = skipList.RangeByScore(60, 100); len(result) != 0 {
+		t.Errorf("Expected empty range, got %v", result)
+	}
+}
@@ -91,7 +91,7 @@ func TestSetHandler(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			err := handlers.SetHandler(test.input)
+			_, err := handlers.SetHandler(test.input)
 
 			if test.expectError {
 				if err == nil {
@@ -168,3 +168,36 @@ func TestGetHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestExpireHandler(t *testing.T) {
+	handlers.SetUpCaches(8, 16)
+	handlers.HashHandler("HSET", []string{"session", "user", "1"})
+
+	if val, _ := handlers.ExpireHandler("TTL", []string{"session"}); val != ">> -1" {
+		t.Errorf("Expected -1 for key without expiry, got %v", val)
+	}
+
+	if _, err := handlers.ExpireHandler("EXPIRE", []string{"session", "100"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if val, _ := handlers.ExpireHandler("TTL", []string{"session"}); val != ">> 100" && val != ">> 99" {
+		t.Errorf("Expected ttl of 100 seconds, got %v", val)
+	}
+
+	if handlers.CurrentCache.SkipList.NumOfElements != 1 {
+		t.Errorf("Expected key in ttl skiplist")
+	}
+
+	if _, err := handlers.ExpireHandler("PERSIST", []string{"session"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if handlers.CurrentCache.SkipList.NumOfElements != 0 {
+		t.Errorf("Expected key to be removed from ttl skiplist")
+	}
+
+	if val, _ := handlers.ExpireHandler("TTL", []string{"missing"}); val != ">> -2" {
+		t.Errorf("Expected -2 for missing key, got %v", val)
+	}
+}
@@ -0,0 +1,133 @@
+package tests
+
+import (
+	"fmt"
+	"prac/handlers"
+	"strings"
+	"testing"
+)
+
+func TestHashHandler(t *testing.T) {
+	handlers.SetUpCaches(8, 16)
+	handlers.CurrentCache.Data["str"] = handlers.CacheItem{Val: "value"}
+
+	tests := []struct {
+		name        string
+		command     string
+		args        []string
+		expectError bool
+		expectedErr string
+		expectedVal string
+	}{
+		{name: "HSET new fields", command: "HSET", args: []string{"user", "name", "milan", "age", "20"}, expectedVal: ">> 2"},
+		{name: "HSET existing field", command: "HSET", args: []string{"user", "age", "21", "city", "pune"}, expectedVal: ">> 1"},
+		{name: "HSET odd arguments", command: "HSET", args: []string{"user", "name"}, expectError: true, expectedErr: "HSET user : Fields and values should be given in pairs"},
+		{name: "HGET", command: "HGET", args: []string{"user", "age"}, expectedVal: ">> 21"},
+		{name: "HGET missing field", command: "HGET", args: []string{"user", "email"}, expectedVal: ">>\n(nil)"},
+		{name: "HMGET", command: "HMGET", args: []string{"user", "name", "email"}, expectedVal: ">>\n1) milan\n2) (nil)"},
+		{name: "HGETALL", command: "HGETALL", args: []string{"user"}, expectedVal: ">>\n1) age : 21\n2) city : pune\n3) name : milan"},
+		{name: "HKEYS", command: "HKEYS", args: []string{"user"}, expectedVal: ">>\n1) age\n2) city\n3) name"},
+		{name: "HVALS", command: "HVALS", args: []string{"user"}, expectedVal: ">>\n1) 21\n2) pune\n3) milan"},
+		{name: "HINCRBY", command: "HINCRBY", args: []string{"user", "age", "-5"}, expectedVal: ">> 16"},
+		{name: "HINCRBY new field", command: "HINCRBY", args: []string{"user", "visits", "1"}, expectedVal: ">> 1"},
+		{name: "HINCRBY not integer", command: "HINCRBY", args: []string{"user", "name", "1"}, expectError: true, expectedErr: "HINCRBY user : Value of field name is not an integer"},
+		{name: "HEXISTS", command: "HEXISTS", args: []string{"user", "city"}, expectedVal: ">> true"},
+		{name: "HDEL", command: "HDEL", args: []string{"user", "city", "visits", "email"}, expectedVal: ">> 2"},
+		{name: "HLEN", command: "HLEN", args: []string{"user"}, expectedVal: ">> 2"},
+		{name: "Wrong type", command: "HGET", args: []string{"str", "a"}, expectError: true, expectedErr: "HGET str : Key holds the wrong kind of value !!!"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			val, err := handlers.HashHandler(test.command, test.args)
+
+			if test.expectError {
+				if err == nil {
+					t.Errorf("Expected error but got none")
+				} else if err.Error() != test.expectedErr {
+					t.Errorf("Expected error: %v, but got: %v", test.expectedErr, err.Error())
+				}
+			} else {
+				if err != nil {
+					t.Errorf("Did not expect an error but got: %v", err)
+				}
+				if val != test.expectedVal {
+					t.Errorf("Expected value %q, but got %q", test.expectedVal, val)
+				}
+			}
+		})
+	}
+
+	handlers.HashHandler("HDEL", []string{"user", "name", "age"})
+
+	if _, exists := handlers.CurrentCache.Data["user"]; exists {
+		t.Error("Expected empty hash to be deleted")
+	}
+}
+
+func TestHashScan(t *testing.T) {
+	handlers.SetUpCaches(8, 16)
+
+	args := []string{"big"}
+	for i := 0; i < 25; i++ {
+		args = append(args, fmt.Sprintf("field%v", i), "v")
+	}
+	handlers.HashHandler("HSET", args)
+
+	seen := make(map[string]int)
+	cursor := "0"
+
+	for {
+		val, err := handlers.HashHandler("HSCAN", []string{"big", cursor, "COUNT", "7"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		lines := strings.Split(strings.TrimPrefix(val, ">> "), "\n")
+		cursor = lines[0]
+
+		for _, line := range lines[1:] {
+			if line != "(empty list)" {
+				seen[strings.SplitN(line, ") ", 2)[1]]++
+			}
+		}
+
+		if cursor == "0" {
+			break
+		}
+	}
+
+	if len(seen) != 25 {
+		t.Errorf("Expected 25 fields from HSCAN, got %v", len(seen))
+	}
+
+	for field, count := range seen {
+		if count != 1 {
+			t.Errorf("Field %v returned %v times", field, count)
+		}
+	}
+}
+
+func TestHashTransactionRollback(t *testing.T) {
+	handlers.SetUpCaches(8, 16)
+	handlers.HashHandler("HSET", []string{"profile", "name", "milan"})
+
+	_, err := handlers.CommitHandler([]handlers.Statement{
+		{Command: "HSET", Args: []string{"profile", "name", "changed"}},
+		{Command: "HSET", Args: []string{"counter", "hits", "1"}},
+		{Command: "DEL", Args: []string{"profile"}},
+		{Command: "LPUSH", Args: []string{"counter", "x"}}, // wrong type -> rollback
+	})
+
+	if err == nil {
+		t.Fatal("Expected commit to fail")
+	}
+
+	if val, _ := handlers.HashHandler("HGET", []string{"profile", "name"}); val != ">> milan" {
+		t.Errorf("Expected profile to be rolled back, got %q", val)
+	}
+
+	if _, exists := handlers.CurrentCache.Data["counter"]; exists {
+		t.Error("Expected counter to be removed by rollback")
+	}
+}
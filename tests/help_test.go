@@ -0,0 +1,78 @@
+package tests
+
+import (
+	"prac/handlers"
+	"strings"
+	"testing"
+)
+
+func TestHelpHandler(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		expectError bool
+		expectedErr string
+		expectedVal string
+	}{
+		{name: "HELP command", args: []string{"GET"}, expectedVal: ">> Syntax : GET key\nArity : 2 (exactly 2 parts including the command)\nGroup : string\nDescription : Value of a key"},
+		{name: "HELP lowercase", args: []string{"lpush"}, expectedVal: ">> Syntax : LPUSH key value [value ...]\nArity : -3 (at least 3 parts including the command)\nGroup : list\nDescription : Pushes values to the head of a list"},
+		{name: "HELP unknown command", args: []string{"FOO"}, expectError: true, expectedErr: "HELP FOO : Unknown command !!!"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			val, err := handlers.CommandHandler("HELP", test.args)
+
+			if test.expectError {
+				if err == nil {
+					t.Errorf("Expected error but got none")
+				} else if err.Error() != test.expectedErr {
+					t.Errorf("Expected error: %v, but got: %v", test.expectedErr, err.Error())
+				}
+			} else {
+				if err != nil {
+					t.Errorf("Did not expect an error but got: %v", err)
+				}
+				if val != test.expectedVal {
+					t.Errorf("Expected value %q, but got %q", test.expectedVal, val)
+				}
+			}
+		})
+	}
+}
+
+func TestHelpListsEveryCommand(t *testing.T) {
+	val, err := handlers.CommandHandler("HELP", []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimPrefix(val, ">>\n"), "\n")
+	if len(lines) != len(handlers.Commands) {
+		t.Fatalf("Expected %v commands, got %v", len(handlers.Commands), len(lines))
+	}
+
+	if lines[0] != "1) SET - Sets the value of a key, clearing its ttl unless KEEPTTL is given" {
+		t.Errorf("Unexpected first line %q", lines[0])
+	}
+
+	seen := map[string]bool{}
+
+	for _, info := range handlers.Commands {
+		if seen[info.Name] {
+			t.Errorf("%v is in the command table twice", info.Name)
+		}
+		seen[info.Name] = true
+
+		if !strings.HasPrefix(info.Syntax, info.Name) || info.Arity == 0 || info.Description == "" {
+			t.Errorf("Incomplete entry for %v", info.Name)
+		}
+	}
+
+	// Commands the client requires arguments for should be documented
+	for _, name := range []string{"SET", "GEOSEARCH", "BF_LOADCHUNK", "CMS_MERGE", "TOPK_LIST", "BLMOVE", "HSCAN", "SDIFFSTORE"} {
+		if _, exists := handlers.LookupCommand(name); !exists {
+			t.Errorf("%v is missing from the command table", name)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package tests
+
+import (
+	"fmt"
+	"math"
+	"prac/handlers"
+	"prac/utils"
+	"testing"
+)
+
+func TestHyperLogLogAccuracy(t *testing.T) {
+	for _, n := range []int{10, 1000, 100000} {
+		hll := utils.CreateHyperLogLog()
+
+		for i := 0; i < n; i++ {
+			hll.Add(fmt.Sprintf("item-%v", i))
+			hll.Add(fmt.Sprintf("item-%v", i/2)) // duplicates shouldn't count
+		}
+
+		count := hll.Count()
+		errorRate := math.Abs(float64(count)-float64(n)) / float64(n)
+
+		if errorRate > 0.03 {
+			t.Errorf("Cardinality %v estimated as %v", n, count)
+		}
+
+		if n >= utils.HLLSparseMaxRegisters && hll.IsSparse() {
+			t.Errorf("Expected HyperLogLog of %v items to be dense", n)
+		}
+
+		if n == 10 && !hll.IsSparse() {
+			t.Error("Expected HyperLogLog of 10 items to be sparse")
+		}
+	}
+}
+
+// Sparse counter should switch to dense as soon as it would take as much memory
+func TestHyperLogLogSparseLimit(t *testing.T) {
+	if utils.HLLSparseMaxRegisters*utils.HLLSparseRegisterSize > utils.HLLRegisters {
+		t.Errorf("Sparse counter of %v registers is bigger than the dense one", utils.HLLSparseMaxRegisters)
+	}
+
+	registers := &utils.HyperLogLog{Sparse: make(map[uint16]uint8)}
+	for i := 0; i < utils.HLLSparseMaxRegisters-1; i++ {
+		registers.Sparse[uint16(i)] = 1
+	}
+
+	hll := utils.CreateHyperLogLog()
+	hll.Merge(registers)
+
+	if !hll.IsSparse() {
+		t.Fatalf("Expected %v registers to be kept sparse", len(registers.Sparse))
+	}
+
+	last := uint16(utils.HLLSparseMaxRegisters - 1)
+	hll.Merge(&utils.HyperLogLog{Sparse: map[uint16]uint8{last: 1}})
+
+	if hll.IsSparse() {
+		t.Errorf("Expected %v registers to be dense", utils.HLLSparseMaxRegisters)
+	}
+
+	registers.Sparse[last] = 1
+
+	if count, expected := hll.Clone().Count(), registers.Count(); count != expected {
+		t.Errorf("Expected the dense counter to keep the registers, estimated %v instead of %v", count, expected)
+	}
+}
+
+func TestHyperLogLogMerge(t *testing.T) {
+	a, b := utils.CreateHyperLogLog(), utils.CreateHyperLogLog()
+
+	for i := 0; i < 20000; i++ {
+		a.Add(fmt.Sprintf("a-%v", i))
+	}
+
+	for i := 0; i < 100; i++ {
+		b.Add(fmt.Sprintf("b-%v", i))
+	}
+
+	// dense into sparse
+	b.Merge(a)
+
+	if count := b.Count(); math.Abs(float64(count)-20100)/20100 > 0.03 {
+		t.Errorf("Expected union of about 20100, got %v", count)
+	}
+}
+
+func TestHyperLogLogHandler(t *testing.T) {
+	handlers.SetUpCaches(8, 16)
+	handlers.CurrentCache.Data["str"] = handlers.CacheItem{Val: "value"}
+
+	tests := []struct {
+		name        string
+		command     string
+		args        []string
+		expectError bool
+		expectedErr string
+		expectedVal string
+	}{
+		{name: "PFADD", command: "PFADD", args: []string{"visitors", "a", "b", "c"}, expectedVal: ">> 1"},
+		{name: "PFADD duplicates", command: "PFADD", args: []string{"visitors", "a", "b"}, expectedVal: ">> 0"},
+		{name: "PFADD second", command: "PFADD", args: []string{"other", "c", "d"}, expectedVal: ">> 1"},
+		{name: "PFCOUNT", command: "PFCOUNT", args: []string{"visitors"}, expectedVal: ">> 3"},
+		{name: "PFCOUNT union", command: "PFCOUNT", args: []string{"visitors", "other", "missing"}, expectedVal: ">> 4"},
+		{name: "PFMERGE", command: "PFMERGE", args: []string{"all", "visitors", "other"}, expectedVal: ">> SUCCESS"},
+		{name: "PFCOUNT merged", command: "PFCOUNT", args: []string{"all"}, expectedVal: ">> 4"},
+		{name: "Wrong type", command: "PFCOUNT", args: []string{"all", "str"}, expectError: true, expectedErr: "PFCOUNT str : Key holds the wrong kind of value !!!"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			val, err := handlers.HyperLogLogHandler(test.command, test.args)
+
+			if test.expectError {
+				if err == nil {
+					t.Errorf("Expected error but got none")
+				} else if err.Error() != test.expectedErr {
+					t.Errorf("Expected error: %v, but got: %v", test.expectedErr, err.Error())
+				}
+			} else {
+				if err != nil {
+					t.Errorf("Did not expect an error but got: %v", err)
+				}
+				if val != test.expectedVal {
+					t.Errorf("Expected value %q, but got %q", test.expectedVal, val)
+				}
+			}
+		})
+	}
+}
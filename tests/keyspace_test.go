@@ -0,0 +1,111 @@
+package tests
+
+import (
+	"fmt"
+	"prac/handlers"
+	"strings"
+	"testing"
+)
+
+func TestKeyspaceHandler(t *testing.T) {
+	handlers.SetUpCaches(8, 16)
+
+	handlers.CommandHandler("SET", []string{"user:1", "milan"})
+	handlers.CommandHandler("SET", []string{"user:2", "patel"})
+	handlers.CommandHandler("LPUSH", []string{"queue", "a"})
+	handlers.CommandHandler("HSET", []string{"user:profile", "name", "milan"})
+	handlers.CommandHandler("SADD", []string{"tags", "go"})
+	handlers.CommandHandler("GEOADD", []string{"places", "13.361389", "38.115556", "palermo"})
+	handlers.CommandHandler("PFADD", []string{"visitors", "a"})
+	handlers.CommandHandler("BF_CREATE", []string{"seen"})
+
+	tests := []struct {
+		name        string
+		command     string
+		args        []string
+		expectError bool
+		expectedErr string
+		expectedVal string
+	}{
+		{name: "EXISTS", command: "EXISTS", args: []string{"user:1"}, expectedVal: ">> 1"},
+		{name: "EXISTS multiple", command: "EXISTS", args: []string{"user:1", "missing", "queue", "user:1"}, expectedVal: ">> 3"},
+		{name: "TYPE string", command: "TYPE", args: []string{"user:1"}, expectedVal: ">> string"},
+		{name: "TYPE list", command: "TYPE", args: []string{"queue"}, expectedVal: ">> list"},
+		{name: "TYPE hash", command: "TYPE", args: []string{"user:profile"}, expectedVal: ">> hash"},
+		{name: "TYPE set", command: "TYPE", args: []string{"tags"}, expectedVal: ">> set"},
+		{name: "TYPE zset", command: "TYPE", args: []string{"places"}, expectedVal: ">> zset"},
+		{name: "TYPE hyperloglog", command: "TYPE", args: []string{"visitors"}, expectedVal: ">> hyperloglog"},
+		{name: "TYPE bloomfilter", command: "TYPE", args: []string{"seen"}, expectedVal: ">> bloomfilter"},
+		{name: "TYPE missing", command: "TYPE", args: []string{"missing"}, expectedVal: ">> none"},
+		{name: "KEYS all", command: "KEYS", args: []string{"*"}, expectedVal: ">>\n1) places\n2) queue\n3) seen\n4) tags\n5) user:1\n6) user:2\n7) user:profile\n8) visitors"},
+		{name: "KEYS pattern", command: "KEYS", args: []string{"user:?"}, expectedVal: ">>\n1) user:1\n2) user:2"},
+		{name: "KEYS no match", command: "KEYS", args: []string{"nothing*"}, expectedVal: ">>\n(empty list)"},
+		{name: "SCAN type", command: "SCAN", args: []string{"0", "TYPE", "string", "COUNT", "100"}, expectedVal: ">> 0\n1) user:2\n2) user:1"},
+		{name: "SCAN match and type", command: "SCAN", args: []string{"0", "MATCH", "user:*", "TYPE", "hash", "COUNT", "100"}, expectedVal: ">> 0\n1) user:profile"},
+		{name: "SCAN invalid cursor", command: "SCAN", args: []string{"abc"}, expectError: true, expectedErr: "SCAN : Invalid cursor"},
+		{name: "SCAN unknown type", command: "SCAN", args: []string{"0", "TYPE", "json"}, expectError: true, expectedErr: "SCAN : Unknown type json"},
+		{name: "SCAN missing value", command: "SCAN", args: []string{"0", "COUNT"}, expectError: true, expectedErr: "SCAN : Missing value for COUNT"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			val, err := handlers.KeyspaceHandler(test.command, test.args)
+
+			if test.expectError {
+				if err == nil {
+					t.Errorf("Expected error but got none")
+				} else if err.Error() != test.expectedErr {
+					t.Errorf("Expected error: %v, but got: %v", test.expectedErr, err.Error())
+				}
+			} else {
+				if err != nil {
+					t.Errorf("Did not expect an error but got: %v", err)
+				}
+				if val != test.expectedVal {
+					t.Errorf("Expected value %q, but got %q", test.expectedVal, val)
+				}
+			}
+		})
+	}
+}
+
+func TestScanWithConcurrentChanges(t *testing.T) {
+	handlers.SetUpCaches(8, 16)
+
+	for i := 0; i < 100; i++ {
+		handlers.CommandHandler("SET", []string{fmt.Sprintf("key-%v", i), "val"})
+	}
+
+	seen := make(map[string]int)
+	cursor := "0"
+
+	for round := 0; ; round++ {
+		val, err := handlers.KeyspaceHandler("SCAN", []string{cursor, "COUNT", "7"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		lines := strings.Split(strings.TrimPrefix(val, ">> "), "\n")
+		cursor = lines[0]
+
+		for _, line := range lines[1:] {
+			if _, key, found := strings.Cut(line, ") "); found {
+				seen[key]++
+			}
+		}
+
+		// Keys added and removed while scanning
+		handlers.CommandHandler("SET", []string{fmt.Sprintf("new-%v", round), "val"})
+		handlers.CommandHandler("DEL", []string{fmt.Sprintf("new-%v", round-1)})
+
+		if cursor == "0" {
+			break
+		}
+	}
+
+	for i := 0; i < 100; i++ {
+		if key := fmt.Sprintf("key-%v", i); seen[key] != 1 {
+			t.Errorf("Key %v present for the whole scan was returned %v times", key, seen[key])
+		}
+	}
+}
@@ -0,0 +1,697 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"prac/handlers"
+	"prac/kvclient"
+	"prac/utils"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testServer struct {
+	listener net.Listener
+
+	mu    sync.Mutex
+	conns []net.Conn
+	wg    sync.WaitGroup // connections being served
+}
+
+// Serves connections the same way kv_server.go does
+func startTestServer(t *testing.T) *testServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := &testServer{listener: listener}
+	t.Cleanup(func() {
+		listener.Close()
+		server.closeConnections()
+		server.wg.Wait()
+	})
+
+	go func() {
+		for {
+			c, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			server.mu.Lock()
+			server.conns = append(server.conns, c)
+			server.wg.Add(1)
+			server.mu.Unlock()
+
+			go func() {
+				defer server.wg.Done()
+				defer c.Close()
+
+				reader := bufio.NewReader(c)
+				connObj := handlers.Connection{IP: c.RemoteAddr().String(), Conn: c, Reader: reader}
+				defer handlers.PubSub.UnsubscribeAll(&connObj)
+
+				for {
+					command, args, err := utils.ReadCommand(reader)
+					if errors.Is(err, utils.ErrEmptyCommand) {
+						c.Write([]byte(utils.SerializeOutput("ERR", err.Error())))
+						continue
+					}
+
+					if errors.Is(err, utils.ErrCommandTooLarge) {
+						c.Write([]byte(utils.SerializeOutput("ERR", err.Error())))
+						return
+					}
+
+					if err != nil {
+						return
+					}
+
+					handlers.SwitchCases(command, args, &connObj, c)
+				}
+			}()
+		}
+	}()
+
+	return server
+}
+
+func (server *testServer) closeConnections() {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	for _, c := range server.conns {
+		c.Close()
+	}
+
+	server.conns = nil
+}
+
+func createTestClient(t *testing.T, server *testServer, options kvclient.Options) *kvclient.Client {
+	options.Addr = server.listener.Addr().String()
+
+	client, err := kvclient.CreateClient(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	return client
+}
+
+func TestClientCommands(t *testing.T) {
+	handlers.SetUpCaches(8, 16)
+
+	client := createTestClient(t, startTestServer(t), kvclient.Options{})
+	ctx := context.Background()
+
+	if err := client.Ping(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if ok, err := client.Set(ctx, "name", "milan patel", nil); err != nil || !ok {
+		t.Fatalf("Set failed: %v %v", ok, err)
+	}
+
+	if val, err := client.Get(ctx, "name"); err != nil || val != "milan patel" {
+		t.Errorf("Expected %q, got %q %v", "milan patel", val, err)
+	}
+
+	if _, err := client.Get(ctx, "missing"); !errors.Is(err, kvclient.ErrNil) {
+		t.Errorf("Expected ErrNil for a missing key, got %v", err)
+	}
+
+	if ok, err := client.Set(ctx, "name", "other", &kvclient.SetOptions{NX: true}); err != nil || ok {
+		t.Errorf("Expected SET NX on an existing key to be skipped, got %v %v", ok, err)
+	}
+
+	if ok, _ := client.Set(ctx, "session", "v", &kvclient.SetOptions{TTL: 1500 * time.Millisecond}); !ok {
+		t.Error("Expected SET with PX to succeed")
+	}
+
+	if reply, _ := client.Do(ctx, "TTL", "session"); reply.Value() != "2" {
+		t.Errorf("Expected ttl of 2 seconds, got %q", reply.Output)
+	}
+
+	if count, err := client.Exists(ctx, "name", "session", "missing"); err != nil || count != 2 {
+		t.Errorf("Expected 2 existing keys, got %v %v", count, err)
+	}
+
+	if val, err := client.IncrBy(ctx, "counter", 5); err != nil || val != 5 {
+		t.Errorf("Expected 5, got %v %v", val, err)
+	}
+
+	var serverErr kvclient.ServerError
+	if _, err := client.IncrBy(ctx, "name", 1); !errors.As(err, &serverErr) {
+		t.Errorf("Expected a ServerError, got %v", err)
+	}
+
+	if err := client.Del(ctx, "counter"); err != nil {
+		t.Error(err)
+	}
+
+	if err := client.BFCreate(ctx, "seen", &kvclient.BloomOptions{Scalable: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	if added, err := client.BFMAdd(ctx, "seen", "a", "b", "a"); err != nil || len(added) != 3 || !added[0] || !added[1] || added[2] {
+		t.Errorf("Unexpected BF_MADD result: %v %v", added, err)
+	}
+
+	if exists, _ := client.BFExists(ctx, "seen", "a"); !exists {
+		t.Error("Expected item to exist in the bloom filter")
+	}
+
+	if exists, _ := client.BFMExists(ctx, "seen", "b", "c"); len(exists) != 2 || !exists[0] {
+		t.Errorf("Unexpected BF_MEXISTS result: %v", exists)
+	}
+
+	if err := client.Select(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if count, _ := client.Exists(ctx, "name"); count != 0 {
+		t.Error("Expected cache 1 to be empty")
+	}
+
+	client.Select(ctx, 0)
+}
+
+// Values looking like nil or a list, and list items with newlines, should come back as they were sent
+func TestClientReplyFraming(t *testing.T) {
+	handlers.SetUpCaches(8, 16)
+
+	client := createTestClient(t, startTestServer(t), kvclient.Options{})
+	ctx := context.Background()
+
+	client.Set(ctx, "list-like", "1) x", nil)
+	client.Set(ctx, "nil-like", "(nil)", nil)
+
+	if reply, err := client.Do(ctx, "GET", "list-like"); err != nil || reply.Value() != "1) x" {
+		t.Errorf("Expected %q, got %q %v", "1) x", reply.Output, err)
+	} else if _, err := kvclient.ParseList(reply.Output); err == nil {
+		t.Error("Expected a single value not to be parsed as a list")
+	}
+
+	if reply, err := client.Do(ctx, "GET", "nil-like"); err != nil || reply.IsNil() || reply.Value() != "(nil)" {
+		t.Errorf("Expected the value (nil), got %q %v", reply.Output, err)
+	}
+
+	var serverErr kvclient.ServerError
+	if _, err := client.Do(ctx, "GET", "missing"); !errors.As(err, &serverErr) || !serverErr.NoKey() {
+		t.Errorf("Expected a NOKEY error, got %v", err)
+	}
+
+	if reply, _ := client.Do(ctx, "LPOP", "missing"); !reply.IsNil() {
+		t.Errorf("Expected (nil), got %q", reply.Output)
+	}
+
+	values := []string{"a\nb", "(nil)", `c\d`, `\n`, ""}
+	client.Do(ctx, "RPUSH", append([]string{"items"}, values...)...)
+
+	reply, err := client.Do(ctx, "LRANGE", "items", "0", "-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	items, err := kvclient.ParseNullableList(reply.Output)
+	if err != nil || len(items) != len(values) {
+		t.Fatalf("Unexpected LRANGE reply %q: %v", reply.Output, err)
+	}
+
+	for i, item := range items {
+		if item == nil || *item != values[i] {
+			t.Errorf("Expected item %v to be %q, got %v", i, values[i], item)
+		}
+	}
+
+	reply, _ = client.Do(ctx, "MGET", "nil-like", "missing")
+	if items, err := kvclient.ParseNullableList(reply.Output); err != nil || len(items) != 2 || items[0] == nil || *items[0] != "(nil)" || items[1] != nil {
+		t.Errorf("Unexpected MGET reply %q: %v", reply.Output, err)
+	}
+
+	if reply, _ := client.Do(ctx, "EVAL", "return nil", "0"); !reply.IsNil() {
+		t.Errorf("Expected a script returning nil to reply (nil), got %q", reply.Output)
+	}
+
+	reply, _ = client.Do(ctx, "EVAL", "return call('LRANGE', KEYS[1], 0, 1)", "1", "items")
+	if items, err := kvclient.ParseList(reply.Output); err != nil || len(items) != 2 || items[0] != values[0] || items[1] != values[1] {
+		t.Errorf("Expected a script to return the list as it is, got %q %v", reply.Output, err)
+	}
+}
+
+// Values holding \r\n shouldn't end the reply early and desync the connection
+func TestClientCRLFValues(t *testing.T) {
+	handlers.SetUpCaches(8, 16)
+
+	client := createTestClient(t, startTestServer(t), kvclient.Options{PoolSize: 1})
+	ctx := context.Background()
+
+	client.Do(ctx, "APPEND", "k", "a\r")
+	client.Do(ctx, "APPEND", "k", "\nb\\n")
+	client.Set(ctx, "z", "last", nil)
+
+	if val, err := client.Get(ctx, "k"); err != nil || val != "a\r\nb\\n" {
+		t.Errorf("Expected %q, got %q %v", "a\r\nb\\n", val, err)
+	}
+
+	if val, err := client.Get(ctx, "z"); err != nil || val != "last" {
+		t.Errorf("Expected the connection to stay in sync, got %q %v", val, err)
+	}
+
+	reply, err := client.Do(ctx, "EVAL", "call('SET', KEYS[1], 'x\\r\\ny') return call('GET', KEYS[1])", "1", "s")
+	if err != nil || reply.Value() != "x\r\ny" {
+		t.Errorf("Expected a script value to come back as it is, got %q %v", reply.Output, err)
+	}
+
+	reply, err = client.Do(ctx, "EVAL", "call('RPUSH', 'l', 'p\\r\\nq') return call('LRANGE', 'l', 0, -1)", "0")
+	if items, parseErr := kvclient.ParseList(reply.Output); err != nil || parseErr != nil || len(items) != 1 || items[0] != "p\r\nq" {
+		t.Errorf("Expected a list item to come back as it is, got %q %v", reply.Output, err)
+	}
+
+	_, err = client.Do(ctx, "EVAL", "call('SET', 'e\\r\\nf', 'v') return call('INCR', 'e\\r\\nf')", "0")
+	if err == nil || !strings.Contains(err.Error(), "e\r\nf") {
+		t.Errorf("Expected the error to name the key as it is, got %v", err)
+	}
+
+	if val, err := client.Get(ctx, "z"); err != nil || val != "last" {
+		t.Errorf("Expected the connection to stay in sync, got %q %v", val, err)
+	}
+}
+
+func TestClientTransaction(t *testing.T) {
+	handlers.SetUpCaches(8, 16)
+
+	client := createTestClient(t, startTestServer(t), kvclient.Options{})
+	ctx := context.Background()
+
+	err := client.Transaction(ctx, func(tx *kvclient.Tx) {
+		tx.Queue("SET", "a", "1")
+		tx.Queue("INCRBY", "a", "10")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if val, _ := client.Get(ctx, "a"); val != "11" {
+		t.Errorf("Expected 11, got %q", val)
+	}
+
+	// Failing statement rolls back the whole transaction
+	err = client.Transaction(ctx, func(tx *kvclient.Tx) {
+		tx.Queue("SET", "a", "changed")
+		tx.Queue("DEL", "missing")
+	})
+	if err == nil {
+		t.Fatal("Expected the transaction to fail")
+	}
+
+	if val, _ := client.Get(ctx, "a"); val != "11" {
+		t.Errorf("Expected the transaction to be rolled back, got %q", val)
+	}
+}
+
+// Commands from other clients shouldn't run in between the calls of a script
+func TestEvalIsAtomic(t *testing.T) {
+	handlers.SetUpCaches(8, 16)
+
+	server := startTestServer(t)
+	script := createTestClient(t, server, kvclient.Options{})
+	writer := createTestClient(t, server, kvclient.Options{})
+	ctx := context.Background()
+
+	if ok, err := script.Set(ctx, "counter", "0", nil); err != nil || !ok {
+		t.Fatalf("Set failed: %v %v", ok, err)
+	}
+
+	stop := make(chan struct{})
+	done := make(chan error)
+
+	go func() {
+		for {
+			select {
+			case <-stop:
+				done <- nil
+				return
+			default:
+			}
+
+			if _, err := writer.Do(ctx, "INCR", "counter"); err != nil {
+				done <- err
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < 5; i++ {
+		reply, err := script.Do(ctx, "EVAL", "local before = call('GET', KEYS[1]) for i = 1, 2000000 do end return before .. ' ' .. call('GET', KEYS[1])", "1", "counter")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if values := strings.Split(reply.Value(), " "); len(values) != 2 || values[0] != values[1] {
+			t.Fatalf("Counter changed while the script was running: %q", reply.Value())
+		}
+	}
+
+	close(stop)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// SCRIPT KILL from another client should stop a script which holds the transaction lock
+func TestScriptKillFromAnotherClient(t *testing.T) {
+	handlers.SetUpCaches(8, 16)
+
+	defer func(limit time.Duration) { handlers.ScriptTimeLimit = limit }(handlers.ScriptTimeLimit)
+	handlers.ScriptTimeLimit = 10 * time.Second
+
+	server := startTestServer(t)
+	script := createTestClient(t, server, kvclient.Options{})
+	killer := createTestClient(t, server, kvclient.Options{})
+	ctx := context.Background()
+
+	start := time.Now()
+	done := make(chan error)
+
+	go func() {
+		_, err := script.Do(ctx, "EVAL", "while true do end", "0")
+		done <- err
+	}()
+
+	for {
+		killCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+		_, err := killer.Do(killCtx, "SCRIPT", "KILL")
+		cancel()
+
+		if err == nil {
+			break
+		}
+
+		if !strings.Contains(err.Error(), "No scripts in execution") {
+			t.Fatalf("SCRIPT KILL failed: %v", err)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := <-done; err == nil || !strings.Contains(err.Error(), "Script killed") {
+		t.Errorf("Expected the script to be killed, got %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Script was stopped only after %v", elapsed)
+	}
+}
+
+func TestClientPoolAndReconnect(t *testing.T) {
+	handlers.SetUpCaches(8, 16)
+
+	server := startTestServer(t)
+	client := createTestClient(t, server, kvclient.Options{PoolSize: 3})
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+
+	for g := 0; g < 20; g++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < 10; i++ {
+				if _, err := client.IncrBy(ctx, "hits", 1); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if val, _ := client.Get(ctx, "hits"); val != "200" {
+		t.Errorf("Expected 200, got %q", val)
+	}
+
+	server.mu.Lock()
+	opened := len(server.conns)
+	server.mu.Unlock()
+
+	if opened > 3 {
+		t.Errorf("Expected at most 3 connections, got %v", opened)
+	}
+
+	// Server closes every connection, the client connects again on its own
+	server.closeConnections()
+	time.Sleep(50 * time.Millisecond)
+
+	if val, err := client.Get(ctx, "hits"); err != nil || val != "200" {
+		t.Errorf("Expected the client to reconnect, got %q %v", val, err)
+	}
+}
+
+func TestClientTimeout(t *testing.T) {
+	handlers.SetUpCaches(8, 16)
+
+	client := createTestClient(t, startTestServer(t), kvclient.Options{PoolSize: 1})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	// Blocks till something is pushed to the list
+	if _, err := client.Do(ctx, "BLPOP", "queue", "0"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the deadline to be exceeded, got %v", err)
+	}
+
+	// Connection waiting for the old reply is replaced, the server drops the old BLPOP when it closes
+	if _, err := client.Do(context.Background(), "LPUSH", "queue", "a"); err != nil {
+		t.Errorf("Expected a new connection after the timeout, got %v", err)
+	}
+
+	if _, err := client.Do(context.Background(), "SET", "key", "a\r\nb"); err == nil {
+		t.Error("Expected arguments with \\r\\n to be rejected")
+	}
+
+	client.Close()
+
+	if err := client.Ping(context.Background()); !errors.Is(err, kvclient.ErrClientClosed) {
+		t.Errorf("Expected ErrClientClosed, got %v", err)
+	}
+}
+
+func TestClientPipeline(t *testing.T) {
+	handlers.SetUpCaches(8, 16)
+
+	server := startTestServer(t)
+	client := createTestClient(t, server, kvclient.Options{PoolSize: 1})
+	ctx := context.Background()
+
+	pipeline := client.Pipeline()
+
+	for i := 0; i < 5000; i++ {
+		pipeline.Queue("SET", fmt.Sprintf("key:%v", i), strings.Repeat("v", 100))
+	}
+	pipeline.Queue("INCR", "key:0")
+	pipeline.Queue("DBSIZE")
+
+	replies, err := pipeline.Exec(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(replies) != 5002 || pipeline.Len() != 0 {
+		t.Fatalf("Expected 5002 replies and an empty pipeline, got %v and %v", len(replies), pipeline.Len())
+	}
+
+	for i := 0; i < 5000; i++ {
+		if replies[i].Output != ">> SUCCESS" {
+			t.Fatalf("Unexpected reply %v : %q", i, replies[i].Output)
+		}
+	}
+
+	// Failing command doesn't stop the rest of the pipeline
+	var serverErr kvclient.ServerError
+	if !errors.As(replies[5000].Err(), &serverErr) {
+		t.Errorf("Expected INCR on a non integer to fail, got %q", replies[5000].Output)
+	}
+
+	if replies[5001].Value() != "5000" {
+		t.Errorf("Expected 5000 keys, got %q", replies[5001].Output)
+	}
+}
+
+// A subscription shouldn't leave a subscribed connection in the pool
+func TestClientSubscribeConnection(t *testing.T) {
+	handlers.SetUpCaches(8, 16)
+
+	server := startTestServer(t)
+	messages := make(chan kvclient.Message, 1)
+	client := createTestClient(t, server, kvclient.Options{PoolSize: 1, OnMessage: func(message kvclient.Message) { messages <- message }})
+	ctx := context.Background()
+
+	if _, err := client.Do(ctx, "SUBSCRIBE", "news"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The only pooled connection still runs any command
+	if _, err := client.Set(ctx, "name", "milan", nil); err != nil {
+		t.Fatalf("Expected SET after SUBSCRIBE to work, got %v", err)
+	}
+
+	if _, err := client.Do(ctx, "PUBLISH", "news", "hello"); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case message := <-messages:
+		if !strings.Contains(message.Output, "hello") {
+			t.Errorf("Expected the published message, got %q", message.Output)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected a message on the subscriber connection")
+	}
+
+	if _, err := client.Do(ctx, "UNSUBSCRIBE", "news"); err != nil {
+		t.Fatal(err)
+	}
+
+	pipeline := client.Pipeline()
+	pipeline.Queue("SUBSCRIBE", "news")
+	pipeline.Queue("GET", "name")
+	if _, err := pipeline.Exec(ctx); !errors.Is(err, kvclient.ErrSubscribeMixed) {
+		t.Errorf("Expected ErrSubscribeMixed for a mixed pipeline, got %v", err)
+	}
+
+	err := client.Transaction(ctx, func(tx *kvclient.Tx) { tx.Queue("PSUBSCRIBE", "*") })
+	if !errors.Is(err, kvclient.ErrSubscribeMixed) {
+		t.Errorf("Expected ErrSubscribeMixed in a transaction, got %v", err)
+	}
+}
+
+func TestServerReadsBufferedCommands(t *testing.T) {
+	handlers.SetUpCaches(8, 16)
+
+	server := startTestServer(t)
+
+	c, err := net.Dial("tcp", server.listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	reader := bufio.NewReader(c)
+
+	readReply := func() string {
+		command, _ := reader.ReadString('\n')
+		output, _ := reader.ReadString('\n')
+		return strings.TrimSpace(command) + " " + strings.TrimSpace(output)
+	}
+
+	// Three commands in a single write, one of them with an empty argument
+	c.Write([]byte("*3\r\nSET\r\na\r\n1\r\n*3\r\nset\r\nempty\r\n\r\n*2\r\nGET\r\na\r\n"))
+
+	for _, expected := range []string{"SET >> SUCCESS", "SET >> SUCCESS", "GET >> 1"} {
+		if reply := readReply(); reply != expected {
+			t.Errorf("Expected %q, got %q", expected, reply)
+		}
+	}
+
+	// Command split across writes
+	c.Write([]byte("*2\r\nGE"))
+	time.Sleep(20 * time.Millisecond)
+	c.Write([]byte("T\r\nempty\r\n"))
+
+	if reply := readReply(); reply != "GET >>" {
+		t.Errorf("Expected the empty value, got %q", reply)
+	}
+
+	// Unframed commands from older clients
+	c.Write([]byte("GET\r\na\r\n"))
+
+	if reply := readReply(); reply != "GET >> 1" {
+		t.Errorf("Expected unframed command to work, got %q", reply)
+	}
+
+	c.Write([]byte("*0\r\n"))
+
+	if reply := readReply(); reply != "ERR "+utils.ErrEmptyCommand.Error() {
+		t.Errorf("Expected an error for an empty command, got %q", reply)
+	}
+}
+
+func TestBlockedClientDisconnect(t *testing.T) {
+	handlers.SetUpCaches(8, 16)
+
+	server := startTestServer(t)
+
+	c, err := net.Dial("tcp", server.listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.Write([]byte("*3\r\nBLPOP\r\njobs\r\n0\r\n"))
+	time.Sleep(50 * time.Millisecond)
+
+	if blocked := handlers.NumBlockedClients(); blocked != 1 {
+		t.Fatalf("Expected 1 blocked client, got %v", blocked)
+	}
+
+	// Client leaves while blocked, its waiter should go away with it
+	c.Close()
+	time.Sleep(50 * time.Millisecond)
+
+	if blocked := handlers.NumBlockedClients(); blocked != 0 {
+		t.Errorf("Expected no blocked clients after the disconnect, got %v", blocked)
+	}
+
+	handlers.CommandHandler("LPUSH", []string{"jobs", "a"})
+
+	if val, _ := handlers.CommandHandler("LRANGE", []string{"jobs", "0", "-1"}); val != ">>\n1) a" {
+		t.Errorf("Expected the pushed element to stay in the list, got %q", val)
+	}
+
+	// Blocked client which stays connected still gets the element, commands sent after it are kept
+	c, err = net.Dial("tcp", server.listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	c.Write([]byte("*3\r\nBLPOP\r\nempty\r\n0\r\n*1\r\nPING\r\n"))
+	time.Sleep(50 * time.Millisecond)
+
+	handlers.CommandHandler("RPUSH", []string{"empty", "b"})
+
+	reader := bufio.NewReader(c)
+	c.SetReadDeadline(time.Now().Add(time.Second))
+
+	// Output of a list spans lines seperated by \n, it ends at \r\n
+	readPart := func() string {
+		part := ""
+		for !strings.HasSuffix(part, "\r\n") {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return part
+			}
+			part += line
+		}
+		return strings.TrimSuffix(part, "\r\n")
+	}
+
+	var replies []string
+	for i := 0; i < 2; i++ {
+		replies = append(replies, readPart()+" "+readPart())
+	}
+
+	if replies[0] != "BLPOP >>\n1) empty\n2) b" || replies[1] != "PING >> PONG" {
+		t.Errorf("Unexpected replies %q", replies)
+	}
+}
@@ -0,0 +1,155 @@
+package tests
+
+import (
+	"prac/handlers"
+	"testing"
+	"time"
+)
+
+func TestListHandler(t *testing.T) {
+	handlers.SetUpCaches(8, 16)
+	handlers.CurrentCache.Data["str"] = handlers.CacheItem{Val: "value"}
+
+	tests := []struct {
+		name        string
+		command     string
+		args        []string
+		expectError bool
+		expectedErr string
+		expectedVal string
+	}{
+		{name: "RPUSH creates list", command: "RPUSH", args: []string{"list", "b", "c", "d"}, expectedVal: ">> 3"},
+		{name: "LPUSH prepends", command: "LPUSH", args: []string{"list", "a"}, expectedVal: ">> 4"},
+		{name: "LRANGE whole list", command: "LRANGE", args: []string{"list", "0", "-1"}, expectedVal: ">>\n1) a\n2) b\n3) c\n4) d"},
+		{name: "LRANGE out of range", command: "LRANGE", args: []string{"list", "10", "20"}, expectedVal: ">>\n(empty list)"},
+		{name: "LINDEX negative", command: "LINDEX", args: []string{"list", "-1"}, expectedVal: ">> d"},
+		{name: "LSET", command: "LSET", args: []string{"list", "1", "x"}, expectedVal: ">> SUCCESS"},
+		{name: "LSET out of range", command: "LSET", args: []string{"list", "9", "x"}, expectError: true, expectedErr: "LSET list : Index out of range !!!"},
+		{name: "RPUSH duplicates", command: "RPUSH", args: []string{"list", "x", "x"}, expectedVal: ">> 6"},
+		{name: "LREM from tail", command: "LREM", args: []string{"list", "-2", "x"}, expectedVal: ">> 2"},
+		{name: "LRANGE after LREM", command: "LRANGE", args: []string{"list", "0", "-1"}, expectedVal: ">>\n1) a\n2) x\n3) c\n4) d"},
+		{name: "LTRIM", command: "LTRIM", args: []string{"list", "1", "2"}, expectedVal: ">> SUCCESS"},
+		{name: "LLEN", command: "LLEN", args: []string{"list"}, expectedVal: ">> 2"},
+		{name: "LPOP", command: "LPOP", args: []string{"list"}, expectedVal: ">> x"},
+		{name: "RPOP with count", command: "RPOP", args: []string{"list", "5"}, expectedVal: ">>\n1) c"},
+		{name: "LPOP missing key", command: "LPOP", args: []string{"list"}, expectedVal: ">>\n(nil)"},
+		{name: "LLEN missing key", command: "LLEN", args: []string{"list"}, expectedVal: ">> 0"},
+		{name: "LPUSH many reverses", command: "LPUSH", args: []string{"many", "c", "b", "a"}, expectedVal: ">> 3"},
+		{name: "LPUSH many onto list", command: "LPUSH", args: []string{"many", "z", "y"}, expectedVal: ">> 5"},
+		{name: "LRANGE after LPUSH many", command: "LRANGE", args: []string{"many", "0", "-1"}, expectedVal: ">>\n1) y\n2) z\n3) a\n4) b\n5) c"},
+		{name: "Wrong type", command: "LPUSH", args: []string{"str", "a"}, expectError: true, expectedErr: "LPUSH str : Key holds the wrong kind of value !!!"},
+		{name: "Missing Key", command: "LLEN", args: []string{}, expectError: true, expectedErr: "LLEN : Missing Key"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			val, err := handlers.ListHandler(test.command, test.args)
+
+			if test.expectError {
+				if err == nil {
+					t.Errorf("Expected error but got none")
+				} else if err.Error() != test.expectedErr {
+					t.Errorf("Expected error: %v, but got: %v", test.expectedErr, err.Error())
+				}
+			} else {
+				if err != nil {
+					t.Errorf("Did not expect an error but got: %v", err)
+				}
+				if val != test.expectedVal {
+					t.Errorf("Expected value %q, but got %q", test.expectedVal, val)
+				}
+			}
+		})
+	}
+
+	if _, exists := handlers.CurrentCache.Data["list"]; exists {
+		t.Error("Expected empty list to be deleted")
+	}
+}
+
+func TestBlockingPopFIFO(t *testing.T) {
+	handlers.SetUpCaches(8, 16)
+
+	first, second := make(chan string, 1), make(chan string, 1)
+
+	blockedPop := func(result chan<- string) {
+		val, err := handlers.BlockingListHandler("BLPOP", []string{"queue", "5"}, true, nil)
+		if err != nil {
+			t.Error(err)
+		}
+		result <- val
+	}
+
+	go blockedPop(first)
+	waitForBlockedClients(t, 1)
+
+	go blockedPop(second)
+	waitForBlockedClients(t, 2)
+
+	if _, err := handlers.ListHandler("RPUSH", []string{"queue", "first", "second"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// first connection that blocked gets the first element
+	if val := <-first; val != ">>\n1) queue\n2) first" {
+		t.Errorf("Expected first waiter to get first, got %q", val)
+	}
+
+	if val := <-second; val != ">>\n1) queue\n2) second" {
+		t.Errorf("Expected second waiter to get second, got %q", val)
+	}
+}
+
+func TestBlockingPopTimeout(t *testing.T) {
+	handlers.SetUpCaches(8, 16)
+
+	start := time.Now()
+	val, err := handlers.BlockingListHandler("BRPOP", []string{"empty", "0.05"}, true, nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if val != ">>\n(nil)" || time.Since(start) < 50*time.Millisecond {
+		t.Errorf("Expected (nil) after timeout, got %q", val)
+	}
+
+	// Nothing should be left waiting on the key
+	handlers.ListHandler("RPUSH", []string{"empty", "a"})
+
+	if val, _ = handlers.ListHandler("LLEN", []string{"empty"}); val != ">> 1" {
+		t.Errorf("Expected pushed element to stay in the list, got %q", val)
+	}
+}
+
+func TestBlockingMove(t *testing.T) {
+	handlers.SetUpCaches(8, 16)
+
+	result := make(chan string)
+	go func() {
+		val, _ := handlers.BlockingListHandler("BLMOVE", []string{"src", "dst", "LEFT", "RIGHT", "5"}, true, nil)
+		result <- val
+	}()
+	waitForBlockedClients(t, 1)
+
+	handlers.ListHandler("RPUSH", []string{"src", "job"})
+
+	if val := <-result; val != ">> job" {
+		t.Errorf("Expected job, got %q", val)
+	}
+
+	if val, _ := handlers.ListHandler("LRANGE", []string{"dst", "0", "-1"}); val != ">>\n1) job" {
+		t.Errorf("Expected job in destination, got %q", val)
+	}
+}
+
+func waitForBlockedClients(t *testing.T, n int) {
+	deadline := time.Now().Add(5 * time.Second)
+
+	for handlers.NumBlockedClients() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %v blocked clients", n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,172 @@
+package tests
+
+import (
+	"prac/handlers"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseNotifyFlags(t *testing.T) {
+	tests := []struct {
+		input       string
+		expected    string
+		expectError bool
+	}{
+		{input: "KEA", expected: "KEg$lshzxd"},
+		{input: "Ke", expectError: true},
+		{input: "Ex", expected: "Ex"},
+		{input: "NONE", expected: "NONE"},
+		{input: "Kz", expected: "Kz"},
+		{input: "Kq", expectError: true},
+	}
+
+	for _, test := range tests {
+		flags, err := handlers.ParseNotifyFlags(test.input)
+
+		if test.expectError {
+			if err == nil {
+				t.Errorf("Expected error for %v but got none", test.input)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Did not expect an error but got: %v", err)
+		}
+
+		if result := handlers.FormatNotifyFlags(flags); result != test.expected {
+			t.Errorf("Expected flags %v, but got %v", test.expected, result)
+		}
+	}
+}
+
+func TestKeyspaceNotifications(t *testing.T) {
+	handlers.SetUpCaches(8, 16)
+
+	conn, reader := newTestConnection(t, "notify")
+
+	handlers.PubSubHandler("SUBSCRIBE", []string{"__keyevent@0__:del", "__keyspace@0__:user"}, conn)
+	defer handlers.PubSub.UnsubscribeAll(conn)
+
+	if _, err := handlers.NotifyConfigHandler([]string{"KEg"}); err != nil {
+		t.Fatal(err)
+	}
+	defer handlers.NotifyConfigHandler([]string{"NONE"})
+
+	received := make(chan string, 2)
+	go func() {
+		for i := 0; i < 2; i++ {
+			reader.ReadString('\n')
+			msg, _ := reader.ReadString('\n')
+			received <- msg
+		}
+	}()
+
+	// $ is not enabled, so SET must not publish anything
+	handlers.SetHandler([]string{"user", "1"})
+	handlers.DelHandler([]string{"user"})
+
+	if msg := <-received; !strings.Contains(msg, "(__keyspace@0__:user) del") {
+		t.Errorf("Unexpected keyspace notification: %q", msg)
+	}
+
+	if msg := <-received; !strings.Contains(msg, "(__keyevent@0__:del) user") {
+		t.Errorf("Unexpected keyevent notification: %q", msg)
+	}
+}
+
+// Writes to lists and hashes raise their events, and emptying a key raises del after them
+func TestContainerNotifications(t *testing.T) {
+	handlers.SetUpCaches(8, 16)
+
+	conn, reader := newTestConnection(t, "containers")
+
+	handlers.PubSubHandler("PSUBSCRIBE", []string{"__keyspace@0__:*"}, conn)
+	defer handlers.PubSub.UnsubscribeAll(conn)
+
+	if _, err := handlers.NotifyConfigHandler([]string{"Klhg"}); err != nil {
+		t.Fatal(err)
+	}
+	defer handlers.NotifyConfigHandler([]string{"NONE"})
+
+	handlers.ListHandler("RPUSH", []string{"queue", "a", "b"})
+	handlers.ListHandler("LPOP", []string{"queue", "2"})
+	handlers.HashHandler("HSET", []string{"user", "name", "milan"})
+	handlers.HashHandler("HDEL", []string{"user", "missing"})
+	handlers.HashHandler("HDEL", []string{"user", "name"})
+	handlers.SetTypeHandler("SADD", []string{"tags", "x"}) // s isn't enabled
+
+	expected := []string{
+		"__keyspace@0__:queue) rpush",
+		"__keyspace@0__:queue) lpop",
+		"__keyspace@0__:queue) del",
+		"__keyspace@0__:user) hset",
+		"__keyspace@0__:user) hdel",
+		"__keyspace@0__:user) del",
+	}
+
+	received := make(chan string)
+	go func() {
+		for {
+			reader.ReadString('\n')
+			msg, err := reader.ReadString('\n')
+			if err != nil {
+				close(received)
+				return
+			}
+			received <- msg
+		}
+	}()
+
+	for _, want := range expected {
+		select {
+		case msg := <-received:
+			if !strings.Contains(msg, want) {
+				t.Errorf("Expected %q, got %q", want, msg)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Expected %q, got nothing", want)
+		}
+	}
+
+	select {
+	case msg, ok := <-received:
+		if ok {
+			t.Errorf("Unexpected notification %q", msg)
+		}
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// A subscriber that doesn't read its messages shouldn't block the commands raising them
+func TestSlowSubscriberDoesNotBlock(t *testing.T) {
+	handlers.SetUpCaches(8, 16)
+
+	conn, _ := newTestConnection(t, "slow")
+
+	handlers.PubSubHandler("PSUBSCRIBE", []string{"__key*"}, conn)
+	defer handlers.PubSub.UnsubscribeAll(conn)
+
+	if _, err := handlers.NotifyConfigHandler([]string{"KE$"}); err != nil {
+		t.Fatal(err)
+	}
+	defer handlers.NotifyConfigHandler([]string{"NONE"})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		for i := 0; i < 3000; i++ {
+			handlers.SetHandler([]string{"key", strconv.Itoa(i)})
+			handlers.PublishHandler([]string{"__keyspace@0__:key", "manual"})
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Commands were blocked by a subscriber that isn't reading")
+	}
+}
@@ -0,0 +1,98 @@
+package tests
+
+import (
+	"bufio"
+	"net"
+	"prac/handlers"
+	"strings"
+	"testing"
+)
+
+func newTestConnection(t *testing.T, id string) (*handlers.Connection, *bufio.Reader) {
+	server, client := net.Pipe()
+	t.Cleanup(func() {
+		server.Close()
+		client.Close()
+	})
+
+	return &handlers.Connection{Id: id, Conn: server}, bufio.NewReader(client)
+}
+
+func TestPublishToSubscribers(t *testing.T) {
+	handlers.SetUpCaches(8, 16)
+
+	sub, subReader := newTestConnection(t, "sub")
+	psub, psubReader := newTestConnection(t, "psub")
+
+	if _, err := handlers.PubSubHandler("SUBSCRIBE", []string{"news"}, sub); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := handlers.PubSubHandler("PSUBSCRIBE", []string{"ne*"}, psub); err != nil {
+		t.Fatal(err)
+	}
+
+	if !sub.InSubscriberMode() || !psub.InSubscriberMode() {
+		t.Error("Expected connections to be in subscriber mode")
+	}
+
+	received := make(chan string, 2)
+	go func() {
+		line, _ := subReader.ReadString('\n')
+		msg, _ := subReader.ReadString('\n')
+		received <- line + msg
+	}()
+	go func() {
+		line, _ := psubReader.ReadString('\n')
+		msg, _ := psubReader.ReadString('\n')
+		received <- line + msg
+	}()
+
+	receivers, err := handlers.PublishHandler([]string{"news", "hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if receivers != 2 {
+		t.Errorf("Expected 2 receivers, got %v", receivers)
+	}
+
+	for i := 0; i < 2; i++ {
+		if msg := <-received; !strings.Contains(msg, "hello") {
+			t.Errorf("Expected pushed message to contain hello, got %q", msg)
+		}
+	}
+
+	handlers.PubSub.UnsubscribeAll(sub)
+	handlers.PubSubHandler("PUNSUBSCRIBE", []string{}, psub)
+
+	if sub.InSubscriberMode() || psub.InSubscriberMode() {
+		t.Error("Expected connections to leave subscriber mode")
+	}
+
+	if receivers, _ = handlers.PublishHandler([]string{"news", "hello"}); receivers != 0 {
+		t.Errorf("Expected 0 receivers after unsubscribing, got %v", receivers)
+	}
+}
+
+func TestPubSubInfoHandler(t *testing.T) {
+	conn, _ := newTestConnection(t, "info")
+
+	handlers.PubSubHandler("SUBSCRIBE", []string{"orders", "order.created", "users"}, conn)
+	defer handlers.PubSub.UnsubscribeAll(conn)
+
+	val, err := handlers.PubSubInfoHandler([]string{"CHANNELS", "order*"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if val != ">>\n1) order.created\n2) orders" {
+		t.Errorf("Unexpected PUBSUB CHANNELS output: %q", val)
+	}
+
+	val, _ = handlers.PubSubInfoHandler([]string{"NUMSUB", "users", "nobody"})
+
+	if val != ">>\n1) users : 1\n2) nobody : 0" {
+		t.Errorf("Unexpected PUBSUB NUMSUB output: %q", val)
+	}
+}
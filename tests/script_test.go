@@ -0,0 +1,148 @@
+package tests
+
+import (
+	"prac/handlers"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEvalHandler(t *testing.T) {
+	handlers.SetUpCaches(8, 16)
+	handlers.CurrentCache.Data["stock"] = handlers.CacheItem{Val: "1"}
+
+	tests := []struct {
+		name        string
+		args        []string
+		expectError bool
+		expectedErr string
+		expectedVal string
+	}{
+		{
+			name:        "Return value",
+			args:        []string{"return ARGV[1] .. KEYS[1]", "1", "key", "arg"},
+			expectedVal: ">> argkey",
+		},
+		{
+			name:        "Decrement if positive",
+			args:        []string{"local v = tonumber(call('GET', KEYS[1])) if v > 0 then call('SET', KEYS[1], v - 1) return 1 end return 0", "1", "stock"},
+			expectedVal: ">> 1",
+		},
+		{
+			name:        "Decrement on empty stock",
+			args:        []string{"local v = tonumber(call('GET', KEYS[1])) if v > 0 then call('SET', KEYS[1], v - 1) return 1 end return 0", "1", "stock"},
+			expectedVal: ">> 0",
+		},
+		{
+			name:        "Missing numkeys",
+			args:        []string{"return 1"},
+			expectError: true,
+			expectedErr: "EVAL : Missing numkeys",
+		},
+		{
+			name:        "Too many keys",
+			args:        []string{"return 1", "2", "a"},
+			expectError: true,
+			expectedErr: "EVAL : numkeys can't be greater than number of arguments",
+		},
+		{
+			name:        "Denied command",
+			args:        []string{"return call('NUM', 1)", "0"},
+			expectError: true,
+			expectedErr: "NUM can't be called from a script !!!",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			val, err := handlers.EvalHandler(test.args)
+
+			if test.expectError {
+				if err == nil {
+					t.Errorf("Expected error but got none")
+				} else if !strings.Contains(err.Error(), test.expectedErr) {
+					t.Errorf("Expected error: %v, but got: %v", test.expectedErr, err.Error())
+				}
+			} else {
+				if err != nil {
+					t.Errorf("Did not expect an error but got: %v", err)
+				}
+				if val != test.expectedVal {
+					t.Errorf("Expected value %v, but got %v", test.expectedVal, val)
+				}
+			}
+		})
+	}
+
+	if handlers.CurrentCache.Data["stock"].Val != "0" {
+		t.Errorf("Expected stock to be decremented to 0, but got %v", handlers.CurrentCache.Data["stock"].Val)
+	}
+}
+
+func TestEvalShaHandler(t *testing.T) {
+	handlers.SetUpCaches(8, 16)
+
+	sha, err := handlers.ScriptHandler([]string{"LOAD", "return KEYS[1]"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sha = strings.TrimPrefix(sha, ">> ")
+
+	val, err := handlers.EvalShaHandler([]string{sha, "1", "foo"})
+	if err != nil {
+		t.Error(err)
+	}
+
+	if val != ">> foo" {
+		t.Errorf("Expected value >> foo, but got %v", val)
+	}
+
+	if _, err = handlers.ScriptHandler([]string{"FLUSH"}); err != nil {
+		t.Error(err)
+	}
+
+	if _, err = handlers.EvalShaHandler([]string{sha, "0"}); err == nil {
+		t.Error("Expected error after SCRIPT FLUSH but got none")
+	}
+}
+
+func TestScriptTimeLimitAndKill(t *testing.T) {
+	handlers.SetUpCaches(8, 16)
+
+	defer func(limit time.Duration) { handlers.ScriptTimeLimit = limit }(handlers.ScriptTimeLimit)
+	handlers.ScriptTimeLimit = 50 * time.Millisecond
+
+	_, err := handlers.EvalHandler([]string{"while true do end", "0"})
+
+	if err == nil || !strings.Contains(err.Error(), "time limit") {
+		t.Errorf("Expected time limit error but got: %v", err)
+	}
+
+	if _, err = handlers.ScriptHandler([]string{"KILL"}); err == nil {
+		t.Error("Expected error when no script is running but got none")
+	}
+
+	handlers.ScriptTimeLimit = 10 * time.Second
+
+	done := make(chan error)
+	go func() {
+		_, err := handlers.EvalHandler([]string{"while true do end", "0"})
+		done <- err
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err = handlers.ScriptHandler([]string{"KILL"}); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Script never started running")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if err = <-done; err == nil || !strings.Contains(err.Error(), "SCRIPT KILL") {
+		t.Errorf("Expected killed error but got: %v", err)
+	}
+}
@@ -0,0 +1,148 @@
+package tests
+
+import (
+	"prac/handlers"
+	"prac/kvclient"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSetTypeHandler(t *testing.T) {
+	handlers.SetUpCaches(8, 16)
+	handlers.CurrentCache.Data["str"] = handlers.CacheItem{Val: "value"}
+
+	tests := []struct {
+		name        string
+		command     string
+		args        []string
+		expectError bool
+		expectedErr string
+		expectedVal string
+	}{
+		{name: "SADD", command: "SADD", args: []string{"a", "go", "rust", "zig", "go"}, expectedVal: ">> 3"},
+		{name: "SADD second set", command: "SADD", args: []string{"b", "go", "java"}, expectedVal: ">> 2"},
+		{name: "SISMEMBER", command: "SISMEMBER", args: []string{"a", "zig"}, expectedVal: ">> true"},
+		{name: "SMISMEMBER", command: "SMISMEMBER", args: []string{"a", "go", "java"}, expectedVal: ">>\n1) true\n2) false"},
+		{name: "SMEMBERS", command: "SMEMBERS", args: []string{"a"}, expectedVal: ">>\n1) go\n2) rust\n3) zig"},
+		{name: "SCARD", command: "SCARD", args: []string{"b"}, expectedVal: ">> 2"},
+		{name: "SINTER", command: "SINTER", args: []string{"a", "b"}, expectedVal: ">>\n1) go"},
+		{name: "SUNION", command: "SUNION", args: []string{"a", "b"}, expectedVal: ">>\n1) go\n2) java\n3) rust\n4) zig"},
+		{name: "SDIFF", command: "SDIFF", args: []string{"a", "b"}, expectedVal: ">>\n1) rust\n2) zig"},
+		{name: "SDIFF missing key", command: "SDIFF", args: []string{"a", "missing"}, expectedVal: ">>\n1) go\n2) rust\n3) zig"},
+		{name: "SINTERSTORE", command: "SINTERSTORE", args: []string{"common", "a", "b"}, expectedVal: ">> 1"},
+		{name: "Stored set", command: "SMEMBERS", args: []string{"common"}, expectedVal: ">>\n1) go"},
+		{name: "SUNIONSTORE overwrites", command: "SUNIONSTORE", args: []string{"str", "b"}, expectedVal: ">> 2"},
+		{name: "SREM", command: "SREM", args: []string{"b", "java", "c"}, expectedVal: ">> 1"},
+		{name: "SRANDMEMBER", command: "SRANDMEMBER", args: []string{"b"}, expectedVal: ">> go"},
+		{name: "SRANDMEMBER with repeats", command: "SRANDMEMBER", args: []string{"b", "-3"}, expectedVal: ">>\n1) go\n2) go\n3) go"},
+		{name: "SRANDMEMBER count too negative", command: "SRANDMEMBER", args: []string{"b", "-1048577"}, expectError: true, expectedErr: "SRANDMEMBER b : Negative count can't be less than -1048576"},
+		{name: "SRANDMEMBER min int count", command: "SRANDMEMBER", args: []string{"b", "-9223372036854775808"}, expectError: true, expectedErr: "SRANDMEMBER b : Negative count can't be less than -1048576"},
+		{name: "SPOP", command: "SPOP", args: []string{"b"}, expectedVal: ">> go"},
+		{name: "SPOP empty", command: "SPOP", args: []string{"b"}, expectedVal: ">>\n(nil)"},
+		{name: "Wrong type", command: "SINTER", args: []string{"a", "list"}, expectError: true, expectedErr: "SINTER list : Key holds the wrong kind of value !!!"},
+	}
+
+	handlers.ListHandler("RPUSH", []string{"list", "x"})
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			val, err := handlers.SetTypeHandler(test.command, test.args)
+
+			if test.expectError {
+				if err == nil {
+					t.Errorf("Expected error but got none")
+				} else if err.Error() != test.expectedErr {
+					t.Errorf("Expected error: %v, but got: %v", test.expectedErr, err.Error())
+				}
+			} else {
+				if err != nil {
+					t.Errorf("Did not expect an error but got: %v", err)
+				}
+				if val != test.expectedVal {
+					t.Errorf("Expected value %q, but got %q", test.expectedVal, val)
+				}
+			}
+		})
+	}
+
+	if _, exists := handlers.CurrentCache.Data["b"]; exists {
+		t.Error("Expected empty set to be deleted")
+	}
+}
+
+func TestSetPopCount(t *testing.T) {
+	handlers.SetUpCaches(8, 16)
+	handlers.SetTypeHandler("SADD", []string{"tags", "a", "b", "c", "d"})
+
+	val, err := handlers.SetTypeHandler("SPOP", []string{"tags", "3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(strings.Split(strings.TrimPrefix(val, ">>\n"), "\n")) != 3 {
+		t.Errorf("Expected 3 popped members, got %q", val)
+	}
+
+	if val, _ = handlers.SetTypeHandler("SCARD", []string{"tags"}); val != ">> 1" {
+		t.Errorf("Expected 1 member left, got %v", val)
+	}
+}
+
+func TestSetRandomMembers(t *testing.T) {
+	handlers.SetUpCaches(8, 16)
+
+	members := []string{"tags"}
+	for i := 0; i < 100; i++ {
+		members = append(members, strconv.Itoa(i))
+	}
+	handlers.SetTypeHandler("SADD", members)
+
+	inSet := func(member string) bool {
+		n, err := strconv.Atoi(member)
+		return err == nil && n >= 0 && n < 100
+	}
+
+	val, _ := handlers.SetTypeHandler("SRANDMEMBER", []string{"tags", "50"})
+	picked, _ := kvclient.ParseList(val)
+	distinct := map[string]bool{}
+	for _, member := range picked {
+		if !inSet(member) {
+			t.Fatalf("Unexpected member %q", member)
+		}
+		distinct[member] = true
+	}
+	if len(picked) != 50 || len(distinct) != 50 {
+		t.Errorf("Expected 50 distinct members, got %v (%v distinct)", len(picked), len(distinct))
+	}
+
+	val, _ = handlers.SetTypeHandler("SRANDMEMBER", []string{"tags", "-300"})
+	picked, _ = kvclient.ParseList(val)
+	if len(picked) != 300 {
+		t.Errorf("Expected 300 members, got %v", len(picked))
+	}
+	for _, member := range picked {
+		if !inSet(member) {
+			t.Fatalf("Unexpected member %q", member)
+		}
+	}
+
+	// Every member should come up sooner or later
+	seen := map[string]bool{}
+	for i := 0; i < 5000 && len(seen) < 100; i++ {
+		val, _ = handlers.SetTypeHandler("SRANDMEMBER", []string{"tags"})
+		seen[strings.TrimPrefix(val, ">> ")] = true
+	}
+	if len(seen) != 100 {
+		t.Errorf("Expected every member to be picked, got %v", len(seen))
+	}
+
+	val, _ = handlers.SetTypeHandler("SPOP", []string{"tags", "150"})
+	picked, _ = kvclient.ParseList(val)
+	if len(picked) != 100 {
+		t.Errorf("Expected all 100 members to be popped, got %v", len(picked))
+	}
+	if _, exists := handlers.CurrentCache.Data["tags"]; exists {
+		t.Error("Expected emptied set to be deleted")
+	}
+}
@@ -0,0 +1,156 @@
+package tests
+
+import (
+	"fmt"
+	"math"
+	"prac/handlers"
+	"prac/utils"
+	"testing"
+)
+
+func TestCountMinSketchAccuracy(t *testing.T) {
+	cms, err := utils.CreateCountMinSketchByProb(0.001, 0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 10000; i++ {
+		cms.IncrBy(fmt.Sprintf("item-%v", i%1000), 1)
+	}
+
+	for i := 0; i < 1000; i++ {
+		count := cms.Query(fmt.Sprintf("item-%v", i))
+
+		// never underestimates, overestimates by at most errorRate * total with high probability
+		if count < 10 || count > 10+10 {
+			t.Errorf("Expected count of item-%v to be about 10, got %v", i, count)
+		}
+	}
+
+	if cms.Count != 10000 {
+		t.Errorf("Expected total count of 10000, got %v", cms.Count)
+	}
+}
+
+// Merged counters should stop at the max uint64 like increments do, instead of wrapping around
+func TestCountMinSketchMergeSaturates(t *testing.T) {
+	cms, _ := utils.CreateCountMinSketch(100, 4)
+	cms.IncrBy("a", math.MaxUint64/2+1)
+
+	dest, _ := utils.CreateCountMinSketch(100, 4)
+
+	if err := dest.Merge([]*utils.CountMinSketch{cms, cms}, []uint64{1, 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if count := dest.Query("a"); count != math.MaxUint64 {
+		t.Errorf("Expected the sum to saturate, got %v", count)
+	}
+
+	if err := dest.Merge([]*utils.CountMinSketch{cms}, []uint64{3}); err != nil {
+		t.Fatal(err)
+	}
+
+	if count := dest.Query("a"); count != math.MaxUint64 || dest.Count != math.MaxUint64 {
+		t.Errorf("Expected the product to saturate, got %v and a total of %v", count, dest.Count)
+	}
+
+	if cms.IncrBy("a", math.MaxUint64); cms.Count != math.MaxUint64 {
+		t.Errorf("Expected the total count to saturate, got %v", cms.Count)
+	}
+}
+
+func TestTopKHeavyHitters(t *testing.T) {
+	topk, err := utils.CreateTopK(3, 50, 5, 0.9)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for round := 0; round < 100; round++ {
+		for i := 0; i < 20; i++ {
+			topk.Add(fmt.Sprintf("noise-%v-%v", round, i))
+		}
+
+		for j := 0; j < 10; j++ {
+			topk.Add("heavy-a")
+		}
+
+		for j := 0; j < 5; j++ {
+			topk.Add("heavy-b")
+			topk.Add("heavy-c")
+		}
+	}
+
+	list := topk.List()
+
+	if len(list) != 3 || list[0].Item != "heavy-a" {
+		t.Fatalf("Expected heavy-a to be the top item, got %v", list)
+	}
+
+	for _, item := range []string{"heavy-a", "heavy-b", "heavy-c"} {
+		if !topk.Query(item) {
+			t.Errorf("Expected %v to be in the top k list, got %v", item, list)
+		}
+	}
+}
+
+func TestSketchHandlers(t *testing.T) {
+	handlers.CountMinSketchMap = make(map[string]*utils.CountMinSketch)
+	handlers.TopKMap = make(map[string]*utils.TopK)
+
+	tests := []struct {
+		name        string
+		command     string
+		args        []string
+		expectError bool
+		expectedErr string
+		expectedVal string
+	}{
+		{name: "CMS_INITBYDIM", command: "CMS_INITBYDIM", args: []string{"clicks", "2000", "5"}, expectedVal: ">> SUCCESS"},
+		{name: "CMS_INITBYDIM existing", command: "CMS_INITBYDIM", args: []string{"clicks", "2000", "5"}, expectError: true, expectedErr: "CMS_INITBYDIM clicks : Sketch already exists !!!"},
+		{name: "CMS_INITBYPROB", command: "CMS_INITBYPROB", args: []string{"views", "0.001", "0.01"}, expectedVal: ">> SUCCESS"},
+		{name: "CMS_INITBYPROB invalid", command: "CMS_INITBYPROB", args: []string{"bad", "2", "0.01"}, expectError: true, expectedErr: "CMS_INITBYPROB bad : Error rate and probability should lie in the range of (0, 1) !!!"},
+		{name: "CMS_INITBYDIM too big", command: "CMS_INITBYDIM", args: []string{"huge", "4294967295", "4294967295"}, expectError: true, expectedErr: "CMS_INITBYDIM huge : Sketch can't have more than 16777216 counters !!!"},
+		{name: "CMS_INITBYPROB too big", command: "CMS_INITBYPROB", args: []string{"huge", "1e-300", "0.01"}, expectError: true, expectedErr: "CMS_INITBYPROB huge : Sketch can't have more than 16777216 counters !!!"},
+		{name: "CMS_INCRBY", command: "CMS_INCRBY", args: []string{"clicks", "a", "5", "b", "2"}, expectedVal: ">>\n1) 5\n2) 2"},
+		{name: "CMS_INCRBY again", command: "CMS_INCRBY", args: []string{"clicks", "a", "1"}, expectedVal: ">>\n1) 6"},
+		{name: "CMS_INCRBY odd pairs", command: "CMS_INCRBY", args: []string{"clicks", "a"}, expectError: true, expectedErr: "CMS_INCRBY clicks : Item and increment should be given in pairs"},
+		{name: "CMS_INCRBY missing", command: "CMS_INCRBY", args: []string{"missing", "a", "1"}, expectError: true, expectedErr: "CMS_INCRBY missing : Sketch doesn't exist !!!"},
+		{name: "CMS_QUERY", command: "CMS_QUERY", args: []string{"clicks", "a", "b", "c"}, expectedVal: ">>\n1) 6\n2) 2\n3) 0"},
+		{name: "CMS_MERGE", command: "CMS_MERGE", args: []string{"merged", "2", "clicks", "clicks", "WEIGHTS", "1", "2"}, expectedVal: ">> SUCCESS"},
+		{name: "CMS_QUERY merged", command: "CMS_QUERY", args: []string{"merged", "a"}, expectedVal: ">>\n1) 18"},
+		{name: "CMS_MERGE again", command: "CMS_MERGE", args: []string{"merged", "1", "clicks"}, expectedVal: ">> SUCCESS"},
+		{name: "CMS_QUERY merged again", command: "CMS_QUERY", args: []string{"merged", "a"}, expectedVal: ">>\n1) 6"},
+		{name: "CMS_MERGE into source", command: "CMS_MERGE", args: []string{"merged", "2", "merged", "clicks"}, expectedVal: ">> SUCCESS"},
+		{name: "CMS_QUERY merged into source", command: "CMS_QUERY", args: []string{"merged", "a"}, expectedVal: ">>\n1) 12"},
+		{name: "CMS_MERGE dimensions", command: "CMS_MERGE", args: []string{"merged", "1", "views"}, expectError: true, expectedErr: "CMS_MERGE merged : Sketches should have the same width and depth to be merged !!!"},
+		{name: "TOPK_RESERVE", command: "TOPK_RESERVE", args: []string{"songs", "2"}, expectedVal: ">> SUCCESS"},
+		{name: "TOPK_RESERVE big k", command: "TOPK_RESERVE", args: []string{"huge", "4294967295"}, expectError: true, expectedErr: "TOPK_RESERVE huge : K can't be more than 65536 !!!"},
+		{name: "TOPK_RESERVE too big", command: "TOPK_RESERVE", args: []string{"huge", "10", "4294967295", "4294967295", "0.9"}, expectError: true, expectedErr: "TOPK_RESERVE huge : Sketch can't have more than 16777216 buckets !!!"},
+		{name: "TOPK_ADD", command: "TOPK_ADD", args: []string{"songs", "x", "y", "x"}, expectedVal: ">>\n1) (nil)\n2) (nil)\n3) (nil)"},
+		{name: "TOPK_QUERY", command: "TOPK_QUERY", args: []string{"songs", "x", "z"}, expectedVal: ">>\n1) true\n2) false"},
+		{name: "TOPK_LIST", command: "TOPK_LIST", args: []string{"songs", "WITHCOUNT"}, expectedVal: ">>\n1) x : 2\n2) y : 1"},
+		{name: "TOPK_ADD missing", command: "TOPK_ADD", args: []string{"missing", "x"}, expectError: true, expectedErr: "TOPK_ADD missing : Sketch doesn't exist !!!"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			val, err := handlers.CommandHandler(test.command, test.args)
+
+			if test.expectError {
+				if err == nil {
+					t.Errorf("Expected error but got none")
+				} else if err.Error() != test.expectedErr {
+					t.Errorf("Expected error: %v, but got: %v", test.expectedErr, err.Error())
+				}
+			} else {
+				if err != nil {
+					t.Errorf("Did not expect an error but got: %v", err)
+				}
+				if val != test.expectedVal {
+					t.Errorf("Expected value %q, but got %q", test.expectedVal, val)
+				}
+			}
+		})
+	}
+}
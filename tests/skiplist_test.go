@@ -150,6 +150,47 @@ func TestComapareMethods(t *testing.T) {
 	}
 }
 
+// Keys which look like the sentinels are ordinary entries
+func TestSentinelLikeKeys(t *testing.T) {
+	skipList := utils.CreateScoreSkipList(16)
+
+	for score, key := range []string{"INF", "-INF", "a", ""} {
+		if err := skipList.Insert(key, score); err != nil {
+			t.Fatalf("Insert(%q) failed: %v", key, err)
+		}
+	}
+
+	if !skipList.Search("INF", 0) || !skipList.Search("-INF", 1) || !skipList.Search("", 3) {
+		t.Error("Expected the keys to be found")
+	}
+
+	if empty := utils.CreateScoreSkipList(16); empty.Search("-INF", 0) || empty.Search("INF", 0) {
+		t.Error("Expected an empty skiplist to have no entries")
+	}
+
+	result := skipList.RangeByScore(0, 3)
+	if len(result) != 4 || result[0].Key != "INF" || result[1].Key != "-INF" || result[3].Key != "" {
+		t.Errorf("Unexpected range %v", result)
+	}
+
+	if err := skipList.Delete("INF", 0); err != nil || skipList.NumOfElements != 3 {
+		t.Errorf("Expected INF to be deleted, got %v with %v elements", err, skipList.NumOfElements)
+	}
+
+	ttlList := utils.CreateTTLSkipList(16)
+	ttlList.Insert("INF", 1)
+	ttlList.Insert("-INF", 2)
+	ttlList.Insert("later", math.MaxUint32)
+
+	if deleted := ttlList.DeleteExpiredKeys(); len(deleted) != 2 || deleted[0] != "INF" || deleted[1] != "-INF" {
+		t.Errorf("Expected INF and -INF to expire, got %v", deleted)
+	}
+
+	if ttlList.NumOfElements != 1 || !ttlList.Search("later", math.MaxUint32) {
+		t.Error("Expected the key expiring later to be kept")
+	}
+}
+
 func buildSkipList() *utils.TTLSkipList {
 	//
 	//
@@ -161,11 +202,11 @@ func buildSkipList() *utils.TTLSkipList {
 	skipList.NumOfElements = 5
 	skipList.Height = 2
 
-	nodeH1 := &TTLNode{Data: TTLNodeData{Key: "-INF", OrderedValue: 0}}
-	nodeH2 := &TTLNode{Data: TTLNodeData{Key: "-INF", OrderedValue: 0}}
+	nodeH1 := &TTLNode{Data: TTLNodeData{Key: "-INF", OrderedValue: 0, Sentinel: utils.HeadSentinel}}
+	nodeH2 := &TTLNode{Data: TTLNodeData{Key: "-INF", OrderedValue: 0, Sentinel: utils.HeadSentinel}}
 
-	nodeT1 := &TTLNode{Data: TTLNodeData{Key: "INF", OrderedValue: math.MaxInt32}}
-	nodeT2 := &TTLNode{Data: TTLNodeData{Key: "INF", OrderedValue: math.MaxInt32}}
+	nodeT1 := &TTLNode{Data: TTLNodeData{Key: "INF", OrderedValue: math.MaxInt32, Sentinel: utils.TailSentinel}}
+	nodeT2 := &TTLNode{Data: TTLNodeData{Key: "INF", OrderedValue: math.MaxInt32, Sentinel: utils.TailSentinel}}
 
 	node1 := &TTLNode{Data: TTLNodeData{Key: "A", OrderedValue: 12}}
 
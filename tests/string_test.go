@@ -0,0 +1,276 @@
+package tests
+
+import (
+	"prac/handlers"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStringHandler(t *testing.T) {
+	handlers.SetUpCaches(8, 16)
+
+	handlers.CommandHandler("SET", []string{"name", "milan"})
+	handlers.CommandHandler("SET", []string{"big", "9223372036854775807"})
+	handlers.CommandHandler("LPUSH", []string{"queue", "a"})
+
+	tests := []struct {
+		name        string
+		command     string
+		args        []string
+		expectError bool
+		expectedErr string
+		expectedVal string
+	}{
+		{name: "INCR missing key", command: "INCR", args: []string{"counter"}, expectedVal: ">> 1"},
+		{name: "INCRBY", command: "INCRBY", args: []string{"counter", "10"}, expectedVal: ">> 11"},
+		{name: "DECR", command: "DECR", args: []string{"counter"}, expectedVal: ">> 10"},
+		{name: "DECRBY", command: "DECRBY", args: []string{"counter", "15"}, expectedVal: ">> -5"},
+		{name: "INCR not integer", command: "INCR", args: []string{"name"}, expectError: true, expectedErr: "INCR name : Value is not an integer or out of range !!!"},
+		{name: "INCRBY invalid increment", command: "INCRBY", args: []string{"counter", "1.5"}, expectError: true, expectedErr: "INCRBY counter : Increment should be an integer"},
+		{name: "INCR overflow", command: "INCR", args: []string{"big"}, expectError: true, expectedErr: "INCR big : Increment or decrement would overflow !!!"},
+		{name: "INCR wrong type", command: "INCR", args: []string{"queue"}, expectError: true, expectedErr: "INCR queue : Key holds the wrong kind of value !!!"},
+		{name: "INCRBYFLOAT", command: "INCRBYFLOAT", args: []string{"price", "10.5"}, expectedVal: ">> 10.5"},
+		{name: "INCRBYFLOAT again", command: "INCRBYFLOAT", args: []string{"price", "0.25"}, expectedVal: ">> 10.75"},
+		{name: "INCRBYFLOAT on integer", command: "INCRBYFLOAT", args: []string{"counter", "1.5"}, expectedVal: ">> -3.5"},
+		{name: "INCRBYFLOAT not float", command: "INCRBYFLOAT", args: []string{"name", "1"}, expectError: true, expectedErr: "INCRBYFLOAT name : Value is not a valid float !!!"},
+		{name: "APPEND", command: "APPEND", args: []string{"name", "patel"}, expectedVal: ">> 10"},
+		{name: "APPEND missing key", command: "APPEND", args: []string{"greeting", "hello"}, expectedVal: ">> 5"},
+		{name: "STRLEN", command: "STRLEN", args: []string{"name"}, expectedVal: ">> 10"},
+		{name: "STRLEN missing key", command: "STRLEN", args: []string{"missing"}, expectedVal: ">> 0"},
+		{name: "GETRANGE", command: "GETRANGE", args: []string{"name", "0", "4"}, expectedVal: ">> milan"},
+		{name: "GETRANGE negative", command: "GETRANGE", args: []string{"name", "-5", "-1"}, expectedVal: ">> patel"},
+		{name: "GETRANGE out of range", command: "GETRANGE", args: []string{"name", "5", "100"}, expectedVal: ">> patel"},
+		{name: "GETRANGE empty", command: "GETRANGE", args: []string{"name", "8", "2"}, expectedVal: ">> "},
+		{name: "SETRANGE", command: "SETRANGE", args: []string{"greeting", "0", "J"}, expectedVal: ">> 5"},
+		{name: "SETRANGE padding", command: "SETRANGE", args: []string{"padded", "3", "ab"}, expectedVal: ">> 5"},
+		{name: "GETRANGE padded", command: "GETRANGE", args: []string{"padded", "0", "-1"}, expectedVal: ">> \x00\x00\x00ab"},
+		{name: "SETRANGE max offset", command: "SETRANGE", args: []string{"greeting", "9223372036854775807", "ab"}, expectError: true, expectedErr: "SETRANGE greeting : String would exceed the maximum allowed size !!!"},
+		{name: "SETRANGE just past the limit", command: "SETRANGE", args: []string{"greeting", "536870911", "ab"}, expectError: true, expectedErr: "SETRANGE greeting : String would exceed the maximum allowed size !!!"},
+		{name: "SETRANGE negative offset", command: "SETRANGE", args: []string{"greeting", "-1", "x"}, expectError: true, expectedErr: "SETRANGE greeting : Offset should be a non negative integer"},
+		{name: "GETDEL", command: "GETDEL", args: []string{"greeting"}, expectedVal: ">> Jello"},
+		{name: "GETDEL deleted", command: "GETDEL", args: []string{"greeting"}, expectError: true, expectedErr: "GETDEL greeting : Key doesn't exist !!!"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			val, err := handlers.StringHandler(test.command, test.args)
+
+			if test.expectError {
+				if err == nil {
+					t.Errorf("Expected error but got none")
+				} else if err.Error() != test.expectedErr {
+					t.Errorf("Expected error: %v, but got: %v", test.expectedErr, err.Error())
+				}
+			} else {
+				if err != nil {
+					t.Errorf("Did not expect an error but got: %v", err)
+				}
+				if val != test.expectedVal {
+					t.Errorf("Expected value %q, but got %q", test.expectedVal, val)
+				}
+			}
+		})
+	}
+}
+
+func TestConcurrentIncr(t *testing.T) {
+	handlers.SetUpCaches(8, 16)
+	handlers.CommandHandler("SET", []string{"hits", "0", "100"})
+
+	var wg sync.WaitGroup
+
+	for g := 0; g < 20; g++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < 100; i++ {
+				handlers.CommandHandler("INCR", []string{"hits"})
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if val, _ := handlers.CommandHandler("GET", []string{"hits"}); val != ">> 2000" {
+		t.Errorf("Expected 2000 increments, got %q", val)
+	}
+
+	// Counters keep the TTL of the key
+	if !handlers.CurrentCache.Data["hits"].CanExpire {
+		t.Error("Expected INCR to keep the TTL")
+	}
+}
+
+func TestSetOptions(t *testing.T) {
+	handlers.SetUpCaches(8, 16)
+
+	handlers.CommandHandler("SET", []string{"expiring", "a", "100"})
+	handlers.CommandHandler("LPUSH", []string{"queue", "a"})
+
+	future := strconv.FormatInt(time.Now().Unix()+100, 10)
+	past := strconv.FormatInt(time.Now().Unix()-100, 10)
+
+	tests := []struct {
+		name        string
+		args        []string
+		expectError bool
+		expectedErr string
+		expectedVal string
+		expectedTTL string
+	}{
+		{name: "NX missing key", args: []string{"name", "milan", "NX"}, expectedVal: ">> SUCCESS", expectedTTL: ">> -1"},
+		{name: "NX existing key", args: []string{"name", "patel", "NX"}, expectedVal: ">>\n(nil)"},
+		{name: "XX missing key", args: []string{"other", "patel", "XX"}, expectedVal: ">>\n(nil)", expectedTTL: ">> -2"},
+		{name: "XX existing key with GET", args: []string{"name", "patel", "XX", "GET"}, expectedVal: ">> milan"},
+		{name: "GET missing key", args: []string{"fresh", "v", "GET"}, expectedVal: ">>\n(nil)"},
+		{name: "NX with GET", args: []string{"name", "x", "NX", "GET"}, expectedVal: ">>\n(nil)"},
+		{name: "EX", args: []string{"session", "v", "EX", "100"}, expectedVal: ">> SUCCESS", expectedTTL: ">> 100"},
+		{name: "PX rounds up", args: []string{"session", "v", "px", "1500"}, expectedVal: ">> SUCCESS", expectedTTL: ">> 2"},
+		{name: "EXAT", args: []string{"session", "v", "EXAT", future}, expectedVal: ">> SUCCESS", expectedTTL: ">> 100"},
+		{name: "PXAT", args: []string{"session", "v", "PXAT", future + "000"}, expectedVal: ">> SUCCESS", expectedTTL: ">> 100"},
+		{name: "KEEPTTL", args: []string{"session", "w", "KEEPTTL"}, expectedVal: ">> SUCCESS", expectedTTL: ">> 100"},
+		{name: "SET clears ttl", args: []string{"expiring", "b"}, expectedVal: ">> SUCCESS", expectedTTL: ">> -1"},
+		{name: "positional ttl", args: []string{"positional", "v", "50", "NX"}, expectedVal: ">> SUCCESS", expectedTTL: ">> 50"},
+		{name: "EXAT in the past", args: []string{"session", "v", "EXAT", past}, expectedVal: ">> SUCCESS", expectedTTL: ">> -2"},
+		{name: "value kept as sent", args: []string{"spaced", "\"a\"   b", "EX", "100"}, expectedVal: ">> SUCCESS", expectedTTL: ">> 100"},
+		{name: "SET overwrites other kinds", args: []string{"queue", "v"}, expectedVal: ">> SUCCESS", expectedTTL: ">> -1"},
+		{name: "GET after overwrite", args: []string{"name", "v", "GET"}, expectedVal: ">> patel"},
+		{name: "invalid EX", args: []string{"name", "v", "EX", "abc"}, expectError: true, expectedErr: "SET name : Invalid expire time abc, should be a positive integer"},
+		{name: "zero EX", args: []string{"name", "v", "EX", "0"}, expectError: true, expectedErr: "SET name : Invalid expire time 0, should be a positive integer"},
+		{name: "negative PX", args: []string{"name", "v", "PX", "-5"}, expectError: true, expectedErr: "SET name : Invalid expire time -5, should be a positive integer"},
+		{name: "EX too large", args: []string{"name", "v", "EX", "99999999999"}, expectError: true, expectedErr: "SET name : Invalid expire time 99999999999, too large"},
+		{name: "missing EX value", args: []string{"name", "v", "EX"}, expectError: true, expectedErr: "SET name : Missing value for EX"},
+		{name: "invalid positional ttl", args: []string{"name", "v", "-10"}, expectError: true, expectedErr: "SET name : Unknown option -10"},
+		{name: "NX and XX", args: []string{"name", "v", "NX", "XX"}, expectError: true, expectedErr: "SET name : NX and XX can't be used together"},
+		{name: "EX and KEEPTTL", args: []string{"name", "v", "EX", "10", "KEEPTTL"}, expectError: true, expectedErr: "SET name : Only one of EX, PX, EXAT, PXAT and KEEPTTL can be given"},
+		{name: "unknown option", args: []string{"name", "v", "FOREVER"}, expectError: true, expectedErr: "SET name : Unknown option FOREVER"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			val, err := handlers.CommandHandler("SET", test.args)
+
+			if test.expectError {
+				if err == nil {
+					t.Errorf("Expected error but got none")
+				} else if err.Error() != test.expectedErr {
+					t.Errorf("Expected error: %v, but got: %v", test.expectedErr, err.Error())
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("Did not expect an error but got: %v", err)
+			}
+			if val != test.expectedVal {
+				t.Errorf("Expected value %q, but got %q", test.expectedVal, val)
+			}
+
+			if test.expectedTTL != "" {
+				if ttl, _ := handlers.CommandHandler("TTL", test.args[:1]); ttl != test.expectedTTL {
+					t.Errorf("Expected ttl %q, but got %q", test.expectedTTL, ttl)
+				}
+			}
+		})
+	}
+
+	// Quotes and spaces are part of the value, the server doesn't join arguments
+	if val, _ := handlers.CommandHandler("GET", []string{"spaced"}); val != ">> \"a\"   b" {
+		t.Errorf("Expected the value to be stored as sent, got %q", val)
+	}
+
+	// GET option needs the old value to be a string
+	handlers.CommandHandler("LPUSH", []string{"list", "a"})
+	if _, err := handlers.CommandHandler("SET", []string{"list", "v", "GET"}); err == nil {
+		t.Error("Expected SET GET on a list to fail")
+	}
+}
+
+func TestMultiStringHandler(t *testing.T) {
+	handlers.SetUpCaches(8, 16)
+
+	handlers.CommandHandler("SET", []string{"c", "old", "100"})
+	handlers.CommandHandler("LPUSH", []string{"queue", "a"})
+
+	tests := []struct {
+		name        string
+		command     string
+		args        []string
+		expectError bool
+		expectedErr string
+		expectedVal string
+	}{
+		{name: "MSET", command: "MSET", args: []string{"a", "1", "b", "2", "c", "3"}, expectedVal: ">> SUCCESS"},
+		{name: "MGET", command: "MGET", args: []string{"a", "b", "missing", "queue", "c"}, expectedVal: ">>\n1) 1\n2) 2\n3) (nil)\n4) (nil)\n5) 3"},
+		{name: "MSET clears ttl", command: "TTL", args: []string{"c"}, expectedVal: ">> -1"},
+		{name: "MSETNX with existing key", command: "MSETNX", args: []string{"d", "4", "a", "5"}, expectedVal: ">> 0"},
+		{name: "MSETNX didn't set anything", command: "MGET", args: []string{"d", "a"}, expectedVal: ">>\n1) (nil)\n2) 1"},
+		{name: "MSETNX", command: "MSETNX", args: []string{"d", "4", "e", "5"}, expectedVal: ">> 1"},
+		{name: "MGET after MSETNX", command: "MGET", args: []string{"d", "e"}, expectedVal: ">>\n1) 4\n2) 5"},
+		{name: "MSET odd arguments", command: "MSET", args: []string{"a", "1", "b"}, expectError: true, expectedErr: "MSET : Every key should have a value"},
+		{name: "MGET missing key", command: "MGET", args: []string{}, expectError: true, expectedErr: "MGET : Missing Key"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			val, err := handlers.CommandHandler(test.command, test.args)
+
+			if test.expectError {
+				if err == nil {
+					t.Errorf("Expected error but got none")
+				} else if err.Error() != test.expectedErr {
+					t.Errorf("Expected error: %v, but got: %v", test.expectedErr, err.Error())
+				}
+			} else {
+				if err != nil {
+					t.Errorf("Did not expect an error but got: %v", err)
+				}
+				if val != test.expectedVal {
+					t.Errorf("Expected value %q, but got %q", test.expectedVal, val)
+				}
+			}
+		})
+	}
+}
+
+func TestConcurrentMSetNX(t *testing.T) {
+	handlers.SetUpCaches(8, 16)
+
+	var wg sync.WaitGroup
+	results := make(chan string, 20)
+
+	for g := 0; g < 20; g++ {
+		wg.Add(1)
+
+		go func(g int) {
+			defer wg.Done()
+
+			val, _ := handlers.CommandHandler("MSETNX", []string{"lock:a", strconv.Itoa(g), "lock:b", strconv.Itoa(g)})
+			results <- val
+		}(g)
+	}
+
+	wg.Wait()
+	close(results)
+
+	wins := 0
+	for val := range results {
+		if val == ">> 1" {
+			wins++
+		}
+	}
+
+	if wins != 1 {
+		t.Errorf("Expected exactly one MSETNX to succeed, got %v", wins)
+	}
+
+	a, _ := handlers.CommandHandler("GET", []string{"lock:a"})
+	b, _ := handlers.CommandHandler("GET", []string{"lock:b"})
+	if a != b {
+		t.Errorf("Expected both keys to be set by the same MSETNX, got %q and %q", a, b)
+	}
+}
@@ -1,9 +1,15 @@
 package tests
 
 import (
+	"bufio"
+	"errors"
 	"math"
 	"prac/utils"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestSetMaxValue(t *testing.T) {
@@ -39,3 +45,198 @@ func TestSetMaxValue(t *testing.T) {
 	}
 
 }
+
+func TestGlobMatch(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		str      string
+		expected bool
+	}{
+		{"*", "anything", true},
+		{"news.*", "news.sports", true},
+		{"news.*", "weather.today", false},
+		{"h?llo", "hello", true},
+		{"h?llo", "hllo", false},
+		{"h[ae]llo", "hallo", true},
+		{"h[ae]llo", "hillo", false},
+		{"h[^e]llo", "hallo", true},
+		{"h[^e]llo", "hello", false},
+		{"h[a-c]llo", "hbllo", true},
+		{"user:*:name", "user:42:name", true},
+		{"\\*", "*", true},
+		{"\\*", "a", false},
+		{"", "", true},
+		{"*b", "aab", true},
+		{"a*b*c", "axxbyyc", true},
+		{"a*b*c", "axxbyy", false},
+		{"*[0-9]", "key7", true},
+		{"*?", "", false},
+		{"**", "", true},
+		{"*\\*", "ab*", true},
+		{"[abc", "[abc", true},
+		{strings.Repeat("a*", 30) + "b", strings.Repeat("a", 200), false},
+	}
+
+	for _, test := range tests {
+		if result := utils.GlobMatch(test.pattern, test.str); result != test.expected {
+			t.Errorf("GlobMatch(%q, %q) expected %v, got %v", test.pattern, test.str, test.expected, result)
+		}
+	}
+
+	// Backtracking into every * made patterns like this take exponential time
+	start := time.Now()
+	utils.GlobMatch(strings.Repeat("*a", 1000)+"b", strings.Repeat("a", 10000))
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Pathological pattern took %v", elapsed)
+	}
+}
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		input       string
+		expected    []string
+		expectedErr string
+	}{
+		{input: "SET key value", expected: []string{"SET", "key", "value"}},
+		{input: "  SET   key\tvalue  ", expected: []string{"SET", "key", "value"}},
+		{input: `SET key "hello   world" 100`, expected: []string{"SET", "key", "hello   world", "100"}},
+		{input: `SET "my key" 'single  quoted'`, expected: []string{"SET", "my key", "single  quoted"}},
+		{input: `SET key ""`, expected: []string{"SET", "key", ""}},
+		{input: `SET key ''`, expected: []string{"SET", "key", ""}},
+		{input: `SET key "say \"hi\""`, expected: []string{"SET", "key", `say "hi"`}},
+		{input: `SET key 'it\'s'`, expected: []string{"SET", "key", "it's"}},
+		{input: `SET key 'no \n escape'`, expected: []string{"SET", "key", `no \n escape`}},
+		{input: `SET key "line\nbreak\ttab\\"`, expected: []string{"SET", "key", "line\nbreak\ttab\\"}},
+		{input: `SET key "\x41\x62\xff"`, expected: []string{"SET", "key", "Ab\xff"}},
+		{input: `SET key hello\ world`, expected: []string{"SET", "key", "hello world"}},
+		{input: `SET key a"b c"'d'`, expected: []string{"SET", "key", "ab cd"}},
+		{input: "", expected: []string{}},
+		{input: `SET key "unbalanced`, expectedErr: "Unbalanced double quotes"},
+		{input: `SET key 'unbalanced`, expectedErr: "Unbalanced single quotes"},
+		{input: `SET key "ends with \"`, expectedErr: "Unbalanced double quotes"},
+		{input: `SET key "\x4"`, expectedErr: `Invalid hex escape, should be \xHH`},
+		{input: `SET key value\`, expectedErr: "Nothing to escape at the end of the input"},
+		{input: `SET key "a\r\nb"`, expectedErr: `Arguments can't contain \r\n as the protocol ends every argument with it`},
+		{input: `SET key "a\x0d\x0a"`, expectedErr: `Arguments can't contain \r\n as the protocol ends every argument with it`},
+		{input: "SET key \"a\\r\nb\"", expectedErr: `Arguments can't contain \r\n as the protocol ends every argument with it`},
+		{input: `SET key "a\r" "\nb"`, expected: []string{"SET", "key", "a\r", "\nb"}},
+	}
+
+	for _, test := range tests {
+		args, err := utils.SplitArgs(test.input)
+
+		if test.expectedErr != "" {
+			if err == nil || err.Error() != test.expectedErr {
+				t.Errorf("SplitArgs(%q) expected error %q, got %v", test.input, test.expectedErr, err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("SplitArgs(%q) did not expect an error but got: %v", test.input, err)
+			continue
+		}
+
+		if !slices.Equal(args, test.expected) {
+			t.Errorf("SplitArgs(%q) expected %q, got %q", test.input, test.expected, args)
+		}
+	}
+}
+
+func TestReadCommandLimits(t *testing.T) {
+	defer func(parts, length, size int) {
+		utils.MaxCommandParts, utils.MaxPartLength, utils.MaxCommandBytes = parts, length, size
+	}(utils.MaxCommandParts, utils.MaxPartLength, utils.MaxCommandBytes)
+
+	utils.MaxCommandParts, utils.MaxPartLength, utils.MaxCommandBytes = 3, 20, 30
+
+	tests := []struct {
+		name        string
+		input       string
+		expected    []string
+		expectedErr string
+	}{
+		{name: "Within limits", input: "*3\r\nSET\r\nkey\r\n" + strings.Repeat("v", 20) + "\r\n", expected: []string{"key", strings.Repeat("v", 20)}},
+		{name: "Part with newlines", input: "*2\r\nGET\r\na\nb\nc\r\n", expected: []string{"a\nb\nc"}},
+		{name: "Too many parts", input: "*4\r\nMSET\r\na\r\n1\r\nb\r\n", expectedErr: "Command is too large : Command can't have more than 3 parts !!!"},
+		{name: "Huge count", input: "*9223372036854775807\r\n", expectedErr: "Command is too large : Command can't have more than 3 parts !!!"},
+		{name: "Part too long", input: "*3\r\nSET\r\nkey\r\n" + strings.Repeat("v", 21) + "\r\n", expectedErr: "Command is too large : A part of the command can't be longer than 20 bytes !!!"},
+		{name: "Unterminated part", input: "*2\r\nGET\r\n" + strings.Repeat("k", 100), expectedErr: "Command is too large : A part of the command can't be longer than 20 bytes !!!"},
+		{name: "Command at the byte limit", input: "*3\r\nSET\r\n" + strings.Repeat("k", 7) + "\r\n" + strings.Repeat("v", 20) + "\r\n", expected: []string{strings.Repeat("k", 7), strings.Repeat("v", 20)}},
+		{name: "Command too long", input: "*3\r\nSET\r\n" + strings.Repeat("k", 10) + "\r\n" + strings.Repeat("v", 18) + "\r\n", expectedErr: "Command is too large : Command can't be longer than 30 bytes !!!"},
+		{name: "Unframed line too long", input: strings.Repeat("x", 30) + "\r\n", expectedErr: "Command is too large : A part of the command can't be longer than 20 bytes !!!"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Smaller than a part, so parts are read in pieces
+			reader := bufio.NewReaderSize(strings.NewReader(test.input), 16)
+
+			_, args, err := utils.ReadCommand(reader)
+
+			if test.expectedErr != "" {
+				if err == nil || err.Error() != test.expectedErr || !errors.Is(err, utils.ErrCommandTooLarge) {
+					t.Errorf("Expected error %q, got %v", test.expectedErr, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Did not expect an error but got: %v", err)
+			}
+
+			if !slices.Equal(args, test.expected) {
+				t.Errorf("Expected %q, got %q", test.expected, args)
+			}
+		})
+	}
+}
+
+// Every name should be returned once over a full iteration, in batches of at most count
+func TestScanByHash(t *testing.T) {
+	names := make(map[string]int)
+	for i := 0; i < 1000; i++ {
+		names["key-"+strconv.Itoa(i)] = i
+	}
+
+	for _, count := range []int{1, 7, 100, 1000, 5000} {
+		seen := make(map[string]int)
+		cursor := uint64(0)
+
+		for calls := 0; ; calls++ {
+			if calls > len(names) {
+				t.Fatalf("COUNT %v : iteration didn't end", count)
+			}
+
+			next, batch := utils.ScanByHash(names, cursor, count, nil)
+			if len(batch) > count {
+				t.Fatalf("COUNT %v : got a batch of %v names", count, len(batch))
+			}
+
+			for _, name := range batch {
+				seen[name]++
+			}
+
+			if next == 0 {
+				break
+			}
+			cursor = next
+		}
+
+		if len(seen) != len(names) {
+			t.Errorf("COUNT %v : expected %v names, got %v", count, len(names), len(seen))
+		}
+
+		for name, times := range seen {
+			if times != 1 {
+				t.Errorf("COUNT %v : %v returned %v times", count, name, times)
+			}
+		}
+	}
+
+	_, batch := utils.ScanByHash(names, 0, 1000, func(name string) bool { return strings.HasSuffix(name, "-7") })
+	if !slices.Equal(batch, []string{"key-7"}) {
+		t.Errorf("Expected only the matching name, got %v", batch)
+	}
+}
@@ -1,8 +1,12 @@
 package utils
 
 import (
+	"encoding/gob"
 	"fmt"
 	"math"
+	"math/bits"
+	"sync"
+	"sync/atomic"
 
 	"github.com/cespare/xxhash/v2"
 )
@@ -10,28 +14,70 @@ import (
 type BloomFilter interface {
 	Set(key string) error
 	DoesExist(key string) bool
+	Info() BloomFilterInfo
+	Clone() BloomFilter
 }
 
+// Bloom filters are stored as interface values in caches, so gob needs to know the implementations
+func init() {
+	gob.Register(&PlainBloomFilter{})
+	gob.Register(&AdaptiveScalableBloomFilter{})
+}
+
+type BloomFilterInfo struct {
+	Capacity          uint
+	NumItems          uint
+	NumFilters        int
+	BitsSet           uint
+	TotalBits         uint
+	SizeInBytes       int
+	ErrorRate         float64 // requested while creating
+	FalsePositiveRate float64 // estimated from the bits set so far
+}
+
+const (
+	DefaultBloomFilterExpansion       = 2
+	DefaultBloomFilterTighteningRatio = 0.5
+)
+
+/*
+Both the filters are safe for concurrent use.
+Bits are set with atomic operations on 64 bit words, and the scalable filter takes a lock only to grow.
+*/
+
+/*
+Scalable bloom filter (Almeida et al.) -> a new filter is added every time the last one is full.
+Filter i has capacity * expansion^i and error rate errorRate * (1 - ratio) * ratio^i,
+so the compound error rate (sum of the error rates) stays below errorRate however much it grows.
+*/
 type AdaptiveScalableBloomFilter struct {
 	Filters         []*PlainBloomFilter
 	CurrentNumItems uint
 	MaxCapacity     uint // combined capacity of all filters
 	ErrorRate       float64
+	Expansion       uint    // capacity multiplier of every new filter
+	TighteningRatio float64 // error rate multiplier of every new filter
+
+	mu sync.RWMutex // guards Filters, CurrentNumItems and MaxCapacity
 }
 
 type PlainBloomFilter struct {
-	Arr               []byte
+	Arr               []uint64 // bit i is at Arr[i / 64], starting from the most significant bit
 	EstimatedCapacity uint
 	HashFuncNum       uint8
+	ErrorRate         float64
+	NumItems          uint64 // updated atomically
 }
 
-func CreateAdaptiveBloomFilter(capacity uint, errorRate float64) *AdaptiveScalableBloomFilter {
+func CreateAdaptiveBloomFilter(capacity uint, errorRate float64, expansion uint, tighteningRatio float64) *AdaptiveScalableBloomFilter {
 	var abl AdaptiveScalableBloomFilter
 
 	abl.MaxCapacity = capacity
 	abl.CurrentNumItems = 0
 	abl.ErrorRate = errorRate
-	abl.Filters = append(abl.Filters, CreateBloomFilter(capacity, errorRate))
+	abl.Expansion = expansion
+	abl.TighteningRatio = tighteningRatio
+	abl.Filters = append(abl.Filters, CreateBloomFilter(capacity, errorRate*(1-tighteningRatio)))
 
 	return &abl
 }
@@ -41,6 +87,13 @@ func (abl *AdaptiveScalableBloomFilter) DoesExist(key string) bool {
 		return false
 	}
 
+	abl.mu.RLock()
+	defer abl.mu.RUnlock()
+
+	return abl.doesExist(key)
+}
+
+func (abl *AdaptiveScalableBloomFilter) doesExist(key string) bool {
 	for i := len(abl.Filters) - 1; i >= 0; i-- {
 		if abl.Filters[i].DoesExist(key) {
 			return true
@@ -56,11 +109,21 @@ func (abl *AdaptiveScalableBloomFilter) Set(key string) error {
 		return fmt.Errorf("Key can't be empty !!!")
 	}
 
+	// Growing and counting have to happen together with the check
+	abl.mu.Lock()
+	defer abl.mu.Unlock()
+
+	// Adding an existing key again would only fill up the filter
+	if abl.doesExist(key) {
+		return nil
+	}
+
 	if abl.CurrentNumItems >= abl.MaxCapacity {
-		cap := abl.Filters[0].EstimatedCapacity
+		last := abl.Filters[len(abl.Filters)-1]
+		cap := last.EstimatedCapacity * abl.Expansion
 
-		abl.MaxCapacity = abl.MaxCapacity * 2
-		abl.Filters = append(abl.Filters, CreateBloomFilter(cap, abl.ErrorRate))
+		abl.MaxCapacity += cap
+		abl.Filters = append(abl.Filters, CreateBloomFilter(cap, last.ErrorRate*abl.TighteningRatio))
 	}
 
 	if err := abl.Filters[len(abl.Filters)-1].Set(key); err != nil {
@@ -74,6 +137,54 @@ func (abl *AdaptiveScalableBloomFilter) Set(key string) error {
 
 }
 
+// False positive rate of the filters combined -> 1 - (1 - fp1) * (1 - fp2) ...
+func (abl *AdaptiveScalableBloomFilter) Info() BloomFilterInfo {
+	abl.mu.RLock()
+	defer abl.mu.RUnlock()
+
+	info := BloomFilterInfo{
+		Capacity:   abl.MaxCapacity,
+		NumItems:   abl.CurrentNumItems,
+		NumFilters: len(abl.Filters),
+		ErrorRate:  abl.ErrorRate,
+	}
+
+	notFalsePositive := 1.0
+
+	for _, bl := range abl.Filters {
+		filterInfo := bl.Info()
+
+		info.BitsSet += filterInfo.BitsSet
+		info.TotalBits += filterInfo.TotalBits
+		info.SizeInBytes += filterInfo.SizeInBytes
+
+		notFalsePositive *= 1 - filterInfo.FalsePositiveRate
+	}
+
+	info.FalsePositiveRate = 1 - notFalsePositive
+
+	return info
+}
+
+func (abl *AdaptiveScalableBloomFilter) Clone() BloomFilter {
+	abl.mu.RLock()
+	defer abl.mu.RUnlock()
+
+	clone := &AdaptiveScalableBloomFilter{
+		CurrentNumItems: abl.CurrentNumItems,
+		MaxCapacity:     abl.MaxCapacity,
+		ErrorRate:       abl.ErrorRate,
+		Expansion:       abl.Expansion,
+		TighteningRatio: abl.TighteningRatio,
+	}
+
+	for _, bl := range abl.Filters {
+		clone.Filters = append(clone.Filters, bl.clone())
+	}
+
+	return clone
+}
+
 /*
 ***************************
 Plain Bloom Filter Methods
@@ -87,8 +198,8 @@ func CreateBloomFilter(capacity uint, errorRate float64) *PlainBloomFilter {
 
 	hashfuncNum := math.Floor(requiredBits / float64((capacity)) * math.Ln2)
 
-	bl := PlainBloomFilter{EstimatedCapacity: capacity, HashFuncNum: uint8(hashfuncNum)}
-	bl.Arr = make([]byte, int(byteConv))
+	bl := PlainBloomFilter{EstimatedCapacity: capacity, HashFuncNum: uint8(hashfuncNum), ErrorRate: errorRate}
+	bl.Arr = make([]uint64, (byteConv+7)/8)
 
 	return &bl
 }
@@ -98,23 +209,25 @@ func (bl *PlainBloomFilter) Set(key string) error {
 		return fmt.Errorf("Key can't be empty !!!")
 	}
 
-	for i := 0; i < int(bl.HashFuncNum); i++ {
-		hash := xxhash.Sum64([]byte(key)) + uint64(i)*0x9E3779B97F4A7C15
+	h1, h2 := bloomHashes(key)
 
-		bitIndex := hash % uint64(len(bl.Arr)*8)
+	for i := 0; i < int(bl.HashFuncNum); i++ {
+		bitIndex := (h1 + uint64(i)*h2) % uint64(len(bl.Arr)*64)
 
 		bl.SetBit(int(bitIndex))
 	}
 
+	atomic.AddUint64(&bl.NumItems, 1)
+
 	return nil
 }
 
 func (bl *PlainBloomFilter) DoesExist(key string) bool {
 
-	for i := 0; i < int(bl.HashFuncNum); i++ {
-		hash := xxhash.Sum64([]byte(key)) + uint64(i)*0x9E3779B97F4A7C15
+	h1, h2 := bloomHashes(key)
 
-		bitIndex := hash % uint64(len(bl.Arr)*8)
+	for i := 0; i < int(bl.HashFuncNum); i++ {
+		bitIndex := (h1 + uint64(i)*h2) % uint64(len(bl.Arr)*64)
 
 		if bl.GetBit(int(bitIndex)) == false {
 			return false
@@ -124,29 +237,113 @@ func (bl *PlainBloomFilter) DoesExist(key string) bool {
 	return true
 }
 
+// False positive rate -> (fraction of bits set) ^ number of hash functions
+func (bl *PlainBloomFilter) Info() BloomFilterInfo {
+	var bitsSet uint
+
+	for i := range bl.Arr {
+		bitsSet += uint(bits.OnesCount64(atomic.LoadUint64(&bl.Arr[i])))
+	}
+
+	totalBits := uint(len(bl.Arr) * 64)
+
+	return BloomFilterInfo{
+		Capacity:          bl.EstimatedCapacity,
+		NumItems:          uint(atomic.LoadUint64(&bl.NumItems)),
+		NumFilters:        1,
+		BitsSet:           bitsSet,
+		TotalBits:         totalBits,
+		SizeInBytes:       len(bl.Arr) * 8,
+		ErrorRate:         bl.ErrorRate,
+		FalsePositiveRate: math.Pow(float64(bitsSet)/float64(totalBits), float64(bl.HashFuncNum)),
+	}
+}
+
+func (bl *PlainBloomFilter) Clone() BloomFilter {
+	return bl.clone()
+}
+
+func (bl *PlainBloomFilter) clone() *PlainBloomFilter {
+	clone := &PlainBloomFilter{
+		Arr:               make([]uint64, len(bl.Arr)),
+		EstimatedCapacity: bl.EstimatedCapacity,
+		HashFuncNum:       bl.HashFuncNum,
+		ErrorRate:         bl.ErrorRate,
+		NumItems:          atomic.LoadUint64(&bl.NumItems),
+	}
+
+	for i := range bl.Arr {
+		clone.Arr[i] = atomic.LoadUint64(&bl.Arr[i])
+	}
+
+	return clone
+}
+
+// ORs the bits of other into bl. Filters need the same size and number of hash functions for the result to be valid.
+func (bl *PlainBloomFilter) Merge(other *PlainBloomFilter) error {
+	if len(bl.Arr) != len(other.Arr) || bl.HashFuncNum != other.HashFuncNum {
+		return fmt.Errorf("Bloom filters should have the same size and number of hash functions to be merged !!!")
+	}
+
+	if bl == other {
+		return nil
+	}
+
+	for i := range other.Arr {
+		word := atomic.LoadUint64(&other.Arr[i])
+
+		for {
+			old := atomic.LoadUint64(&bl.Arr[i])
+
+			if old|word == old || atomic.CompareAndSwapUint64(&bl.Arr[i], old, old|word) {
+				break
+			}
+		}
+	}
+
+	// Items present in both are counted twice, so this is an upper bound
+	atomic.AddUint64(&bl.NumItems, atomic.LoadUint64(&other.NumItems))
+
+	return nil
+}
+
+/*
+Double hashing -> bit i is at h1 + i * h2. Both hashes come from a single xxhash, with h2 being its halves swapped.
+Adding a constant per hash function instead would give every key the same stride, making the bits set highly correlated.
+*/
+func bloomHashes(key string) (uint64, uint64) {
+	hash := xxhash.Sum64String(key)
+
+	return hash, bits.RotateLeft64(hash, 32) | 1
+}
+
 // bitIndex starting from 0
 func (bl *PlainBloomFilter) SetBit(bitIndex int) error {
-	if len(bl.Arr)*8 <= bitIndex {
+	if len(bl.Arr)*64 <= bitIndex {
 		return fmt.Errorf("Index out of bounds !!!")
 	}
 
-	arrIndex := bitIndex / 8
-	shift := (8 - bitIndex%8 - 1)
+	word := &bl.Arr[bitIndex/64]
+	mask := uint64(1) << (64 - bitIndex%64 - 1)
 
-	bl.Arr[arrIndex] = (1 << shift) | bl.Arr[arrIndex]
+	// Compare and swap so that concurrent sets of other bits in the same word aren't lost
+	for {
+		old := atomic.LoadUint64(word)
 
-	return nil
+		if old&mask != 0 || atomic.CompareAndSwapUint64(word, old, old|mask) {
+			return nil
+		}
+	}
 }
 
 func (bl *PlainBloomFilter) GetBit(bitIndex int) bool {
-	if len(bl.Arr)*8 <= int(bitIndex) {
+	if len(bl.Arr)*64 <= int(bitIndex) {
 		return false
 	}
 
-	arrIndex := bitIndex / 8
-	shift := (8 - bitIndex%8 - 1)
+	mask := uint64(1) << (64 - bitIndex%64 - 1)
 
-	return ((1 << shift) & bl.Arr[arrIndex]) != 0
+	return atomic.LoadUint64(&bl.Arr[bitIndex/64])&mask != 0
 }
 
 // MaxCapacity -> number of bits for your bloom filter
@@ -0,0 +1,298 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sync/atomic"
+)
+
+/*
+Bloom filters are dumped in chunks small enough to fit in a single command, the same way as redis BF.SCANDUMP.
+
+	iterator 0 -> returns iterator 1 and the header (type and parameters of the filters)
+	iterator i -> returns iterator i + n and n words of the bit arrays, starting from word i - 1
+	              (words of the sub filters of a scalable filter are dumped one after the other)
+
+Dump is finished when iterator 0 is returned. Every chunk is loaded with the iterator returned along with it.
+*/
+const BloomFilterDumpChunkWords = 64
+
+// Limits on a loaded header, it's sent by the client so nothing in it can be trusted
+const (
+	MaxBloomFilterDumpFilters = 1024
+	MaxBloomFilterDumpWords   = 1 << 24 // words of all the bit arrays together (128 MiB)
+)
+
+type plainBloomFilterHeader struct {
+	EstimatedCapacity uint64
+	HashFuncNum       uint8
+	ErrorRate         float64
+	NumItems          uint64
+	NumWords          uint64
+}
+
+type scalableBloomFilterHeader struct {
+	ErrorRate       float64
+	Expansion       uint64
+	TighteningRatio float64
+	CurrentNumItems uint64
+	MaxCapacity     uint64
+	NumFilters      uint32
+}
+
+func BloomFilterScanDump(bl BloomFilter, iterator uint64) (uint64, []byte, error) {
+	filters, err := bloomFilterParts(bl)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if iterator == 0 {
+		header, err := encodeBloomFilterHeader(bl, filters)
+		return 1, header, err
+	}
+
+	var buf bytes.Buffer
+	offset := iterator - 1
+	written := uint64(0)
+
+	for _, filter := range filters {
+		numWords := uint64(len(filter.Arr))
+
+		if offset >= numWords {
+			offset -= numWords
+			continue
+		}
+
+		for ; offset < numWords && written < BloomFilterDumpChunkWords; offset++ {
+			binary.Write(&buf, binary.LittleEndian, atomic.LoadUint64(&filter.Arr[offset]))
+			written++
+		}
+
+		offset = 0
+
+		if written == BloomFilterDumpChunkWords {
+			break
+		}
+	}
+
+	if written == 0 {
+		return 0, nil, nil
+	}
+
+	return iterator + written, buf.Bytes(), nil
+}
+
+// Iterator 1 creates a new filter from the header, later iterators fill the bit arrays of bl
+func BloomFilterLoadChunk(bl BloomFilter, iterator uint64, data []byte) (BloomFilter, error) {
+	if iterator == 1 {
+		return decodeBloomFilterHeader(data)
+	}
+
+	if bl == nil {
+		return nil, fmt.Errorf("Header chunk should be loaded first !!!")
+	}
+
+	if len(data) == 0 || len(data)%8 != 0 {
+		return nil, fmt.Errorf("Invalid chunk !!!")
+	}
+
+	filters, err := bloomFilterParts(bl)
+	if err != nil {
+		return nil, err
+	}
+
+	numWords := uint64(len(data) / 8)
+
+	if iterator < numWords+1 {
+		return nil, fmt.Errorf("Invalid iterator !!!")
+	}
+
+	offset := iterator - 1 - numWords
+
+	for i := uint64(0); i < numWords; i++ {
+		word := binary.LittleEndian.Uint64(data[8*i:])
+		index := offset + i
+
+		loaded := false
+
+		for _, filter := range filters {
+			if index < uint64(len(filter.Arr)) {
+				atomic.StoreUint64(&filter.Arr[index], word)
+				loaded = true
+				break
+			}
+
+			index -= uint64(len(filter.Arr))
+		}
+
+		if !loaded {
+			return nil, fmt.Errorf("Chunk doesn't fit in the bloom filter !!!")
+		}
+	}
+
+	return bl, nil
+}
+
+func bloomFilterParts(bl BloomFilter) ([]*PlainBloomFilter, error) {
+	switch filter := bl.(type) {
+	case *PlainBloomFilter:
+		return []*PlainBloomFilter{filter}, nil
+
+	case *AdaptiveScalableBloomFilter:
+		filter.mu.RLock()
+		defer filter.mu.RUnlock()
+
+		return append([]*PlainBloomFilter{}, filter.Filters...), nil
+	}
+
+	return nil, fmt.Errorf("Unknown type of bloom filter !!!")
+}
+
+func encodeBloomFilterHeader(bl BloomFilter, filters []*PlainBloomFilter) ([]byte, error) {
+	var buf bytes.Buffer
+
+	abl, scalable := bl.(*AdaptiveScalableBloomFilter)
+
+	binary.Write(&buf, binary.LittleEndian, scalable)
+
+	if scalable {
+		abl.mu.RLock()
+		header := scalableBloomFilterHeader{
+			ErrorRate:       abl.ErrorRate,
+			Expansion:       uint64(abl.Expansion),
+			TighteningRatio: abl.TighteningRatio,
+			CurrentNumItems: uint64(abl.CurrentNumItems),
+			MaxCapacity:     uint64(abl.MaxCapacity),
+			NumFilters:      uint32(len(filters)),
+		}
+		abl.mu.RUnlock()
+
+		binary.Write(&buf, binary.LittleEndian, header)
+	}
+
+	for _, filter := range filters {
+		header := plainBloomFilterHeader{
+			EstimatedCapacity: uint64(filter.EstimatedCapacity),
+			HashFuncNum:       filter.HashFuncNum,
+			ErrorRate:         filter.ErrorRate,
+			NumItems:          atomic.LoadUint64(&filter.NumItems),
+			NumWords:          uint64(len(filter.Arr)),
+		}
+
+		if err := binary.Write(&buf, binary.LittleEndian, header); err != nil {
+			return nil, err
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+func decodeBloomFilterHeader(data []byte) (BloomFilter, error) {
+	reader := bytes.NewReader(data)
+	invalid := fmt.Errorf("Invalid header chunk !!!")
+
+	var scalable bool
+	if err := binary.Read(reader, binary.LittleEndian, &scalable); err != nil {
+		return nil, invalid
+	}
+
+	var scalableHeader scalableBloomFilterHeader
+	numFilters := uint32(1)
+
+	if scalable {
+		if err := binary.Read(reader, binary.LittleEndian, &scalableHeader); err != nil {
+			return nil, invalid
+		}
+
+		if err := validateScalableHeader(scalableHeader); err != nil {
+			return nil, err
+		}
+
+		numFilters = scalableHeader.NumFilters
+	}
+
+	// Every filter has a header of its own, so the chunk has to hold exactly numFilters of them
+	if uint64(reader.Len()) != uint64(numFilters)*uint64(binary.Size(plainBloomFilterHeader{})) {
+		return nil, invalid
+	}
+
+	headers := make([]plainBloomFilterHeader, numFilters)
+	totalWords := uint64(0)
+
+	for i := range headers {
+		if err := binary.Read(reader, binary.LittleEndian, &headers[i]); err != nil {
+			return nil, invalid
+		}
+
+		if err := validatePlainHeader(headers[i]); err != nil {
+			return nil, err
+		}
+
+		totalWords += headers[i].NumWords
+
+		if totalWords > MaxBloomFilterDumpWords {
+			return nil, fmt.Errorf("Bloom filter can't be bigger than %v bytes !!!", MaxBloomFilterDumpWords*8)
+		}
+	}
+
+	filters := make([]*PlainBloomFilter, len(headers))
+
+	for i, header := range headers {
+		filters[i] = &PlainBloomFilter{
+			Arr:               make([]uint64, header.NumWords),
+			EstimatedCapacity: uint(header.EstimatedCapacity),
+			HashFuncNum:       header.HashFuncNum,
+			ErrorRate:         header.ErrorRate,
+			NumItems:          header.NumItems,
+		}
+	}
+
+	if !scalable {
+		return filters[0], nil
+	}
+
+	return &AdaptiveScalableBloomFilter{
+		Filters:         filters,
+		CurrentNumItems: uint(scalableHeader.CurrentNumItems),
+		MaxCapacity:     uint(scalableHeader.MaxCapacity),
+		ErrorRate:       scalableHeader.ErrorRate,
+		Expansion:       uint(scalableHeader.Expansion),
+		TighteningRatio: scalableHeader.TighteningRatio,
+	}, nil
+}
+
+func validateScalableHeader(header scalableBloomFilterHeader) error {
+	switch {
+	case header.NumFilters == 0 || header.NumFilters > MaxBloomFilterDumpFilters:
+		return fmt.Errorf("Number of filters should lie in the range of [1, %v] !!!", MaxBloomFilterDumpFilters)
+	case !isRatio(header.ErrorRate):
+		return fmt.Errorf("Error rate should lie in the range of (0, 1) !!!")
+	case header.Expansion == 0:
+		return fmt.Errorf("Expansion should be a positive integer !!!")
+	case !isRatio(header.TighteningRatio):
+		return fmt.Errorf("Tightening ratio should lie in the range of (0, 1) !!!")
+	}
+
+	return nil
+}
+
+func validatePlainHeader(header plainBloomFilterHeader) error {
+	switch {
+	case header.NumWords == 0 || header.NumWords > MaxBloomFilterDumpWords:
+		return fmt.Errorf("Bloom filter can't be bigger than %v bytes !!!", MaxBloomFilterDumpWords*8)
+	case header.HashFuncNum == 0:
+		return fmt.Errorf("Number of hash functions should be a positive integer !!!")
+	case header.EstimatedCapacity == 0:
+		return fmt.Errorf("Capacity should be a positive integer !!!")
+	case !isRatio(header.ErrorRate):
+		return fmt.Errorf("Error rate should lie in the range of (0, 1) !!!")
+	}
+
+	return nil
+}
+
+// false for NaN as well
+func isRatio(val float64) bool {
+	return val > 0 && val < 1
+}
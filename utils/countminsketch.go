@@ -0,0 +1,154 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// Counters of a sketch (128 MiB), the dimensions come from the client
+const CountMinSketchMaxCounters = 1 << 24
+
+type CountMinSketch struct {
+	Table [][]uint64 // Depth rows of Width counters
+	Width uint
+	Depth uint
+	Count uint64 // total of all the increments
+}
+
+func CreateCountMinSketch(width uint, depth uint) (*CountMinSketch, error) {
+	if width == 0 || depth == 0 {
+		return nil, fmt.Errorf("Width and depth should be positive !!!")
+	}
+
+	if err := checkCountMinSketchSize(float64(width), float64(depth)); err != nil {
+		return nil, err
+	}
+
+	cms := CountMinSketch{Width: width, Depth: depth}
+
+	cms.Table = make([][]uint64, depth)
+	for i := range cms.Table {
+		cms.Table[i] = make([]uint64, width)
+	}
+
+	return &cms, nil
+}
+
+/*
+errorRate -> overestimation as a fraction of total count (e.g. 0.001)
+probability -> chance of the overestimation going beyond errorRate (e.g. 0.01)
+*/
+func CreateCountMinSketchByProb(errorRate float64, probability float64) (*CountMinSketch, error) {
+	if errorRate <= 0 || errorRate >= 1 || probability <= 0 || probability >= 1 {
+		return nil, fmt.Errorf("Error rate and probability should lie in the range of (0, 1) !!!")
+	}
+
+	width := math.Ceil(math.E / errorRate)
+	depth := math.Ceil(math.Log(1 / probability))
+
+	// Checked before the conversion, tiny error rates give widths which don't fit in a uint
+	if err := checkCountMinSketchSize(width, depth); err != nil {
+		return nil, err
+	}
+
+	return CreateCountMinSketch(uint(width), uint(depth))
+}
+
+func checkCountMinSketchSize(width float64, depth float64) error {
+	if width*depth > CountMinSketchMaxCounters {
+		return fmt.Errorf("Sketch can't have more than %v counters !!!", CountMinSketchMaxCounters)
+	}
+
+	return nil
+}
+
+// Returns the estimated count of the item after incrementing
+func (cms *CountMinSketch) IncrBy(item string, increment uint64) uint64 {
+	estimate := uint64(math.MaxUint64)
+
+	for row, col := range cms.columns(item) {
+		cms.Table[row][col] = saturatingAdd(cms.Table[row][col], increment)
+
+		estimate = min(estimate, cms.Table[row][col])
+	}
+
+	cms.Count = saturatingAdd(cms.Count, increment)
+
+	return estimate
+}
+
+func (cms *CountMinSketch) Query(item string) uint64 {
+	estimate := uint64(math.MaxUint64)
+
+	for row, col := range cms.columns(item) {
+		estimate = min(estimate, cms.Table[row][col])
+	}
+
+	return estimate
+}
+
+/*
+Replaces the counters of cms with the sum of weight * counters of each source, saturating at the max uint64.
+cms can be one of the sources. All the sketches must have the same dimensions.
+*/
+func (cms *CountMinSketch) Merge(sources []*CountMinSketch, weights []uint64) error {
+	for _, source := range sources {
+		if source.Width != cms.Width || source.Depth != cms.Depth {
+			return fmt.Errorf("Sketches should have the same width and depth to be merged !!!")
+		}
+	}
+
+	// Summed into a new table, so that cms being a source doesn't change what is read
+	table := make([][]uint64, cms.Depth)
+	for row := range table {
+		table[row] = make([]uint64, cms.Width)
+	}
+
+	count := uint64(0)
+
+	for i, source := range sources {
+		for row := range source.Table {
+			for col, val := range source.Table[row] {
+				table[row][col] = saturatingAdd(table[row][col], saturatingMul(val, weights[i]))
+			}
+		}
+
+		count = saturatingAdd(count, saturatingMul(source.Count, weights[i]))
+	}
+
+	cms.Table, cms.Count = table, count
+
+	return nil
+}
+
+func saturatingAdd(a uint64, b uint64) uint64 {
+	if a > math.MaxUint64-b {
+		return math.MaxUint64
+	}
+
+	return a + b
+}
+
+func saturatingMul(a uint64, b uint64) uint64 {
+	if b != 0 && a > math.MaxUint64/b {
+		return math.MaxUint64
+	}
+
+	return a * b
+}
+
+// Column of the item in each row, using double hashing on the two halves of xxhash
+func (cms *CountMinSketch) columns(item string) []uint {
+	hash := xxhash.Sum64String(item)
+	h1, h2 := uint(hash&math.MaxUint32), uint(hash>>32)|1
+
+	cols := make([]uint, cms.Depth)
+
+	for row := range cols {
+		cols[row] = (h1 + uint(row)*h2) % cms.Width
+	}
+
+	return cols
+}
@@ -0,0 +1,223 @@
+package utils
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// Membership filter which also allows removing the items added to it
+type DeletableFilter interface {
+	Set(key string) error
+	DoesExist(key string) bool
+	Delete(key string) bool
+	Count(key string) uint
+}
+
+const (
+	CuckooMaxKicks = 500     // relocations tried before giving up on an insert
+	CuckooMaxWords = 1 << 24 // words of the slot array (128 MiB), the parameters come from the client
+)
+
+/*
+Cuckoo filter storing a fingerprint of every item in one of its two candidate buckets.
+The alternate bucket can be computed from the fingerprint alone (i2 = i1 ^ hash(fingerprint)),
+so fingerprints can be moved around on collisions and deleted without knowing the items.
+*/
+type CuckooFilter struct {
+	Slots           []uint64 // NumBuckets * BucketSize fingerprints of FingerprintBits each packed together, 0 -> empty slot
+	NumBuckets      uint     // power of 2
+	BucketSize      uint
+	FingerprintBits uint8
+	NumItems        uint
+}
+
+func CreateCuckooFilter(capacity uint, bucketSize uint, fingerprintBits uint8) (*CuckooFilter, error) {
+	if capacity == 0 || bucketSize == 0 {
+		return nil, fmt.Errorf("Capacity and bucket size should be positive !!!")
+	}
+
+	if fingerprintBits < 1 || fingerprintBits > 32 {
+		return nil, fmt.Errorf("Fingerprint bits should lie in the range of [1, 32] !!!")
+	}
+
+	// Cuckoo filters can be filled up to about 95% with buckets of 4. Number of buckets is rounded up to a power of 2.
+	// Worked out as a float so that huge capacities can't overflow before being checked.
+	buckets := math.Exp2(math.Ceil(math.Log2(math.Ceil(float64(capacity) / float64(bucketSize) / 0.95))))
+
+	if buckets*float64(bucketSize)*float64(fingerprintBits) > CuckooMaxWords*64 {
+		return nil, fmt.Errorf("Cuckoo filter can't be bigger than %v bytes !!!", CuckooMaxWords*8)
+	}
+
+	numBuckets := uint(buckets)
+
+	cf := CuckooFilter{NumBuckets: numBuckets, BucketSize: bucketSize, FingerprintBits: fingerprintBits}
+	cf.Slots = make([]uint64, (numBuckets*bucketSize*uint(fingerprintBits)+63)/64)
+
+	return &cf, nil
+}
+
+func (cf *CuckooFilter) Set(key string) error {
+	if key == "" {
+		return fmt.Errorf("Key can't be empty !!!")
+	}
+
+	fp, i1, i2 := cf.locate(key)
+
+	if cf.insertIntoBucket(i1, fp) || cf.insertIntoBucket(i2, fp) {
+		cf.NumItems++
+		return nil
+	}
+
+	// Both buckets are full -> keep kicking a random fingerprint to its alternate bucket
+	type move struct{ bucket, slot uint }
+	path := make([]move, 0, CuckooMaxKicks)
+
+	bucket := []uint{i1, i2}[rand.Intn(2)]
+	victim := fp
+
+	for kick := 0; kick < CuckooMaxKicks; kick++ {
+		slot := uint(rand.Intn(int(cf.BucketSize)))
+		index := bucket*cf.BucketSize + slot
+
+		victim = cf.swapSlot(index, victim)
+		path = append(path, move{bucket, slot})
+
+		bucket = cf.altIndex(bucket, victim)
+
+		if cf.insertIntoBucket(bucket, victim) {
+			cf.NumItems++
+			return nil
+		}
+	}
+
+	// Undo the relocations so that no fingerprint is lost
+	for i := len(path) - 1; i >= 0; i-- {
+		index := path[i].bucket*cf.BucketSize + path[i].slot
+		victim = cf.swapSlot(index, victim)
+	}
+
+	return fmt.Errorf("Filter is full !!!")
+}
+
+func (cf *CuckooFilter) DoesExist(key string) bool {
+	return cf.Count(key) > 0
+}
+
+// Number of times the fingerprint of the key was added (can be off because of fingerprint collisions)
+func (cf *CuckooFilter) Count(key string) uint {
+	if key == "" {
+		return 0
+	}
+
+	fp, i1, i2 := cf.locate(key)
+
+	count := cf.countInBucket(i1, fp)
+	if i2 != i1 {
+		count += cf.countInBucket(i2, fp)
+	}
+
+	return count
+}
+
+// Removes one occurrence of the key. Deleting items which were never added can remove other items.
+func (cf *CuckooFilter) Delete(key string) bool {
+	if key == "" {
+		return false
+	}
+
+	fp, i1, i2 := cf.locate(key)
+
+	for _, bucket := range []uint{i1, i2} {
+		for slot := uint(0); slot < cf.BucketSize; slot++ {
+			index := bucket*cf.BucketSize + slot
+
+			if cf.getSlot(index) == fp {
+				cf.setSlot(index, 0)
+				cf.NumItems--
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Fingerprint and the two candidate buckets of the key
+func (cf *CuckooFilter) locate(key string) (uint32, uint, uint) {
+	hash := xxhash.Sum64String(key)
+
+	// 0 marks an empty slot -> spread the fingerprints evenly over [1, 2^FingerprintBits - 1]
+	fp := uint32((hash>>32)%((uint64(1)<<cf.FingerprintBits)-1) + 1)
+
+	i1 := uint(hash) & (cf.NumBuckets - 1)
+
+	return fp, i1, cf.altIndex(i1, fp)
+}
+
+func (cf *CuckooFilter) altIndex(bucket uint, fp uint32) uint {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], fp)
+
+	return (bucket ^ uint(xxhash.Sum64(buf[:]))) & (cf.NumBuckets - 1)
+}
+
+func (cf *CuckooFilter) insertIntoBucket(bucket uint, fp uint32) bool {
+	for slot := uint(0); slot < cf.BucketSize; slot++ {
+		index := bucket*cf.BucketSize + slot
+
+		if cf.getSlot(index) == 0 {
+			cf.setSlot(index, fp)
+			return true
+		}
+	}
+
+	return false
+}
+
+func (cf *CuckooFilter) countInBucket(bucket uint, fp uint32) uint {
+	var count uint
+
+	for slot := uint(0); slot < cf.BucketSize; slot++ {
+		if cf.getSlot(bucket*cf.BucketSize+slot) == fp {
+			count++
+		}
+	}
+
+	return count
+}
+
+// Fingerprint in the slot, which may span two words
+func (cf *CuckooFilter) getSlot(index uint) uint32 {
+	bit := index * uint(cf.FingerprintBits)
+	word, offset := bit/64, bit%64
+
+	fp := cf.Slots[word] >> offset
+	if offset+uint(cf.FingerprintBits) > 64 {
+		fp |= cf.Slots[word+1] << (64 - offset)
+	}
+
+	return uint32(fp & (uint64(1)<<cf.FingerprintBits - 1))
+}
+
+func (cf *CuckooFilter) setSlot(index uint, fp uint32) {
+	bit := index * uint(cf.FingerprintBits)
+	word, offset := bit/64, bit%64
+	mask := uint64(1)<<cf.FingerprintBits - 1
+
+	cf.Slots[word] = cf.Slots[word]&^(mask<<offset) | uint64(fp)<<offset
+	if offset+uint(cf.FingerprintBits) > 64 {
+		cf.Slots[word+1] = cf.Slots[word+1]&^(mask>>(64-offset)) | uint64(fp)>>(64-offset)
+	}
+}
+
+// Puts fp in the slot and returns the fingerprint it held
+func (cf *CuckooFilter) swapSlot(index uint, fp uint32) uint32 {
+	old := cf.getSlot(index)
+	cf.setSlot(index, fp)
+
+	return old
+}
@@ -0,0 +1,192 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+)
+
+// Same limits as redis, so that the cells are squares in web mercator projection
+const (
+	GeoStepMax = 26 // bits per coordinate, geohash scores are 52 bits long
+
+	GeoLatMin = -85.05112878
+	GeoLatMax = 85.05112878
+	GeoLonMin = -180.0
+	GeoLonMax = 180.0
+
+	earthRadiusInMeters = 6372797.560856
+	mercatorMax         = 20037726.37
+)
+
+const geoBase32 = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// Interleaved geohash of the coordinates. Latitude bits are at even positions and longitude bits at odd positions.
+func GeoEncode(lon float64, lat float64) (uint64, error) {
+	if lon < GeoLonMin || lon > GeoLonMax || lat < GeoLatMin || lat > GeoLatMax {
+		return 0, fmt.Errorf("Invalid longitude,latitude pair %v,%v", lon, lat)
+	}
+
+	latIndex := geoCellIndex(lat, GeoLatMin, GeoLatMax, GeoStepMax)
+	lonIndex := geoCellIndex(lon, GeoLonMin, GeoLonMax, GeoStepMax)
+
+	return interleave(latIndex, lonIndex), nil
+}
+
+// Returns the center of the cell represented by the geohash
+func GeoDecode(hash uint64) (float64, float64) {
+	latIndex, lonIndex := deinterleave(hash)
+
+	cells := float64(uint64(1) << GeoStepMax)
+
+	latCell := (GeoLatMax - GeoLatMin) / cells
+	lonCell := (GeoLonMax - GeoLonMin) / cells
+
+	lat := GeoLatMin + (float64(latIndex)+0.5)*latCell
+	lon := GeoLonMin + (float64(lonIndex)+0.5)*lonCell
+
+	return math.Max(GeoLonMin, math.Min(GeoLonMax, lon)), math.Max(GeoLatMin, math.Min(GeoLatMax, lat))
+}
+
+// Haversine distance in meters
+func GeoDistance(lon1 float64, lat1 float64, lon2 float64, lat2 float64) float64 {
+	lat1r, lon1r := lat1*math.Pi/180, lon1*math.Pi/180
+	lat2r, lon2r := lat2*math.Pi/180, lon2*math.Pi/180
+
+	u := math.Sin((lat2r - lat1r) / 2)
+	v := math.Sin((lon2r - lon1r) / 2)
+
+	return 2 * earthRadiusInMeters * math.Asin(math.Sqrt(u*u+math.Cos(lat1r)*math.Cos(lat2r)*v*v))
+}
+
+// Standard 11 character geohash (latitude range of [-90, 90]) which can be used on geohash.org
+func GeoHashString(lon float64, lat float64) string {
+	latRange := [2]float64{-90, 90}
+	lonRange := [2]float64{-180, 180}
+
+	result := make([]byte, 11)
+	isLon := true
+
+	for i := range result {
+		var index byte
+
+		for bit := 0; bit < 5; bit++ {
+			r, val := &latRange, lat
+			if isLon {
+				r, val = &lonRange, lon
+			}
+
+			mid := (r[0] + r[1]) / 2
+			index <<= 1
+
+			if val >= mid {
+				index |= 1
+				r[0] = mid
+			} else {
+				r[1] = mid
+			}
+
+			isLon = !isLon
+		}
+
+		result[i] = geoBase32[index]
+	}
+
+	return string(result)
+}
+
+/*
+Score ranges [min, max) of the 3x3 cells around the point, at a precision where a cell is at least as big as the radius.
+Every point within the radius lies in one of these ranges, but the ranges also contain points outside the radius,
+so results have to be filtered by distance.
+*/
+func GeoSearchRanges(lon float64, lat float64, radiusInMeters float64) [][2]uint64 {
+	step := geoEstimateSteps(radiusInMeters, lat)
+
+	latIndex := int64(geoCellIndex(lat, GeoLatMin, GeoLatMax, step))
+	lonIndex := int64(geoCellIndex(lon, GeoLonMin, GeoLonMax, step))
+
+	cells := int64(1) << step
+	shift := 2 * (GeoStepMax - step)
+
+	seen := make(map[uint64]bool)
+	ranges := [][2]uint64{}
+
+	for dLat := int64(-1); dLat <= 1; dLat++ {
+		for dLon := int64(-1); dLon <= 1; dLon++ {
+			neighbourLat := latIndex + dLat
+
+			if neighbourLat < 0 || neighbourLat >= cells {
+				continue
+			}
+
+			// Longitude wraps around the anti meridian
+			neighbourLon := ((lonIndex+dLon)%cells + cells) % cells
+
+			hash := interleave(uint32(neighbourLat), uint32(neighbourLon))
+
+			if seen[hash] {
+				continue
+			}
+			seen[hash] = true
+
+			ranges = append(ranges, [2]uint64{hash << shift, (hash + 1) << shift})
+		}
+	}
+
+	return ranges
+}
+
+// Number of bits per coordinate at which a cell is at least as big as the radius
+func geoEstimateSteps(radiusInMeters float64, lat float64) uint {
+	if radiusInMeters == 0 {
+		return GeoStepMax
+	}
+
+	step := 1
+
+	for radiusInMeters < mercatorMax {
+		radiusInMeters *= 2
+		step++
+	}
+
+	step -= 2
+
+	// Cells get narrower towards the poles
+	if lat > 66 || lat < -66 {
+		step--
+		if lat > 80 || lat < -80 {
+			step--
+		}
+	}
+
+	return uint(max(1, min(step, GeoStepMax)))
+}
+
+func geoCellIndex(val float64, minVal float64, maxVal float64, step uint) uint32 {
+	cells := float64(uint64(1) << step)
+	index := (val - minVal) / (maxVal - minVal) * cells
+
+	return uint32(math.Min(index, cells-1))
+}
+
+func interleave(x uint32, y uint32) uint64 {
+	var result uint64
+
+	for i := 0; i < 32; i++ {
+		result |= uint64((x>>i)&1) << (2 * i)
+		result |= uint64((y>>i)&1) << (2*i + 1)
+	}
+
+	return result
+}
+
+func deinterleave(hash uint64) (uint32, uint32) {
+	var x, y uint32
+
+	for i := 0; i < 32; i++ {
+		x |= uint32((hash>>(2*i))&1) << i
+		y |= uint32((hash>>(2*i+1))&1) << i
+	}
+
+	return x, y
+}
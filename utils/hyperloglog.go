@@ -0,0 +1,144 @@
+package utils
+
+import (
+	"math"
+	"math/bits"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+const (
+	HLLPrecision = 14
+	HLLRegisters = 1 << HLLPrecision // 16384 registers -> standard error of 0.81%
+
+	// Bytes taken by a register in the sparse map : 2 byte index, 1 byte rank, padding and map overhead
+	HLLSparseRegisterSize = 6
+
+	// Registers are kept in a map till the map is as big as the dense array (1 byte per register), after which the array is used
+	HLLSparseMaxRegisters = HLLRegisters / HLLSparseRegisterSize
+)
+
+/*
+HyperLogLog with 2^14 registers of 6 bit ranks (stored as bytes).
+Small counters only keep the non zero registers (sparse), and switch to the full register array (dense) as they grow.
+*/
+type HyperLogLog struct {
+	Sparse map[uint16]uint8
+	Dense  []uint8
+}
+
+func CreateHyperLogLog() *HyperLogLog {
+	return &HyperLogLog{Sparse: make(map[uint16]uint8)}
+}
+
+func (hll *HyperLogLog) IsSparse() bool {
+	return hll.Dense == nil
+}
+
+// Returns true if the estimated cardinality may have changed
+func (hll *HyperLogLog) Add(item string) bool {
+	hash := xxhash.Sum64String(item)
+
+	index := uint16(hash & (HLLRegisters - 1))
+
+	// Rank -> position of the first set bit in the remaining bits
+	rank := uint8(bits.TrailingZeros64((hash>>HLLPrecision)|(1<<(64-HLLPrecision)))) + 1
+
+	return hll.setRegister(index, rank)
+}
+
+// Union of both the counters is stored in hll
+func (hll *HyperLogLog) Merge(other *HyperLogLog) {
+	if other.IsSparse() {
+		for index, rank := range other.Sparse {
+			hll.setRegister(index, rank)
+		}
+		return
+	}
+
+	for index, rank := range other.Dense {
+		if rank > 0 {
+			hll.setRegister(uint16(index), rank)
+		}
+	}
+}
+
+func (hll *HyperLogLog) Count() uint64 {
+	sum := 0.0
+	zeros := 0
+
+	for index := 0; index < HLLRegisters; index++ {
+		rank := hll.getRegister(uint16(index))
+
+		if rank == 0 {
+			zeros++
+		}
+
+		sum += 1 / float64(uint64(1)<<rank)
+	}
+
+	m := float64(HLLRegisters)
+	alpha := 0.7213 / (1 + 1.079/m)
+
+	estimate := alpha * m * m / sum
+
+	// Linear counting is more accurate for small cardinalities
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+
+	return uint64(math.Round(estimate))
+}
+
+func (hll *HyperLogLog) Clone() *HyperLogLog {
+	clone := &HyperLogLog{}
+
+	if hll.IsSparse() {
+		clone.Sparse = make(map[uint16]uint8, len(hll.Sparse))
+		for index, rank := range hll.Sparse {
+			clone.Sparse[index] = rank
+		}
+	} else {
+		clone.Dense = append([]uint8{}, hll.Dense...)
+	}
+
+	return clone
+}
+
+func (hll *HyperLogLog) getRegister(index uint16) uint8 {
+	if hll.IsSparse() {
+		return hll.Sparse[index]
+	}
+
+	return hll.Dense[index]
+}
+
+// Register only ever grows. Returns true if it was updated.
+func (hll *HyperLogLog) setRegister(index uint16, rank uint8) bool {
+	if hll.getRegister(index) >= rank {
+		return false
+	}
+
+	if !hll.IsSparse() {
+		hll.Dense[index] = rank
+		return true
+	}
+
+	if hll.Sparse == nil {
+		hll.Sparse = make(map[uint16]uint8)
+	}
+
+	hll.Sparse[index] = rank
+
+	if len(hll.Sparse) >= HLLSparseMaxRegisters {
+		hll.Dense = make([]uint8, HLLRegisters)
+
+		for i, r := range hll.Sparse {
+			hll.Dense[i] = r
+		}
+
+		hll.Sparse = nil
+	}
+
+	return true
+}
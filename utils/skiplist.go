@@ -11,8 +11,17 @@ import (
 type NodeData[T cmp.Ordered] struct {
 	Key          string
 	OrderedValue T
+	Sentinel     Sentinel // head and tail are told apart by this, so any string can be a key
 }
 
+type Sentinel uint8
+
+const (
+	NotSentinel  Sentinel = iota
+	HeadSentinel          // smaller than every entry
+	TailSentinel          // bigger than every entry
+)
+
 type Node[T cmp.Ordered] struct {
 	Data  NodeData[T]
 	Up    *Node[T]
@@ -39,8 +48,8 @@ type ScoreSkipList struct {
 }
 
 func CreateTTLSkipList(maxHeight uint8) *TTLSkipList {
-	HeadNode := &Node[uint32]{Data: NodeData[uint32]{"-INF", 0}}
-	TailNode := &Node[uint32]{Data: NodeData[uint32]{"INF", math.MaxInt32}}
+	HeadNode := &Node[uint32]{Data: NodeData[uint32]{"-INF", 0, HeadSentinel}}
+	TailNode := &Node[uint32]{Data: NodeData[uint32]{"INF", math.MaxInt32, TailSentinel}}
 
 	HeadNode.Right = TailNode
 	TailNode.Left = HeadNode
@@ -49,8 +58,8 @@ func CreateTTLSkipList(maxHeight uint8) *TTLSkipList {
 }
 
 func CreateScoreSkipList(maxHeight uint8) *ScoreSkipList {
-	HeadNode := &Node[int]{Data: NodeData[int]{"-INF", 0}}
-	TailNode := &Node[int]{Data: NodeData[int]{"INF", math.MaxInt32}}
+	HeadNode := &Node[int]{Data: NodeData[int]{"-INF", 0, HeadSentinel}}
+	TailNode := &Node[int]{Data: NodeData[int]{"INF", math.MaxInt32, TailSentinel}}
 
 	HeadNode.Right = TailNode
 	TailNode.Left = HeadNode
@@ -98,7 +107,7 @@ func (skipList *TTLSkipList) DeleteExpiredKeys() []string {
 
 	var deletedKeys []string
 
-	for curr != nil && curr.Data.Key != "INF" {
+	for curr != nil && curr.Data.Sentinel != TailSentinel {
 		if uint32(time.Now().Unix()) < curr.Data.OrderedValue {
 			break
 		}
@@ -157,6 +166,10 @@ func (skipList *ScoreSkipList) FindUpperLevelPrevElem(prevNode *Node[int]) *Node
 	return skipList.SkipList.FindUpperLevelPrevElem(prevNode)
 }
 
+func (skipList *ScoreSkipList) RangeByScore(min int, max int) []NodeData[int] {
+	return skipList.SkipList.RangeByValue(min, max)
+}
+
 /*
 ***********************
 BASE SKIPLIST METHODS
@@ -178,7 +191,7 @@ func (skipList *SkipList[T]) Search(key string, orderedValue T) bool {
 
 	node := skipList.FindEntry(key, orderedValue)
 	//fmt.Println(node)
-	if node.Data.Key == key {
+	if node.Data.matches(key) {
 		return true
 	}
 
@@ -192,14 +205,14 @@ func (skipList *SkipList[T]) Insert(key string, orderedValue T) error {
 
 	prevNode := skipList.FindEntry(key, orderedValue)
 
-	if prevNode.Data.Key == key {
+	if prevNode.Data.matches(key) {
 		return fmt.Errorf("This node is already present !!!")
 	}
 
 	//We are at the lowest level
 	nextNode := prevNode.Right
 
-	newNode := &Node[T]{Data: NodeData[T]{key, orderedValue}}
+	newNode := &Node[T]{Data: NodeData[T]{Key: key, OrderedValue: orderedValue}}
 
 	prevNode.Right = newNode
 	newNode.Left = prevNode
@@ -218,7 +231,7 @@ func (skipList *SkipList[T]) Insert(key string, orderedValue T) error {
 			break
 		}
 
-		newUpperNode := &Node[T]{Data: NodeData[T]{key, orderedValue}}
+		newUpperNode := &Node[T]{Data: NodeData[T]{Key: key, OrderedValue: orderedValue}}
 
 		// Create new level
 		if currentLevel >= skipList.Height {
@@ -226,10 +239,10 @@ func (skipList *SkipList[T]) Insert(key string, orderedValue T) error {
 			var minVal T
 			maxVal := SetMaxValue[T]()
 
-			skipList.Head.Up = &Node[T]{Data: NodeData[T]{"-INF", minVal}}
+			skipList.Head.Up = &Node[T]{Data: NodeData[T]{"-INF", minVal, HeadSentinel}}
 			skipList.Head.Up.Down = skipList.Head
 
-			skipList.Tail.Up = &Node[T]{Data: NodeData[T]{"INF", maxVal}}
+			skipList.Tail.Up = &Node[T]{Data: NodeData[T]{"INF", maxVal, TailSentinel}}
 			skipList.Tail.Up.Down = skipList.Tail
 
 			skipList.Head = skipList.Head.Up
@@ -278,7 +291,7 @@ func (skipList *SkipList[T]) Delete(key string, orderedValue T) error {
 
 	node := skipList.FindEntry(key, orderedValue)
 
-	if node.Data.Key != key {
+	if !node.Data.matches(key) {
 		return fmt.Errorf("Can't find this key !!!")
 	}
 
@@ -320,7 +333,7 @@ func (skipList *SkipList[T]) FindUpperLevelPrevElem(prevNode *Node[T]) *Node[T]
 	// fmt.Printf("{Up: %v, Down: %v, Left: %v, Right: %v}\n", ptr.Up, ptr.Down, ptr.Left, ptr.Right)
 
 	// Find first ladder left of prevNode
-	for ptr != nil && ptr.Data.Key != "-INF" && ptr.Up == nil {
+	for ptr != nil && ptr.Data.Sentinel != HeadSentinel && ptr.Up == nil {
 		ptr = ptr.Left
 	}
 
@@ -334,13 +347,13 @@ func (skipList *SkipList[T]) FindUpperLevelPrevElem(prevNode *Node[T]) *Node[T]
 
 // Returns the entry. If nothing is matched, returns the immediate smaller element in the lowest level
 func (skipList *SkipList[T]) FindEntry(key string, orderedValue T) *Node[T] {
-	node := NodeData[T]{key, orderedValue}
+	node := NodeData[T]{Key: key, OrderedValue: orderedValue}
 
 	current := skipList.Head
 
 	var path string
 
-	for current != nil && current.Data.Key != "INF" {
+	for current != nil && current.Data.Sentinel != TailSentinel {
 		path += current.Data.Key + " "
 		if current.Data.Compare(node) == 0 {
 			return current
@@ -367,10 +380,15 @@ func (a NodeData[T]) Compare(b NodeData[T]) int8 {
 	// a > b : -1
 	// a < b :   1
 
-	if a.Key == "-INF" || b.Key == "INF" {
+	if a.Sentinel == HeadSentinel || b.Sentinel == TailSentinel {
 		return 1
 	}
 
+	// Tail is always the biggest, whatever its OrderedValue is
+	if a.Sentinel == TailSentinel || b.Sentinel == HeadSentinel {
+		return -1
+	}
+
 	if a.Key == b.Key {
 		return 0
 	}
@@ -390,6 +408,11 @@ func (a NodeData[T]) Compare(b NodeData[T]) int8 {
 	return 1
 }
 
+// Whether this is the entry of key, sentinels never match
+func (a NodeData[T]) matches(key string) bool {
+	return a.Sentinel == NotSentinel && a.Key == key
+}
+
 func (skipList *SkipList[T]) Print() {
 	skipList.mu.RLock()
 	defer skipList.mu.RUnlock()
@@ -411,3 +434,32 @@ func (skipList *SkipList[T]) Print() {
 		currentHead = currentHead.Down
 	}
 }
+
+// Returns the entries with min <= OrderedValue <= max in ascending order
+func (skipList *SkipList[T]) RangeByValue(min T, max T) []NodeData[T] {
+	skipList.mu.RLock()
+	defer skipList.mu.RUnlock()
+
+	current := skipList.Head
+
+	// Find the last node smaller than min, level by level
+	for {
+		for current.Right != nil && current.Right.Data.Sentinel != TailSentinel && current.Right.Data.OrderedValue < min {
+			current = current.Right
+		}
+
+		if current.Down == nil {
+			break
+		}
+
+		current = current.Down
+	}
+
+	result := []NodeData[T]{}
+
+	for current = current.Right; current != nil && current.Data.Sentinel != TailSentinel && current.Data.OrderedValue <= max; current = current.Right {
+		result = append(result, current.Data)
+	}
+
+	return result
+}
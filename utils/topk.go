@@ -0,0 +1,177 @@
+package utils
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// Limits on the parameters sent by the client
+const (
+	TopKMaxK       = 1 << 16 // the heap is searched linearly on every add
+	TopKMaxBuckets = 1 << 24 // width * depth (128 MiB)
+)
+
+type TopKBucket struct {
+	Fingerprint uint32
+	Count       uint32
+}
+
+type TopKItem struct {
+	Item  string
+	Count uint32
+}
+
+/*
+HeavyKeeper sketch which keeps track of the K most frequent items.
+Buckets are shared by items (count-min sketch like), and the count of a bucket holding a different item
+decays with a probability of Decay^count, so only the heavy hitters keep their buckets.
+*/
+type TopK struct {
+	Buckets [][]TopKBucket
+	Heap    TopKHeap // min heap of the current top k items
+	K       uint
+	Width   uint
+	Depth   uint
+	Decay   float64
+}
+
+func CreateTopK(k uint, width uint, depth uint, decay float64) (*TopK, error) {
+	if k == 0 || width == 0 || depth == 0 {
+		return nil, fmt.Errorf("K, width and depth should be positive !!!")
+	}
+
+	if decay <= 0 || decay > 1 {
+		return nil, fmt.Errorf("Decay should lie in the range of (0, 1] !!!")
+	}
+
+	if k > TopKMaxK {
+		return nil, fmt.Errorf("K can't be more than %v !!!", TopKMaxK)
+	}
+
+	if float64(width)*float64(depth) > TopKMaxBuckets {
+		return nil, fmt.Errorf("Sketch can't have more than %v buckets !!!", TopKMaxBuckets)
+	}
+
+	topk := TopK{K: k, Width: width, Depth: depth, Decay: decay}
+
+	topk.Buckets = make([][]TopKBucket, depth)
+	for i := range topk.Buckets {
+		topk.Buckets[i] = make([]TopKBucket, width)
+	}
+
+	return &topk, nil
+}
+
+// Returns the item expelled from the top k list because of this item, if any
+func (topk *TopK) Add(item string) (string, bool) {
+	hash := xxhash.Sum64String(item)
+	fingerprint := uint32(hash >> 32)
+	h1, h2 := uint(hash&math.MaxUint32), uint(hash>>32)|1
+
+	var maxCount uint32
+
+	for row := range topk.Buckets {
+		bucket := &topk.Buckets[row][(h1+uint(row)*h2)%topk.Width]
+
+		switch {
+		case bucket.Count == 0:
+			bucket.Fingerprint = fingerprint
+			bucket.Count = 1
+
+		case bucket.Fingerprint == fingerprint:
+			if bucket.Count < math.MaxUint32 {
+				bucket.Count++
+			}
+
+		case rand.Float64() < math.Pow(topk.Decay, float64(bucket.Count)):
+			bucket.Count--
+
+			if bucket.Count == 0 {
+				bucket.Fingerprint = fingerprint
+				bucket.Count = 1
+			}
+		}
+
+		if bucket.Fingerprint == fingerprint {
+			maxCount = max(maxCount, bucket.Count)
+		}
+	}
+
+	if index := topk.Heap.find(item); index >= 0 {
+		topk.Heap[index].Count = max(topk.Heap[index].Count, maxCount)
+		heap.Fix(&topk.Heap, index)
+		return "", false
+	}
+
+	if uint(len(topk.Heap)) < topk.K {
+		heap.Push(&topk.Heap, TopKItem{Item: item, Count: maxCount})
+		return "", false
+	}
+
+	if maxCount <= topk.Heap[0].Count {
+		return "", false
+	}
+
+	expelled := topk.Heap[0].Item
+	topk.Heap[0] = TopKItem{Item: item, Count: maxCount}
+	heap.Fix(&topk.Heap, 0)
+
+	return expelled, true
+}
+
+func (topk *TopK) Query(item string) bool {
+	return topk.Heap.find(item) >= 0
+}
+
+// Top k items in the descending order of their counts
+func (topk *TopK) List() []TopKItem {
+	items := append([]TopKItem{}, topk.Heap...)
+
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Count != items[j].Count {
+			return items[i].Count > items[j].Count
+		}
+		return items[i].Item < items[j].Item
+	})
+
+	return items
+}
+
+/*
+***************************
+Min Heap Methods (container/heap)
+***************************
+*/
+
+type TopKHeap []TopKItem
+
+func (h TopKHeap) Len() int           { return len(h) }
+func (h TopKHeap) Less(i, j int) bool { return h[i].Count < h[j].Count }
+func (h TopKHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *TopKHeap) Push(x any) {
+	*h = append(*h, x.(TopKItem))
+}
+
+func (h *TopKHeap) Pop() any {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+// Heap holds at most k items, so a linear scan is enough
+func (h TopKHeap) find(item string) int {
+	for i := range h {
+		if h[i].Item == item {
+			return i
+		}
+	}
+
+	return -1
+}
@@ -1,21 +1,29 @@
 package utils
 
 import (
+	"bufio"
 	"bytes"
 	"cmp"
+	"container/heap"
 	"crypto/rand"
 	"encoding/binary"
 	"encoding/gob"
+	"errors"
 	"fmt"
+	"io"
 	"math"
 	"math/big"
 	"os"
 	"reflect"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+
+	"github.com/cespare/xxhash/v2"
 )
 
-var CommandsWithRequiredArgs []string = []string{"SET", "DEL", "GET", "NUM"}
+var CommandsWithRequiredArgs []string = []string{"SET", "DEL", "GET", "NUM", "EVAL", "EVALSHA", "SCRIPT"}
 
 func StoreCacheGobEncoded[K string | int, V any](fileName string, cache map[K]V) error {
 	var buf bytes.Buffer
@@ -94,6 +102,233 @@ func DeserializeInput(str string) (string, []string, error) {
 	return command, args, nil
 }
 
+var ErrEmptyCommand = errors.New("Command should have atleast 1 part")
+
+// Limits on what a client can send in one command, reading stops with ErrCommandTooLarge above them
+var (
+	MaxCommandParts = 1024 * 1024
+	MaxPartLength   = 512 * 1024 * 1024  // bytes, same as the longest string value
+	MaxCommandBytes = 1024 * 1024 * 1024 // bytes over all the parts, or many long parts could still add up to count * MaxPartLength
+)
+
+// The rest of the command is left unread, so the connection can't be used after it
+var ErrCommandTooLarge = errors.New("Command is too large")
+
+/*
+Reads the next command sent on the connection.
+  - framed : *<number of parts>\r\nCOMMAND\r\nARG\r\n... -> any number of commands can be sent in one write (pipelining)
+  - unframed : COMMAND\r\nARG\r\n... sent in one write, whatever is buffered is taken as one command
+*/
+func ReadCommand(reader *bufio.Reader) (string, []string, error) {
+	line, err := readCRLFLine(reader, MaxCommandBytes)
+	if err != nil {
+		return "", nil, err
+	}
+
+	header, framed := strings.CutPrefix(line, "*")
+	count, err := strconv.Atoi(header)
+
+	if framed && err == nil {
+		if count < 1 {
+			return "", nil, ErrEmptyCommand
+		}
+
+		if count > MaxCommandParts {
+			return "", nil, fmt.Errorf("%w : Command can't have more than %v parts !!!", ErrCommandTooLarge, MaxCommandParts)
+		}
+
+		// count comes from the client, so it isn't trusted for the allocation
+		parts := make([]string, 0, min(count, 64))
+		size := 0
+
+		for len(parts) < count {
+			part, err := readCRLFLine(reader, MaxCommandBytes-size)
+			if err != nil {
+				return "", nil, err
+			}
+
+			size += len(part)
+			parts = append(parts, part)
+		}
+
+		return strings.ToUpper(parts[0]), parts[1:], nil
+	}
+
+	rest := make([]byte, reader.Buffered())
+	if _, err := io.ReadFull(reader, rest); err != nil {
+		return "", nil, err
+	}
+
+	return DeserializeInput(line + "\r\n" + string(rest))
+}
+
+// Reads till \r\n, a part can contain \n. remaining is how many bytes the command still has room for.
+func readCRLFLine(reader *bufio.Reader, remaining int) (string, error) {
+	var line []byte
+
+	for !bytes.HasSuffix(line, []byte("\r\n")) {
+		part, err := reader.ReadSlice('\n')
+		if err != nil && err != bufio.ErrBufferFull {
+			return "", err
+		}
+
+		line = append(line, part...)
+
+		if len(line) > MaxPartLength+len("\r\n") {
+			return "", fmt.Errorf("%w : A part of the command can't be longer than %v bytes !!!", ErrCommandTooLarge, MaxPartLength)
+		}
+
+		if len(line) > remaining+len("\r\n") {
+			return "", fmt.Errorf("%w : Command can't be longer than %v bytes !!!", ErrCommandTooLarge, MaxCommandBytes)
+		}
+	}
+
+	return string(line[:len(line)-len("\r\n")]), nil
+}
+
+// Input ended before the argument did, the client reads another line for these
+var (
+	ErrUnbalancedSingleQuotes = errors.New("Unbalanced single quotes")
+	ErrUnbalancedDoubleQuotes = errors.New("Unbalanced double quotes")
+	ErrTrailingEscape         = errors.New("Nothing to escape at the end of the input")
+)
+
+// Parts of a command are ended by \r\n, so no argument can hold it
+var ErrCRLFInArgument = errors.New("Arguments can't contain \\r\\n as the protocol ends every argument with it")
+
+/*
+Splits a line typed in the client into arguments, the way a shell does :
+  - arguments are seperated by whitespace
+  - 'single quotes' keep everything as typed, \' is the only escape
+  - "double quotes" understand \n \r \t \b \a \\ \" and \xHH (hex byte)
+  - outside quotes \ keeps the next character as it is
+  - quoted and unquoted parts next to each other form one argument : a"b c" -> a b c
+  - empty quotes give an empty argument
+  - \r followed by \n can't be sent, so an argument holding it is an error
+*/
+func SplitArgs(input string) ([]string, error) {
+	args := []string{}
+
+	var arg strings.Builder
+	inArg := false
+
+	for i := 0; i < len(input); i++ {
+		c := input[i]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+
+		case c == '\\':
+			if i+1 >= len(input) {
+				return nil, ErrTrailingEscape
+			}
+
+			i++
+			arg.WriteByte(input[i])
+			inArg = true
+
+		case c == '\'':
+			end := i + 1
+
+			for ; end < len(input) && input[end] != '\''; end++ {
+				if input[end] == '\\' && end+1 < len(input) && input[end+1] == '\'' {
+					end++
+				}
+				arg.WriteByte(input[end])
+			}
+
+			if end >= len(input) {
+				return nil, ErrUnbalancedSingleQuotes
+			}
+
+			i = end
+			inArg = true
+
+		case c == '"':
+			end := i + 1
+
+			for ; end < len(input) && input[end] != '"'; end++ {
+				if input[end] != '\\' {
+					arg.WriteByte(input[end])
+					continue
+				}
+
+				if end+1 >= len(input) {
+					return nil, ErrUnbalancedDoubleQuotes
+				}
+
+				end++
+
+				switch input[end] {
+				case 'n':
+					arg.WriteByte('\n')
+				case 'r':
+					arg.WriteByte('\r')
+				case 't':
+					arg.WriteByte('\t')
+				case 'b':
+					arg.WriteByte('\b')
+				case 'a':
+					arg.WriteByte('\a')
+				case 'x':
+					if end+2 >= len(input) || !isHexDigit(input[end+1]) || !isHexDigit(input[end+2]) {
+						return nil, fmt.Errorf("Invalid hex escape, should be \\xHH")
+					}
+
+					arg.WriteByte(hexValue(input[end+1])<<4 | hexValue(input[end+2]))
+					end += 2
+				default:
+					// \\ and \" along with any other character are kept as is
+					arg.WriteByte(input[end])
+				}
+			}
+
+			if end >= len(input) {
+				return nil, ErrUnbalancedDoubleQuotes
+			}
+
+			i = end
+			inArg = true
+
+		default:
+			arg.WriteByte(c)
+			inArg = true
+		}
+	}
+
+	if inArg {
+		args = append(args, arg.String())
+	}
+
+	for _, arg := range args {
+		if strings.Contains(arg, "\r\n") {
+			return nil, ErrCRLFInArgument
+		}
+	}
+
+	return args, nil
+}
+
+func isHexDigit(c byte) bool {
+	return ('0' <= c && c <= '9') || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+}
+
+func hexValue(c byte) byte {
+	switch {
+	case c >= 'a':
+		return c - 'a' + 10
+	case c >= 'A':
+		return c - 'A' + 10
+	}
+
+	return c - '0'
+}
+
 func Prepend[T any](x []T, y T) []T {
 	var temp T
 	x = append(x, temp)
@@ -198,3 +433,160 @@ func LocateGobFile(dirPath, pattern string) (string, error) {
 
 	return "", fmt.Errorf("No gob files exist !!!")
 }
+
+/*
+Glob style matching -> * (any sequence), ? (any single char), [abc], [^abc], [a-z] and \ for escaping.
+Iterative, going back only to the last * seen, so it takes O(len(pattern) * len(str)) at worst.
+Going back to an earlier * is never needed, the last one can already take whatever the earlier one would have.
+*/
+func GlobMatch(pattern, str string) bool {
+	p, s := 0, 0
+	starP, starS := -1, 0 // pattern index of the last *, and where in str it started matching
+
+	for s < len(str) {
+		if p < len(pattern) && pattern[p] == '*' {
+			starP, starS = p, s
+			p++
+			continue
+		}
+
+		if p < len(pattern) {
+			if matched, next := globMatchChar(pattern, p, str[s]); matched {
+				p, s = next, s+1
+				continue
+			}
+		}
+
+		if starP < 0 {
+			return false
+		}
+
+		// the last * takes one more char
+		starS++
+		p, s = starP+1, starS
+	}
+
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+
+	return p == len(pattern)
+}
+
+// Whether the single char pattern starting at pattern[p] matches c, and the index after it
+func globMatchChar(pattern string, p int, c byte) (bool, int) {
+	switch pattern[p] {
+	case '?':
+		return true, p + 1
+
+	case '[':
+		end := strings.IndexByte(pattern[p+1:], ']')
+		if end < 0 {
+			// unclosed bracket is treated as a literal
+			return c == '[', p + 1
+		}
+
+		class := pattern[p+1 : p+1+end]
+		negate := len(class) > 0 && class[0] == '^'
+		if negate {
+			class = class[1:]
+		}
+
+		matched := false
+		for i := 0; i < len(class); i++ {
+			if i+2 < len(class) && class[i+1] == '-' {
+				if class[i] <= c && c <= class[i+2] {
+					matched = true
+				}
+				i += 2
+			} else if class[i] == c {
+				matched = true
+			}
+		}
+
+		return matched != negate, p + end + 2
+
+	case '\\':
+		if p+1 < len(pattern) {
+			p++
+		}
+	}
+
+	return pattern[p] == c, p + 1
+}
+
+/*
+Cursor based iteration which stays stable while the collection changes between calls.
+Names are visited in the order of their xxhash, and the cursor is the hash of the next name to be returned,
+so elements present for the whole iteration are returned once no matter what is added or removed
+(names sharing a 64 bit hash may be returned twice).
+Every call still goes over all the names, but only keeps the count smallest hashes past the cursor -> O(n log count).
+Returns the next cursor (0 when iteration is complete) and the matched names.
+*/
+func ScanByHash[V any](names map[string]V, cursor uint64, count int, match func(name string) bool) (uint64, []string) {
+	// count + 1 smallest hashes past the cursor, the extra one gives the next cursor
+	batch := make(scanHeap, 0, min(count+1, len(names)))
+
+	for name := range names {
+		hash := xxhash.Sum64String(name)
+		if hash < cursor {
+			continue
+		}
+
+		entry := hashedName{hash, name}
+
+		if len(batch) <= count {
+			heap.Push(&batch, entry)
+		} else if entry.less(batch[0]) {
+			batch[0] = entry
+			heap.Fix(&batch, 0)
+		}
+	}
+
+	next := uint64(0)
+	if len(batch) > count {
+		next = heap.Pop(&batch).(hashedName).hash
+	}
+
+	sort.Slice(batch, func(i, j int) bool { return batch[i].less(batch[j]) })
+
+	result := []string{}
+
+	for _, h := range batch {
+		if match == nil || match(h.name) {
+			result = append(result, h.name)
+		}
+	}
+
+	return next, result
+}
+
+type hashedName struct {
+	hash uint64
+	name string
+}
+
+func (h hashedName) less(other hashedName) bool {
+	if h.hash == other.hash {
+		return h.name < other.name
+	}
+	return h.hash < other.hash
+}
+
+// Max heap of hashedName (container/heap)
+type scanHeap []hashedName
+
+func (h scanHeap) Len() int           { return len(h) }
+func (h scanHeap) Less(i, j int) bool { return h[j].less(h[i]) }
+func (h scanHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *scanHeap) Push(x any) {
+	*h = append(*h, x.(hashedName))
+}
+
+func (h *scanHeap) Pop() any {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}